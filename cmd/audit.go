@@ -8,7 +8,6 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"github.com/khaugen7/eks-security-scanner/internal/kube"
 	"github.com/khaugen7/eks-security-scanner/internal/scanner"
 )
 
@@ -22,7 +21,7 @@ Reports:
 - Dangerously permissive IAM policies`,
 	Run: func(cmd *cobra.Command, args []string) {
 		clusterName, err := cmd.Flags().GetString("cluster")
-		client := kube.GetClient()
+		client := scanClient("", scanner.AuditResources...)
 
 		if err != nil {
 			fmt.Println("Failed to read --cluster flag:", err)
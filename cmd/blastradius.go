@@ -0,0 +1,44 @@
+/*
+Copyright © 2025 Kyle Haugen kylehaugen.dev
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/khaugen7/eks-security-scanner/internal/scanner"
+)
+
+var blastRadiusCmd = &cobra.Command{
+	Use:   "blast-radius",
+	Short: "Show everything reachable from a compromised Pod or ServiceAccount",
+	Long: `Show everything reachable from a compromised Pod or ServiceAccount.
+
+Given a starting identity, this command reports what an attacker who controls it could do:
+  - Kubernetes permissions granted through RoleBindings and ClusterRoleBindings
+  - AWS permissions of the IAM role assumed through IRSA (skipped with --skip-iam)
+  - Secrets readable through RBAC or mounted into the pod
+  - Services the pod can connect to
+  - Ways to escape to the node (privileged containers, host namespaces, hostPath mounts)
+
+The starting identity is given as pod/<namespace>/<name> or sa/<namespace>/<name>.
+
+Example usage:
+  eks-scanner blast-radius --cluster my-eks-cluster --from pod/prod/api-7d9f
+  eks-scanner blast-radius --cluster my-eks-cluster --from sa/prod/api --skip-iam`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		from, _ := cmd.Flags().GetString("from")
+		skipIAM, _ := cmd.Flags().GetBool("skip-iam")
+		client := scanClient("", scanner.BlastRadiusResources...)
+
+		scanner.RunBlastRadiusCheck(from, skipIAM, client)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(blastRadiusCmd)
+	blastRadiusCmd.Flags().String("from", "", "Compromised identity: pod/<namespace>/<name> or sa/<namespace>/<name>")
+	blastRadiusCmd.Flags().Bool("skip-iam", false, "Don't query AWS IAM for the policies of the assumed role")
+	blastRadiusCmd.MarkFlagRequired("from")
+}
@@ -6,38 +6,97 @@ package cmd
 import (
 	"github.com/spf13/cobra"
 
-	"github.com/khaugen7/eks-security-scanner/internal/kube"
 	"github.com/khaugen7/eks-security-scanner/internal/scanner"
 )
 
 var graphCmd = &cobra.Command{
 	Use:   "graph",
-	Short: "Generate a threat graph of your EKS cluster in ASCII (default) or DOT format",
+	Short: "Generate a threat graph of your EKS cluster in ASCII (default), DOT or Mermaid format",
 	Long: `Generate a threat graph of your EKS cluster by analyzing relationships between Pods, Services, Endpoints, ServiceAccounts, and IAM roles.
 
 	This command models potential attack paths by mapping:
-	- Pod → ServiceAccount → IAM Role (via IRSA)
+	- Pod → ServiceAccount → IAM Role (via IRSA; pods that do not automount the
+	  ServiceAccount token are linked with uses-no-token, and straight to the
+	  IAM role with web-identity since the webhook still injects its token)
 	- Pod → Service (based on label selectors)
-	- Service → Endpoint (pod IPs behind services)
+	- Service → Pod (from EndpointSlice targetRefs)
+	- Internet → LoadBalancer/NodePort Services, Ingresses and Gateways
+	- ServiceAccount/User/Group → Role → resource (RBAC capabilities)
+	- Pod → Node → IAM Role (container escape to the instance profile, looked up
+	  in AWS unless --skip-iam is set)
+	- Pod → Secret/ConfigMap (by mount method)
+
+	IAM roles with admin-equivalent policies are highlighted, unless --skip-iam
+	is set.
+
+	Pods isolated by NetworkPolicies are annotated with whether traffic from
+	the internet (a public ipBlock) can still reach them.
 
 	The resulting graph helps visualize the blast radius of a compromised Pod or identity.
 
-	You can output the graph in either:
+	You can output the graph in:
 	- ASCII (default) for quick CLI inspection
-	- DOT (Graphviz format) for advanced visualization or reporting
+	- DOT (Graphviz format), colored by risk and clustered by namespace, for reports
+	- Mermaid for rendering in GitHub/GitLab markdown and wikis, with a subgraph per namespace
+	- GraphML for yEd, Gephi and other graph tools
+	- Cypher MERGE statements for loading into Neo4j; rerunning updates the existing data
+	- HTML: a single offline page (written to --out) with pan/zoom, search,
+	  namespace filtering, high-risk highlighting and per-node findings
+
+	On large clusters, cut the graph down with --focus/--depth (the neighbourhood
+	of one node), --kinds, --only-risky and --collapse-replicas (one node per
+	owning controller instead of one per pod).
+
+	With --reachability, the command instead prints the pod-to-pod allow matrix
+	computed from NetworkPolicies (pod and namespace selectors, ipBlocks, ports).
 
 	Example usage:
 	eks-scanner graph --cluster my-eks-cluster
-	eks-scanner graph --cluster my-eks-cluster --format dot`,
+	eks-scanner graph --cluster my-eks-cluster --format dot
+	eks-scanner graph --cluster my-eks-cluster --format mermaid > threat-graph.md
+	eks-scanner graph --cluster my-eks-cluster --format cypher | cypher-shell -u neo4j
+	eks-scanner graph --cluster my-eks-cluster --format html --out threat-graph.html
+	eks-scanner graph --cluster my-eks-cluster --focus pod/prod/api-7d9f --depth 3
+	eks-scanner graph --cluster my-eks-cluster --kinds pod,sa,iam-role --only-risky --collapse-replicas
+	eks-scanner graph --cluster my-eks-cluster --namespace prod --reachability`,
 	Run: func(cmd *cobra.Command, args []string) {
 		outputFormat, _ := cmd.Flags().GetString("format")
 		namespace, _ := cmd.Flags().GetString("namespace")
-		client := kube.GetClient()
+		reachability, _ := cmd.Flags().GetBool("reachability")
+		outFile, _ := cmd.Flags().GetString("out")
+		focus, _ := cmd.Flags().GetString("focus")
+		depth, _ := cmd.Flags().GetInt("depth")
+		kinds, _ := cmd.Flags().GetStringSlice("kinds")
+		onlyRisky, _ := cmd.Flags().GetBool("only-risky")
+		collapseReplicas, _ := cmd.Flags().GetBool("collapse-replicas")
+		skipIAM, _ := cmd.Flags().GetBool("skip-iam")
+		client := scanClient(namespace, scanner.GraphResources...)
 
-		scanner.RunGraphCheck(outputFormat, namespace, client)
+		if reachability {
+			scanner.RunReachabilityCheck(namespace, client)
+			return
+		}
+		scanner.RunGraphCheck(namespace, scanner.GraphOptions{
+			Format:           outputFormat,
+			OutFile:          outFile,
+			Focus:            focus,
+			Depth:            depth,
+			Kinds:            kinds,
+			OnlyRisky:        onlyRisky,
+			CollapseReplicas: collapseReplicas,
+			SkipIAM:          skipIAM,
+		}, client)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(graphCmd)
+	graphCmd.Flags().Bool("reachability", false, "Print the pod-to-pod NetworkPolicy allow matrix instead of the graph")
+	graphCmd.Flags().StringP("out", "o", scanner.DefaultHTMLGraphFile, "File the html format writes the interactive graph to")
+	graphCmd.Flags().String("focus", "", "Only show the neighbourhood of this node, e.g. pod/prod/api-7d9f")
+	graphCmd.Flags().Int("depth", 2, "Hops to include around --focus in either direction (0 for no limit)")
+	graphCmd.Flags().StringSlice("kinds", nil, "Only show nodes of these kinds, e.g. pod,sa,iam-role (comma-separated)")
+	graphCmd.Flags().Bool("only-risky", false, "Only show nodes with a risk score or finding")
+	graphCmd.Flags().Bool("collapse-replicas", false, "Group pods by their owning Deployment, StatefulSet, DaemonSet or Job")
+	graphCmd.Flags().Bool("skip-iam", false, "Don't query AWS for node instance profile roles or admin-equivalent IAM policies")
 }
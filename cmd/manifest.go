@@ -0,0 +1,94 @@
+/*
+Copyright © 2025 Kyle Haugen kylehaugen.dev
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/khaugen7/eks-security-scanner/internal/manifest"
+	"github.com/khaugen7/eks-security-scanner/internal/scanner"
+)
+
+var manifestCmd = &cobra.Command{
+	Use:   "manifest",
+	Short: "Scan Kubernetes manifests before they reach the cluster",
+	// Manifest scans never talk to a cluster, so --cluster is not required.
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		_ = cmd.Flags().SetAnnotation("cluster", cobra.BashCompOneRequiredFlag, []string{"false"})
+	},
+}
+
+var manifestScanCmd = &cobra.Command{
+	Use:   "scan <files|dirs|kustomizations|->",
+	Short: "Run the privilege, namespace and RBAC checks against YAML or JSON manifests",
+	Long: `Decode multi-document YAML and JSON manifests and run the privilege, namespace
+and RBAC checks against them, reporting the file and line of each finding.
+
+Directories are searched recursively for .yaml, .yml and .json files; "-" reads
+from standard input, so Helm output can be piped in. Objects rendered by
+helm template are also attributed to the chart template they came from.
+
+A directory holding a kustomization.yaml is rendered with kustomize, as
+kustomize build would, instead of being searched. Findings name the file each
+object came from and the overlay that rendered it, and every overlay is
+checked on its own.
+
+Scanned kinds:
+  - Pods and workload controllers (Deployments, StatefulSets, DaemonSets,
+    ReplicaSets, ReplicationControllers, Jobs and CronJobs), checked as the
+    pod their template creates
+  - ServiceAccounts, Secrets (metadata only), Roles, ClusterRoles,
+    RoleBindings and ClusterRoleBindings
+  - Namespaces, ResourceQuotas, LimitRanges and NetworkPolicies
+
+ResourceQuota and LimitRange checks only run for namespaces whose Namespace
+object is among the manifests. Objects without a namespace are placed in
+default. Other kinds, such as custom resources, are skipped.
+
+Example usage:
+  eks-scanner manifest scan k8s/
+  eks-scanner manifest scan overlays/prod overlays/staging
+  eks-scanner manifest scan deploy.yaml rbac.yaml --fail-on HIGH
+  helm template my-release ./chart | eks-scanner manifest scan -`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		failOn, _ := cmd.Flags().GetString("fail-on")
+		allowedHostPaths, _ := cmd.Flags().GetStringSlice("allow-hostpath")
+		allowedRegistries, _ := cmd.Flags().GetStringSlice("allowed-registries")
+		if failOn != "" && !scanner.ValidSeverity(failOn) {
+			fmt.Fprintf(os.Stderr, "Invalid --fail-on %q: use LOW, MED, HIGH or CRITICAL\n", failOn)
+			os.Exit(2)
+		}
+
+		res, err := manifest.Load(args, os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read manifests: %v\n", err)
+			os.Exit(2)
+		}
+
+		findings := scanner.RunManifestCheck(res, scanner.PrivilegeOptions{
+			AllowedHostPaths:  allowedHostPaths,
+			AllowedRegistries: allowedRegistries,
+		})
+		if failOn == "" {
+			return
+		}
+		for _, f := range findings {
+			if scanner.SeverityAtLeast(f.Severity, failOn) {
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(manifestCmd)
+	manifestCmd.AddCommand(manifestScanCmd)
+	manifestScanCmd.Flags().String("fail-on", "", "Exit with status 1 if any finding is at or above this severity: LOW, MED, HIGH or CRITICAL")
+	manifestScanCmd.Flags().StringSlice("allow-hostpath", nil, "hostPath volumes that are expected and should not be reported (comma-separated)")
+	manifestScanCmd.Flags().StringSlice("allowed-registries", nil, "Registry host globs or registry/repository prefixes images may be pulled from (comma-separated)")
+}
@@ -6,7 +6,6 @@ package cmd
 import (
 	"github.com/spf13/cobra"
 
-	"github.com/khaugen7/eks-security-scanner/internal/kube"
 	"github.com/khaugen7/eks-security-scanner/internal/scanner"
 )
 
@@ -16,9 +15,14 @@ var namespaceCmd = &cobra.Command{
 	Long: `Scan Kubernetes namespace(s) for security misconfigurations and over-permissive defaults.
 
 This check identifies common namespace-level risks including:
-  - Missing ResourceQuotas: Pods can consume unlimited CPU/memory
-  - Missing LimitRanges: Containers may run without resource limits
-  - Overuse of the default ServiceAccount: increases blast radius
+  - Missing or ineffective ResourceQuotas: quotas must constrain requests.cpu, limits.memory,
+    pods and object counts, and stay below the cluster's allocatable capacity
+  - Missing LimitRanges or LimitRanges without default container CPU/memory limits
+  - Containers running without CPU/memory requests or limits
+  - Pods running as the default ServiceAccount: increases blast radius
+  - Automounted ServiceAccount tokens that carry RBAC permissions
+  - Legacy long-lived ServiceAccount token Secrets
+  - ServiceAccounts with mounted tokens but no bindings (candidates to disable automount)
   - Dangerous RoleBindings: default SA bound to cluster-admin or other powerful roles
 
 These issues often go unnoticed in development clusters or shared environments and can lead to privilege escalation, denial of service, or full cluster compromise if left unchecked.
@@ -28,7 +32,7 @@ Example usage:
 
 	Run: func(cmd *cobra.Command, args []string) {
 		namespace, _ := rootCmd.Flags().GetString("namespace")
-		client := kube.GetClient()
+		client := scanClient(namespace, scanner.NamespaceResources...)
 		scanner.RunNamespaceCheck(namespace, client)
 	},
 }
@@ -0,0 +1,54 @@
+/*
+Copyright © 2025 Kyle Haugen kylehaugen.dev
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/khaugen7/eks-security-scanner/internal/scanner"
+)
+
+var pathsCmd = &cobra.Command{
+	Use:   "paths",
+	Short: "Find and rank attack paths from exposed entry points to high-value targets",
+	Long: `Find and rank attack paths from exposed entry points to high-value targets.
+
+This command builds the threat graph and searches it for routes an attacker could take from:
+  - LoadBalancer and NodePort Services
+  - Ingress backends
+  - Privileged Pods
+
+to:
+  - ServiceAccounts bound to cluster-admin
+  - IAM roles with admin-equivalent policies (looked up in AWS unless --skip-iam is set)
+  - Other IAM roles, Secrets and Nodes
+
+Each path is printed hop by hop with the reason the hop is possible, ranked by
+target value, entry point exposure and path length.
+
+Example usage:
+  eks-scanner paths --cluster my-eks-cluster
+  eks-scanner paths --cluster my-eks-cluster --namespace prod --limit 10 --skip-iam`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		namespace, _ := rootCmd.Flags().GetString("namespace")
+		maxDepth, _ := cmd.Flags().GetInt("max-depth")
+		limit, _ := cmd.Flags().GetInt("limit")
+		skipIAM, _ := cmd.Flags().GetBool("skip-iam")
+		client := scanClient(namespace, scanner.PathsResources...)
+
+		scanner.RunPathsCheck(namespace, scanner.PathOptions{
+			MaxDepth: maxDepth,
+			Limit:    limit,
+			SkipIAM:  skipIAM,
+		}, client)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pathsCmd)
+	pathsCmd.Flags().Int("max-depth", 8, "Maximum number of hops in a path (0 for unlimited)")
+	pathsCmd.Flags().Int("limit", 25, "Maximum number of paths to print (0 for all)")
+	pathsCmd.Flags().Bool("skip-iam", false, "Don't query AWS IAM to identify admin-equivalent roles")
+}
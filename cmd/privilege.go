@@ -8,7 +8,6 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"github.com/khaugen7/eks-security-scanner/internal/kube"
 	"github.com/khaugen7/eks-security-scanner/internal/scanner"
 )
 
@@ -19,15 +18,38 @@ var privilegeCmd = &cobra.Command{
 	Long: `Scans all Pods and highlights:
 - privileged: true or hostPID/hostNetwork/hostPath
 - Containers running as root
-- Dangerous Linux capabilities`,
+- Dangerous Linux capabilities
+
+hostPath volumes are graded by path: the host root, /etc, /proc, /root,
+/var/lib/kubelet and container runtime sockets, and any directory containing
+them such as /var/run, are CRITICAL, read-only log directories are LOW and
+everything else is HIGH. Paths that are expected in
+your cluster can be excluded with --allow-hostpath.
+
+Container images are checked for supply-chain risks:
+- :latest or untagged images, and images not pinned by digest
+- Registries outside --allowed-registries (when set)
+- Mutable tags combined with imagePullPolicy IfNotPresent or Never
+- Running digests that differ from the spec or between pods (tag drift)
+
+Example usage:
+  eks-scanner privilege --cluster my-eks-cluster --allow-hostpath /proc,/sys
+  eks-scanner privilege --cluster my-eks-cluster --allowed-registries '123456789012.dkr.ecr.*.amazonaws.com'`,
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("Running privileges scan...")
 		namespace, _ := rootCmd.Flags().GetString("namespace")
-		client := kube.GetClient()
-		scanner.RunPrivilegeCheck(namespace, client)
+		allowedHostPaths, _ := cmd.Flags().GetStringSlice("allow-hostpath")
+		allowedRegistries, _ := cmd.Flags().GetStringSlice("allowed-registries")
+		client := scanClient(namespace, scanner.PrivilegeResources...)
+		scanner.RunPrivilegeCheck(namespace, scanner.PrivilegeOptions{
+			AllowedHostPaths:  allowedHostPaths,
+			AllowedRegistries: allowedRegistries,
+		}, client)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(privilegeCmd)
+	privilegeCmd.Flags().StringSlice("allow-hostpath", nil, "hostPath volumes expected in the cluster that should not be reported (comma-separated)")
+	privilegeCmd.Flags().StringSlice("allowed-registries", nil, "Registry host globs or registry/repository prefixes images may be pulled from (comma-separated)")
 }
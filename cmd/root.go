@@ -4,26 +4,34 @@ Copyright © 2025 Kyle Haugen kylehaugen.dev
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
 
 	"github.com/khaugen7/eks-security-scanner/internal/kube"
 	"github.com/khaugen7/eks-security-scanner/internal/scanner"
+	"github.com/khaugen7/eks-security-scanner/internal/snapshot"
 )
 
 var allChecks bool
 var clusterName string
 var outputFormat string
 var namespace string
+var fromSnapshot string
+var kubeOptions kube.Options
 
 func init() {
 	rootCmd.PersistentFlags().BoolVarP(&allChecks, "all", "a", false, "Run all checks")
 	rootCmd.PersistentFlags().StringVarP(&clusterName, "cluster", "c", "", "Name of the EKS cluster to scan (required)")
 	rootCmd.MarkPersistentFlagRequired("cluster")
 	rootCmd.PersistentFlags().StringVarP(&namespace, "namespace", "n", "", "Name of the namespace scan")
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "ascii", "Output format: ascii or dot")
+	rootCmd.PersistentFlags().StringVar(&kubeOptions.Kubeconfig, "kubeconfig", "", "Path to the kubeconfig file (default: KUBECONFIG, then ~/.kube/config, then in-cluster)")
+	rootCmd.PersistentFlags().StringVar(&kubeOptions.Context, "context", "", "Kubeconfig context to use instead of the current context")
+	rootCmd.PersistentFlags().StringVar(&fromSnapshot, "from-snapshot", "", "Scan a snapshot saved with 'snapshot save' instead of the live cluster")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "ascii", "Output format: ascii, dot, mermaid, graphml, cypher or html")
 }
 
 var rootCmd = &cobra.Command{
@@ -31,19 +39,60 @@ var rootCmd = &cobra.Command{
 	Short: "Scan your EKS cluster for common security misconfigurations",
 	Run: func(cmd *cobra.Command, args []string) {
 		if allChecks {
-			client := kube.GetClient()
+			var resources []string
+			for _, r := range [][]string{
+				scanner.AuditResources, scanner.PrivilegeResources, scanner.NamespaceResources,
+				scanner.SecretsResources, scanner.GraphResources,
+			} {
+				resources = append(resources, r...)
+			}
+			client := scanClient(namespace, resources...)
 			fmt.Println("Running all checks...")
 			// Run all scanners
 			scanner.RunAuditCheck(clusterName, client)
-			scanner.RunPrivilegeCheck(namespace, client)
+			scanner.RunPrivilegeCheck(namespace, scanner.PrivilegeOptions{}, client)
 			scanner.RunNamespaceCheck(namespace, client)
-			scanner.RunGraphCheck(outputFormat, namespace, client)
+			scanner.RunSecretsCheck(namespace, client)
+			scanner.RunGraphCheck(namespace, scanner.GraphOptions{Format: outputFormat}, client)
 		} else {
 			_ = cmd.Help()
 		}
 	},
 }
 
+// scanClient returns the client scanners read from: a snapshot of the cluster
+// collected once, page by page, so that every scanner in a run sees the same
+// state and no resource is listed twice. Only the resources the scanners read
+// are collected, from namespace if set, so a scan needs no more RBAC than the
+// scanners themselves. With --from-snapshot the snapshot is read from disk and
+// AWS calls are answered from it as well.
+func scanClient(namespace string, resources ...string) kubernetes.Interface {
+	if fromSnapshot == "" {
+		scope := snapshot.Scope{Namespace: namespace, Resources: resources}
+		return snapshot.Collect(context.TODO(), kubeClient(), scope).Client()
+	}
+
+	snap, err := snapshot.Load(fromSnapshot)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load snapshot: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "[i] Scanning snapshot of %s taken %s\n", snap.Cluster, snap.CollectedAt.Format("2006-01-02 15:04 MST"))
+	scanner.LoadAWSConfig = snap.ReplayAWS
+	return snap.Client()
+}
+
+// kubeClient connects to the cluster selected by --kubeconfig and --context,
+// exiting with the reason if that is not possible.
+func kubeClient() kubernetes.Interface {
+	client, err := kube.GetClient(kubeOptions)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect to the cluster: %v\n", err)
+		os.Exit(1)
+	}
+	return client
+}
+
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
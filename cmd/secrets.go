@@ -0,0 +1,39 @@
+/*
+Copyright © 2025 Kyle Haugen kylehaugen.dev
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/khaugen7/eks-security-scanner/internal/scanner"
+)
+
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Scan env vars, ConfigMaps and pod annotations for exposed credentials",
+	Long: `Scan env vars, ConfigMaps and pod annotations for exposed credentials.
+
+Secrets belong in Kubernetes Secrets or an external secret store, not in plain
+env values or ConfigMaps that anyone with read access can see. This check reports:
+  - Known credential formats: AWS access keys, private keys, JWTs and database URLs with passwords (HIGH)
+  - High-entropy strings that look like API keys or tokens (MED)
+
+ConfigMap binaryData values are checked as text, which finds kubeconfigs and
+PEM keys stored there; binary formats such as Java keystores are not parsed.
+
+Only the resource and key are reported; secret values are never printed.
+
+Example usage:
+  eks-scanner secrets --cluster my-eks-cluster --namespace dev`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		namespace, _ := rootCmd.Flags().GetString("namespace")
+		client := scanClient(namespace, scanner.SecretsResources...)
+		scanner.RunSecretsCheck(namespace, client)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(secretsCmd)
+}
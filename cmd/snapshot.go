@@ -0,0 +1,75 @@
+/*
+Copyright © 2025 Kyle Haugen kylehaugen.dev
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/spf13/cobra"
+
+	"github.com/khaugen7/eks-security-scanner/internal/scanner"
+	"github.com/khaugen7/eks-security-scanner/internal/snapshot"
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Save cluster state for offline scanning",
+	Long: `Capture everything the scanners read from Kubernetes and AWS into a single
+file, then scan it later without credentials by passing --from-snapshot to any
+command.
+
+Secret values are never written to the snapshot: only Secret metadata is kept,
+without managedFields or the kubectl last-applied-configuration annotation.`,
+}
+
+var snapshotSaveCmd = &cobra.Command{
+	Use:   "save",
+	Short: "Save a snapshot of the cluster and its AWS data to a file",
+	Long: `Collect every Kubernetes resource the scanners use, across all namespaces, and
+record the EKS, IAM and EC2 API responses they need. The snapshot is written as
+JSON, gzip-compressed when the file name ends in .gz.
+
+Example usage:
+  eks-scanner snapshot save --cluster my-eks-cluster --out snap.json.gz
+  eks-scanner privilege --cluster my-eks-cluster --from-snapshot snap.json.gz
+  eks-scanner --all --cluster my-eks-cluster --from-snapshot snap.json.gz`,
+	Run: func(cmd *cobra.Command, args []string) {
+		out, _ := cmd.Flags().GetString("out")
+		skipAWS, _ := cmd.Flags().GetBool("skip-aws")
+
+		snap := snapshot.Collect(context.TODO(), kubeClient(), snapshot.Scope{})
+		snap.Cluster = clusterName
+		for resource, err := range snap.Errors {
+			fmt.Printf("[!] Could not list %s: %s\n", resource, err)
+		}
+
+		if !skipAWS {
+			cfg, err := config.LoadDefaultConfig(context.TODO())
+			if err != nil {
+				fmt.Printf("[!] Skipping AWS data: unable to load AWS config: %v\n", err)
+			} else {
+				recording := snap.RecordAWS(cfg)
+				scanner.LoadAWSConfig = func(context.Context) (aws.Config, error) { return recording, nil }
+				scanner.PrefetchAWS(clusterName, snap.Client())
+			}
+		}
+
+		if err := snap.Save(out); err != nil {
+			fmt.Printf("Failed to save snapshot: %v\n", err)
+			return
+		}
+		fmt.Printf("[✓] Snapshot of %s saved to %s (%d pods, %d namespaces, %d AWS responses)\n",
+			clusterName, out, len(snap.Pods), len(snap.Namespaces), len(snap.AWS))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(snapshotCmd)
+	snapshotCmd.AddCommand(snapshotSaveCmd)
+	snapshotSaveCmd.Flags().String("out", "snapshot.json.gz", "File to write the snapshot to")
+	snapshotSaveCmd.Flags().Bool("skip-aws", false, "Do not record EKS, IAM and EC2 API responses")
+}
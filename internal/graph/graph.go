@@ -0,0 +1,264 @@
+// Package graph models the cluster threat graph: typed nodes for Kubernetes
+// and AWS resources, de-duplicated labelled edges between them, and the
+// traversal helpers that renderers and analyses are built on.
+package graph
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Kind identifies the type of resource a node represents. The value doubles
+// as the node ID prefix, e.g. pod/ns/name.
+type Kind string
+
+const (
+	KindPod            Kind = "pod"
+	KindService        Kind = "svc"
+	KindEndpoint       Kind = "ep"
+	KindServiceAccount Kind = "sa"
+	KindIAMRole        Kind = "iam-role"
+	KindIngress        Kind = "ing"
+	KindSecret         Kind = "secret"
+	KindConfigMap      Kind = "cm"
+	KindNode           Kind = "node"
+	KindRole           Kind = "role"
+	KindClusterRole    Kind = "clusterrole"
+	KindUser           Kind = "user"
+	KindGroup          Kind = "group"
+
+	// KindResource is a Kubernetes resource type an RBAC role grants access
+	// to, e.g. secrets or pods/exec. Namespace is the scope of the grant.
+	KindResource Kind = "resource"
+
+	// KindInternet is the single node standing for traffic from outside the
+	// cluster. Its ID is just "internet".
+	KindInternet Kind = "internet"
+	KindGateway  Kind = "gw"
+	KindRoute    Kind = "route"
+)
+
+// Internet is the node external exposure edges start from.
+var Internet = Node{Kind: KindInternet, Name: "internet"}
+
+// Node is a resource in the graph. Namespace is empty for cluster-scoped and
+// AWS resources.
+type Node struct {
+	Kind      Kind
+	Namespace string
+	Name      string
+
+	// Attrs holds resource details that analyses and renderers key off,
+	// e.g. "privileged" on pods or "arn" on IAM roles.
+	Attrs map[string]string
+
+	// Risk is a 0-10 score of how valuable the node is to an attacker or
+	// how exposed it is. Zero means no known risk.
+	Risk int
+}
+
+// ID returns the node's unique identifier: kind/namespace/name for
+// namespaced resources and kind/name otherwise.
+func (n Node) ID() string {
+	if n.Kind == KindInternet {
+		return string(KindInternet)
+	}
+	if n.Namespace == "" {
+		return fmt.Sprintf("%s/%s", n.Kind, n.Name)
+	}
+	return fmt.Sprintf("%s/%s/%s", n.Kind, n.Namespace, n.Name)
+}
+
+// DisplayName is the ID without the kind prefix.
+func (n Node) DisplayName() string {
+	if n.Namespace == "" {
+		return n.Name
+	}
+	return n.Namespace + "/" + n.Name
+}
+
+// Attr returns the named attribute, or "" if unset.
+func (n Node) Attr(key string) string {
+	return n.Attrs[key]
+}
+
+// Edge is a directed, labelled relationship between two node IDs.
+type Edge struct {
+	From  string
+	To    string
+	Label string
+}
+
+// Graph is a directed multigraph with at most one edge per (from, to, label).
+// Nodes and edges are kept in insertion order so output is stable.
+type Graph struct {
+	nodes map[string]*Node
+	order []string
+
+	edges   []Edge
+	edgeSet map[Edge]bool
+	out     map[string][]int
+	in      map[string][]int
+}
+
+func New() *Graph {
+	return &Graph{
+		nodes:   make(map[string]*Node),
+		edgeSet: make(map[Edge]bool),
+		out:     make(map[string][]int),
+		in:      make(map[string][]int),
+	}
+}
+
+// AddNode inserts n, or merges it into the existing node with the same ID:
+// attributes are combined and the higher risk score wins. It returns the
+// stored node.
+func (g *Graph) AddNode(n Node) *Node {
+	id := n.ID()
+	existing, ok := g.nodes[id]
+	if !ok {
+		stored := n
+		stored.Attrs = make(map[string]string, len(n.Attrs))
+		for k, v := range n.Attrs {
+			stored.Attrs[k] = v
+		}
+		g.nodes[id] = &stored
+		g.order = append(g.order, id)
+		return &stored
+	}
+
+	for k, v := range n.Attrs {
+		existing.Attrs[k] = v
+	}
+	if n.Risk > existing.Risk {
+		existing.Risk = n.Risk
+	}
+	return existing
+}
+
+// AddEdge adds both nodes and a labelled edge between them. It reports
+// whether the edge was new.
+func (g *Graph) AddEdge(from, to Node, label string) bool {
+	g.AddNode(from)
+	g.AddNode(to)
+	return g.Connect(from.ID(), to.ID(), label)
+}
+
+// Connect adds a labelled edge between two existing nodes. It reports whether
+// the edge was new; edges to unknown nodes are ignored.
+func (g *Graph) Connect(from, to, label string) bool {
+	if g.nodes[from] == nil || g.nodes[to] == nil {
+		return false
+	}
+	e := Edge{From: from, To: to, Label: label}
+	if g.edgeSet[e] {
+		return false
+	}
+	g.edgeSet[e] = true
+	g.edges = append(g.edges, e)
+	g.out[from] = append(g.out[from], len(g.edges)-1)
+	g.in[to] = append(g.in[to], len(g.edges)-1)
+	return true
+}
+
+// Node looks up a node by ID.
+func (g *Graph) Node(id string) (*Node, bool) {
+	n, ok := g.nodes[id]
+	return n, ok
+}
+
+// Nodes returns every node in insertion order.
+func (g *Graph) Nodes() []*Node {
+	nodes := make([]*Node, 0, len(g.order))
+	for _, id := range g.order {
+		nodes = append(nodes, g.nodes[id])
+	}
+	return nodes
+}
+
+// NodesOfKind returns the nodes of the given kind in insertion order.
+func (g *Graph) NodesOfKind(kind Kind) []*Node {
+	var nodes []*Node
+	for _, id := range g.order {
+		if g.nodes[id].Kind == kind {
+			nodes = append(nodes, g.nodes[id])
+		}
+	}
+	return nodes
+}
+
+// Edges returns every edge in insertion order.
+func (g *Graph) Edges() []Edge {
+	return append([]Edge(nil), g.edges...)
+}
+
+// Out returns the edges leaving id in insertion order.
+func (g *Graph) Out(id string) []Edge {
+	return g.collect(g.out[id])
+}
+
+// In returns the edges entering id in insertion order.
+func (g *Graph) In(id string) []Edge {
+	return g.collect(g.in[id])
+}
+
+func (g *Graph) collect(idx []int) []Edge {
+	edges := make([]Edge, 0, len(idx))
+	for _, i := range idx {
+		edges = append(edges, g.edges[i])
+	}
+	return edges
+}
+
+// Sources returns the IDs of nodes with at least one outgoing edge, sorted.
+func (g *Graph) Sources() []string {
+	sources := make([]string, 0, len(g.out))
+	for id := range g.out {
+		sources = append(sources, id)
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// Len returns the number of nodes and edges.
+func (g *Graph) Len() (nodes, edges int) {
+	return len(g.nodes), len(g.edges)
+}
+
+// Subgraph returns a copy of the graph holding the nodes keep accepts and the
+// edges between them. Insertion order is preserved.
+func (g *Graph) Subgraph(keep func(*Node) bool) *Graph {
+	sub := New()
+	for _, n := range g.Nodes() {
+		if keep(n) {
+			sub.AddNode(*n)
+		}
+	}
+	for _, e := range g.edges {
+		sub.Connect(e.From, e.To, e.Label)
+	}
+	return sub
+}
+
+// Collapse returns a copy of the graph in which every node that group maps
+// to a representative is replaced by it. Nodes sharing a representative are
+// merged as by AddNode, their edges are redirected to it, and edges that
+// would become self-loops are dropped.
+func (g *Graph) Collapse(group func(*Node) (Node, bool)) *Graph {
+	collapsed := New()
+	ids := make(map[string]string, len(g.nodes))
+	for _, n := range g.Nodes() {
+		rep, ok := group(n)
+		if !ok {
+			rep = *n
+		}
+		ids[n.ID()] = collapsed.AddNode(rep).ID()
+	}
+	for _, e := range g.edges {
+		from, to := ids[e.From], ids[e.To]
+		if from != to {
+			collapsed.Connect(from, to, e.Label)
+		}
+	}
+	return collapsed
+}
@@ -0,0 +1,175 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func node(kind Kind, ns, name string) Node {
+	return Node{Kind: kind, Namespace: ns, Name: name}
+}
+
+func TestNodeID(t *testing.T) {
+	if got := node(KindPod, "ns", "p").ID(); got != "pod/ns/p" {
+		t.Errorf("ID() = %q; want pod/ns/p", got)
+	}
+	if got := node(KindIAMRole, "", "Admin").ID(); got != "iam-role/Admin" {
+		t.Errorf("ID() = %q; want iam-role/Admin", got)
+	}
+}
+
+func TestAddNodeMerges(t *testing.T) {
+	g := New()
+	g.AddNode(Node{Kind: KindPod, Namespace: "ns", Name: "p", Attrs: map[string]string{"a": "1"}, Risk: 3})
+	g.AddNode(Node{Kind: KindPod, Namespace: "ns", Name: "p", Attrs: map[string]string{"b": "2"}, Risk: 1})
+
+	n, ok := g.Node("pod/ns/p")
+	if !ok {
+		t.Fatal("node not found")
+	}
+	if n.Attr("a") != "1" || n.Attr("b") != "2" {
+		t.Errorf("attributes not merged: %v", n.Attrs)
+	}
+	if n.Risk != 3 {
+		t.Errorf("Risk = %d; want highest score 3", n.Risk)
+	}
+	if nodes, _ := g.Len(); nodes != 1 {
+		t.Errorf("expected 1 node, got %d", nodes)
+	}
+}
+
+func TestAddEdgeDeduplicates(t *testing.T) {
+	g := New()
+	pod, sa := node(KindPod, "ns", "p"), node(KindServiceAccount, "ns", "sa")
+
+	if !g.AddEdge(pod, sa, "uses") {
+		t.Error("expected first edge to be added")
+	}
+	if g.AddEdge(pod, sa, "uses") {
+		t.Error("expected duplicate edge to be ignored")
+	}
+	if !g.AddEdge(pod, sa, "other") {
+		t.Error("expected edge with a different label to be added")
+	}
+	if g.Connect("pod/ns/p", "pod/ns/missing", "x") {
+		t.Error("expected edge to unknown node to be ignored")
+	}
+
+	if _, edges := g.Len(); edges != 2 {
+		t.Errorf("expected 2 edges, got %d", edges)
+	}
+	if len(g.Out("pod/ns/p")) != 2 || len(g.In("sa/ns/sa")) != 2 {
+		t.Error("adjacency indexes out of sync")
+	}
+}
+
+func TestNodesOfKind(t *testing.T) {
+	g := New()
+	g.AddEdge(node(KindPod, "ns", "b"), node(KindServiceAccount, "ns", "sa"), "uses")
+	g.AddEdge(node(KindPod, "ns", "a"), node(KindServiceAccount, "ns", "sa"), "uses")
+
+	var ids []string
+	for _, n := range g.NodesOfKind(KindPod) {
+		ids = append(ids, n.ID())
+	}
+	if !reflect.DeepEqual(ids, []string{"pod/ns/b", "pod/ns/a"}) {
+		t.Errorf("NodesOfKind = %v", ids)
+	}
+	if !reflect.DeepEqual(g.Sources(), []string{"pod/ns/a", "pod/ns/b"}) {
+		t.Errorf("Sources = %v", g.Sources())
+	}
+}
+
+func TestShortestPaths(t *testing.T) {
+	// svc ← pod → sa → role, plus a longer pod → other → role detour
+	g := New()
+	pod, svc, sa := node(KindPod, "ns", "p"), node(KindService, "ns", "s"), node(KindServiceAccount, "ns", "sa")
+	role, other := node(KindIAMRole, "", "Admin"), node(KindServiceAccount, "ns", "other")
+	g.AddEdge(pod, svc, "matches")
+	g.AddEdge(pod, sa, "uses")
+	g.AddEdge(sa, role, "assumes")
+	g.AddEdge(pod, other, "x")
+	g.AddEdge(other, sa, "y")
+
+	p, ok := g.ShortestPath("pod/ns/p", "iam-role/Admin", Forward)
+	if !ok {
+		t.Fatal("expected a path to the role")
+	}
+	if !reflect.DeepEqual(p.Nodes(), []string{"pod/ns/p", "sa/ns/sa", "iam-role/Admin"}) {
+		t.Errorf("path = %v", p.Nodes())
+	}
+
+	// The service only reaches the pod against edge direction.
+	if _, ok := g.ShortestPath("svc/ns/s", "iam-role/Admin", Forward); ok {
+		t.Error("expected no forward path from service")
+	}
+	p, ok = g.ShortestPath("svc/ns/s", "iam-role/Admin", Undirected)
+	if !ok || !p[0].Reverse || p[0].To() != "pod/ns/p" {
+		t.Errorf("expected reverse first hop, got %+v", p)
+	}
+
+	if got := g.Reachable("pod/ns/p", 1, Forward); !reflect.DeepEqual(got, []string{"svc/ns/s", "sa/ns/sa", "sa/ns/other"}) {
+		t.Errorf("Reachable depth 1 = %v", got)
+	}
+	if got := g.ShortestPaths("pod/ns/p", 0, Forward); len(got) != 5 || len(got["pod/ns/p"]) != 0 {
+		t.Errorf("ShortestPaths = %v", got)
+	}
+}
+
+func TestInternetID(t *testing.T) {
+	if got := Internet.ID(); got != "internet" {
+		t.Errorf("Internet.ID() = %q, want internet", got)
+	}
+	if got := Internet.DisplayName(); got != "internet" {
+		t.Errorf("Internet.DisplayName() = %q, want internet", got)
+	}
+}
+
+func TestSubgraph(t *testing.T) {
+	g := New()
+	g.AddEdge(node(KindPod, "ns", "p"), node(KindServiceAccount, "ns", "sa"), "uses")
+	g.AddEdge(node(KindServiceAccount, "ns", "sa"), node(KindIAMRole, "", "R"), "assumes")
+
+	sub := g.Subgraph(func(n *Node) bool { return n.Kind != KindIAMRole })
+	if nodes, edges := sub.Len(); nodes != 2 || edges != 1 {
+		t.Errorf("Len() = %d, %d; want 2, 1", nodes, edges)
+	}
+	if _, ok := sub.Node("iam-role/R"); ok {
+		t.Error("filtered node should be dropped")
+	}
+	if nodes, edges := g.Len(); nodes != 3 || edges != 2 {
+		t.Error("Subgraph must not modify the original graph")
+	}
+}
+
+func TestCollapse(t *testing.T) {
+	g := New()
+	sa := node(KindServiceAccount, "ns", "web")
+	for _, name := range []string{"web-1", "web-2"} {
+		pod := Node{Kind: KindPod, Namespace: "ns", Name: name, Attrs: map[string]string{"owner": "deployment/web"}}
+		g.AddEdge(pod, sa, "uses")
+	}
+	g.Connect("pod/ns/web-1", "pod/ns/web-2", "talks-to")
+	g.AddNode(Node{Kind: KindPod, Namespace: "ns", Name: "web-2", Risk: 5})
+
+	c := g.Collapse(func(n *Node) (Node, bool) {
+		if n.Attr("owner") == "" {
+			return Node{}, false
+		}
+		return Node{Kind: n.Kind, Namespace: n.Namespace, Name: n.Attr("owner"), Risk: n.Risk}, true
+	})
+
+	if nodes, edges := c.Len(); nodes != 2 || edges != 1 {
+		t.Errorf("Len() = %d, %d; want 2, 1", nodes, edges)
+	}
+	rep, ok := c.Node("pod/ns/deployment/web")
+	if !ok {
+		t.Fatal("representative node missing")
+	}
+	if rep.Risk != 5 {
+		t.Errorf("Risk = %d; want highest replica risk 5", rep.Risk)
+	}
+	if out := c.Out(rep.ID()); len(out) != 1 || out[0].To != "sa/ns/web" {
+		t.Errorf("Out() = %v; want one edge to sa/ns/web", out)
+	}
+}
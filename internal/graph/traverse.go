@@ -0,0 +1,129 @@
+package graph
+
+// Hop is one step along a path. Reverse is set when the edge was followed
+// against its direction, e.g. from a Service back to the Pods it selects.
+type Hop struct {
+	Edge    Edge
+	Reverse bool
+}
+
+// From returns the node the hop starts at.
+func (h Hop) From() string {
+	if h.Reverse {
+		return h.Edge.To
+	}
+	return h.Edge.From
+}
+
+// To returns the node the hop ends at.
+func (h Hop) To() string {
+	if h.Reverse {
+		return h.Edge.From
+	}
+	return h.Edge.To
+}
+
+// Path is a sequence of connected hops.
+type Path []Hop
+
+// Nodes returns the IDs visited by the path, starting node included.
+func (p Path) Nodes() []string {
+	if len(p) == 0 {
+		return nil
+	}
+	ids := []string{p[0].From()}
+	for _, h := range p {
+		ids = append(ids, h.To())
+	}
+	return ids
+}
+
+// Follow decides whether a traversal may take edge e. reverse is true when
+// e enters the current node and would be followed backwards.
+type Follow func(e Edge, reverse bool) bool
+
+// Forward follows outgoing edges only.
+func Forward(e Edge, reverse bool) bool { return !reverse }
+
+// Undirected follows edges in both directions.
+func Undirected(e Edge, reverse bool) bool { return true }
+
+// Hops returns the hops available from id under follow, outgoing edges
+// first, each in insertion order.
+func (g *Graph) Hops(id string, follow Follow) []Hop {
+	var hops []Hop
+	for _, e := range g.Out(id) {
+		if follow(e, false) {
+			hops = append(hops, Hop{Edge: e})
+		}
+	}
+	for _, e := range g.In(id) {
+		if follow(e, true) {
+			hops = append(hops, Hop{Edge: e, Reverse: true})
+		}
+	}
+	return hops
+}
+
+// ShortestPaths runs a breadth-first search from start and returns the
+// shortest path to every reachable node, keyed by node ID. start itself maps
+// to an empty path. maxDepth limits the number of hops; zero means no limit.
+func (g *Graph) ShortestPaths(start string, maxDepth int, follow Follow) map[string]Path {
+	paths := make(map[string]Path)
+	if g.nodes[start] == nil {
+		return paths
+	}
+	paths[start] = Path{}
+
+	frontier := []string{start}
+	for depth := 0; len(frontier) > 0 && (maxDepth == 0 || depth < maxDepth); depth++ {
+		var next []string
+		for _, id := range frontier {
+			for _, h := range g.Hops(id, follow) {
+				to := h.To()
+				if _, seen := paths[to]; seen {
+					continue
+				}
+				p := make(Path, len(paths[id]), len(paths[id])+1)
+				copy(p, paths[id])
+				paths[to] = append(p, h)
+				next = append(next, to)
+			}
+		}
+		frontier = next
+	}
+	return paths
+}
+
+// ShortestPath returns the shortest path from one node to another.
+func (g *Graph) ShortestPath(from, to string, follow Follow) (Path, bool) {
+	p, ok := g.ShortestPaths(from, 0, follow)[to]
+	return p, ok
+}
+
+// Reachable returns the IDs of nodes reachable from start within maxDepth
+// hops (zero means no limit), in breadth-first order. start is not included.
+func (g *Graph) Reachable(start string, maxDepth int, follow Follow) []string {
+	var ids []string
+	seen := map[string]bool{start: true}
+	frontier := []string{start}
+	if g.nodes[start] == nil {
+		return ids
+	}
+	for depth := 0; len(frontier) > 0 && (maxDepth == 0 || depth < maxDepth); depth++ {
+		var next []string
+		for _, id := range frontier {
+			for _, h := range g.Hops(id, follow) {
+				to := h.To()
+				if seen[to] {
+					continue
+				}
+				seen[to] = true
+				ids = append(ids, to)
+				next = append(next, to)
+			}
+		}
+		frontier = next
+	}
+	return ids
+}
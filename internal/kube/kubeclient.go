@@ -1,23 +1,62 @@
 package kube
 
 import (
+	"errors"
+	"fmt"
+
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
-	"os"
-	"path/filepath"
 )
 
-func GetClient() *kubernetes.Clientset {
-	homedir, _ := os.UserHomeDir()
-	kubeconfig := filepath.Join(homedir, ".kube", "config")
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
-	if err != nil {
-		panic(err.Error())
+// Options selects the cluster to connect to.
+type Options struct {
+	// Kubeconfig is the kubeconfig file to use. When empty, the files listed
+	// in KUBECONFIG are merged as kubectl does, defaulting to ~/.kube/config.
+	Kubeconfig string
+
+	// Context is the kubeconfig context to use instead of the current one.
+	Context string
+}
+
+// inClusterConfig is swapped out in tests.
+var inClusterConfig = rest.InClusterConfig
+
+// RESTConfig resolves the client configuration for opts. Without a
+// kubeconfig, and when no file or context was asked for, it falls back to the
+// ServiceAccount credentials of the pod it is running in.
+func RESTConfig(opts Options) (*rest.Config, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = opts.Kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: opts.Context}
+
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+	if err == nil {
+		return config, nil
 	}
+	if !clientcmd.IsEmptyConfig(err) || opts.Kubeconfig != "" || opts.Context != "" {
+		return nil, fmt.Errorf("loading kubeconfig: %w", err)
+	}
+
+	config, inClusterErr := inClusterConfig()
+	if inClusterErr != nil {
+		if errors.Is(inClusterErr, rest.ErrNotInCluster) {
+			return nil, errors.New("no kubeconfig found: set --kubeconfig or KUBECONFIG, or run inside a cluster")
+		}
+		return nil, fmt.Errorf("loading in-cluster config: %w", inClusterErr)
+	}
+	return config, nil
+}
 
+// GetClient returns a clientset for the cluster selected by opts.
+func GetClient(opts Options) (*kubernetes.Clientset, error) {
+	config, err := RESTConfig(opts)
+	if err != nil {
+		return nil, err
+	}
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		panic(err.Error())
+		return nil, fmt.Errorf("creating Kubernetes client: %w", err)
 	}
-	return clientset
+	return clientset, nil
 }
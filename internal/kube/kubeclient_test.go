@@ -0,0 +1,115 @@
+package kube
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/rest"
+)
+
+// writeKubeconfig writes a kubeconfig with one cluster, user and context per
+// name, where each cluster's server is https://<name>.example.com.
+func writeKubeconfig(t *testing.T, current string, names ...string) string {
+	t.Helper()
+	var clusters, users, contexts strings.Builder
+	for _, n := range names {
+		clusters.WriteString("- name: " + n + "\n  cluster:\n    server: https://" + n + ".example.com\n")
+		users.WriteString("- name: " + n + "\n  user:\n    token: " + n + "-token\n")
+		contexts.WriteString("- name: " + n + "\n  context:\n    cluster: " + n + "\n    user: " + n + "\n")
+	}
+	content := "apiVersion: v1\nkind: Config\n"
+	if current != "" {
+		content += "current-context: " + current + "\n"
+	}
+	content += "clusters:\n" + clusters.String() + "users:\n" + users.String() + "contexts:\n" + contexts.String()
+
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// isolate points HOME and KUBECONFIG away from the developer's own config
+// and fails the test if the in-cluster fallback is used.
+func isolate(t *testing.T, kubeconfig string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("KUBECONFIG", kubeconfig)
+	orig := inClusterConfig
+	inClusterConfig = func() (*rest.Config, error) { return nil, rest.ErrNotInCluster }
+	t.Cleanup(func() { inClusterConfig = orig })
+}
+
+func TestRESTConfig_MergesKubeconfigFiles(t *testing.T) {
+	first := writeKubeconfig(t, "dev", "dev")
+	second := writeKubeconfig(t, "", "prod")
+	isolate(t, first+string(os.PathListSeparator)+second)
+
+	config, err := RESTConfig(Options{})
+	if err != nil {
+		t.Fatalf("RESTConfig: %v", err)
+	}
+	if config.Host != "https://dev.example.com" {
+		t.Errorf("current context: host = %s", config.Host)
+	}
+
+	config, err = RESTConfig(Options{Context: "prod"})
+	if err != nil {
+		t.Fatalf("RESTConfig with context from the second file: %v", err)
+	}
+	if config.Host != "https://prod.example.com" || config.BearerToken != "prod-token" {
+		t.Errorf("prod context: host = %s, token = %s", config.Host, config.BearerToken)
+	}
+}
+
+func TestRESTConfig_ExplicitKubeconfig(t *testing.T) {
+	isolate(t, writeKubeconfig(t, "dev", "dev"))
+	explicit := writeKubeconfig(t, "staging", "staging")
+
+	config, err := RESTConfig(Options{Kubeconfig: explicit})
+	if err != nil {
+		t.Fatalf("RESTConfig: %v", err)
+	}
+	if config.Host != "https://staging.example.com" {
+		t.Errorf("--kubeconfig ignored: host = %s", config.Host)
+	}
+
+	if _, err := RESTConfig(Options{Kubeconfig: explicit, Context: "missing"}); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("expected an error for an unknown context, got %v", err)
+	}
+	if _, err := RESTConfig(Options{Kubeconfig: filepath.Join(t.TempDir(), "nope")}); err == nil {
+		t.Error("expected an error for a missing kubeconfig file")
+	}
+}
+
+func TestRESTConfig_InClusterFallback(t *testing.T) {
+	isolate(t, "")
+
+	if _, err := RESTConfig(Options{}); err == nil || !strings.Contains(err.Error(), "no kubeconfig found") {
+		t.Errorf("expected a no-kubeconfig error outside a cluster, got %v", err)
+	}
+
+	inClusterConfig = func() (*rest.Config, error) { return &rest.Config{Host: "https://10.0.0.1:443"}, nil }
+	config, err := RESTConfig(Options{})
+	if err != nil {
+		t.Fatalf("RESTConfig in cluster: %v", err)
+	}
+	if config.Host != "https://10.0.0.1:443" {
+		t.Errorf("in-cluster host = %s", config.Host)
+	}
+
+	// An explicit context is never satisfied by the in-cluster config.
+	if _, err := RESTConfig(Options{Context: "prod"}); err == nil {
+		t.Error("expected an error for --context without a kubeconfig")
+	}
+}
+
+func TestGetClient_ReturnsErrors(t *testing.T) {
+	isolate(t, "")
+	if client, err := GetClient(Options{}); err == nil || client != nil {
+		t.Errorf("GetClient = %v, %v; want an error", client, err)
+	}
+}
@@ -0,0 +1,97 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// kustomizationFile returns the kustomization file in dir, or "" if dir is
+// not a kustomization.
+func kustomizationFile(dir string) string {
+	for _, name := range konfig.RecognizedKustomizationFileNames() {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// renderKustomization builds the kustomization in dir, as kustomize build
+// would, and decodes the rendered objects. Each object is attributed to the
+// overlay and to the file it originally came from; lines are not known
+// after rendering.
+func (res *Result) renderKustomization(dir string) error {
+	res.Files++
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	fs := originFS{FileSystem: filesys.MakeFsOnDisk(), kustomization: filepath.Join(abs, filepath.Base(kustomizationFile(dir)))}
+
+	resources, err := krusty.MakeKustomizer(krusty.MakeDefaultOptions()).Run(fs, abs)
+	if err != nil {
+		return fmt.Errorf("kustomize build %s: %w", dir, err)
+	}
+
+	for _, r := range resources.Resources() {
+		src := Source{File: dir, Overlay: dir}
+		if origin, err := r.GetOrigin(); err == nil && origin != nil {
+			switch {
+			case origin.Repo != "":
+				src.File = origin.Repo + "//" + origin.Path
+			case origin.Path != "":
+				src.File = filepath.Join(dir, origin.Path)
+			}
+		}
+		if err := r.SetOrigin(nil); err != nil {
+			return err
+		}
+
+		data, err := r.AsYAML()
+		if err != nil {
+			return fmt.Errorf("kustomize build %s: %w", dir, err)
+		}
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("kustomize build %s: %w", dir, err)
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+		if err := res.decodeNode(doc.Content[0], src); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// originFS turns on origin annotations for the kustomization being built,
+// so every rendered object records the file it was loaded from.
+type originFS struct {
+	filesys.FileSystem
+	kustomization string
+}
+
+func (fs originFS) ReadFile(path string) ([]byte, error) {
+	data, err := fs.FileSystem.ReadFile(path)
+	if err != nil || filepath.Clean(path) != fs.kustomization {
+		return data, err
+	}
+	var k types.Kustomization
+	if sigsyaml.Unmarshal(data, &k) != nil || slices.Contains(k.BuildMetadata, types.OriginAnnotations) {
+		// Leave malformed files for kustomize to report.
+		return data, nil
+	}
+	k.BuildMetadata = append(k.BuildMetadata, types.OriginAnnotations)
+	return sigsyaml.Marshal(k)
+}
@@ -0,0 +1,127 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+// writeTree creates files under dir from a map of relative path to content.
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+var kustomizeTree = map[string]string{
+	"base/kustomization.yaml": "resources:\n- deployment.yaml\n",
+	"base/deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: nginx
+`,
+	"overlays/prod/kustomization.yaml": `namespace: prod
+namePrefix: prod-
+resources:
+- ../../base
+- sa.yaml
+patches:
+- path: host-network.yaml
+`,
+	"overlays/prod/sa.yaml": "apiVersion: v1\nkind: ServiceAccount\nmetadata:\n  name: web\n",
+	"overlays/prod/host-network.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      hostNetwork: true
+`,
+}
+
+func TestLoad_Kustomization(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, kustomizeTree)
+	overlay := filepath.Join(dir, "overlays", "prod")
+
+	res, err := Load([]string{overlay}, nil)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if res.Files != 1 || len(res.Objects) != 2 {
+		t.Fatalf("expected 2 objects from 1 kustomization, got %d from %d", len(res.Objects), res.Files)
+	}
+
+	var deploy Object
+	for _, o := range res.Objects {
+		if o.Kind() == "Deployment" {
+			deploy = o
+		}
+	}
+	d, ok := deploy.Object.(*appsv1.Deployment)
+	if !ok {
+		t.Fatalf("no Deployment rendered: %+v", res.Objects)
+	}
+	if d.Namespace != "prod" || d.Name != "prod-web" || !d.Spec.Template.Spec.HostNetwork {
+		t.Errorf("overlay not applied: %s/%s hostNetwork=%v", d.Namespace, d.Name, d.Spec.Template.Spec.HostNetwork)
+	}
+	if _, ok := d.Annotations["config.kubernetes.io/origin"]; ok {
+		t.Error("origin annotation leaked into the rendered object")
+	}
+
+	want := Source{File: filepath.Join(dir, "base", "deployment.yaml"), Overlay: overlay}
+	if deploy.Source != want {
+		t.Errorf("Source = %+v, want %+v", deploy.Source, want)
+	}
+	if !strings.HasSuffix(deploy.Source.String(), "base/deployment.yaml (overlay "+overlay+")") {
+		t.Errorf("Source.String() = %q", deploy.Source.String())
+	}
+}
+
+func TestLoad_WalkRendersKustomizations(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, kustomizeTree)
+	writeTree(t, dir, map[string]string{"plain/ns.yaml": "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: prod\n"})
+
+	res, err := Load([]string{dir}, nil)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	// base and overlays/prod are rendered; the overlay's patch file is not
+	// read on its own.
+	if res.Files != 3 {
+		t.Errorf("expected 2 kustomizations and 1 file, got %d inputs", res.Files)
+	}
+	overlays := map[string]int{}
+	for _, o := range res.Objects {
+		overlays[o.Source.Overlay]++
+	}
+	if overlays[filepath.Join(dir, "base")] != 1 || overlays[filepath.Join(dir, "overlays", "prod")] != 2 || overlays[""] != 1 {
+		t.Errorf("unexpected objects per overlay: %v", overlays)
+	}
+}
+
+func TestLoad_KustomizationError(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"kustomization.yaml": "resources:\n- missing.yaml\n"})
+	if _, err := Load([]string{dir}, nil); err == nil || !strings.Contains(err.Error(), "kustomize build") {
+		t.Errorf("expected a kustomize build error, got %v", err)
+	}
+}
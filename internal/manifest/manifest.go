@@ -0,0 +1,296 @@
+// Package manifest decodes Kubernetes objects from YAML and JSON files,
+// keeping the file and line each object was defined at so findings can be
+// reported against the source rather than the cluster.
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// Stdin is the path that reads manifests from standard input.
+const Stdin = "-"
+
+// Source is where an object was defined. Template is the chart template a
+// Helm-rendered object came from, taken from the "# Source:" comment helm
+// template writes before each document. Overlay is the kustomization that
+// rendered the object; Line is zero for rendered objects.
+type Source struct {
+	File     string
+	Line     int
+	Template string
+	Overlay  string
+}
+
+func (s Source) String() string {
+	loc := s.File
+	if s.Line > 0 {
+		loc = fmt.Sprintf("%s:%d", s.File, s.Line)
+	}
+	if s.Template != "" {
+		loc += " (" + s.Template + ")"
+	}
+	if s.Overlay != "" {
+		loc += " (overlay " + s.Overlay + ")"
+	}
+	return loc
+}
+
+// Object is a decoded Kubernetes object and where it was defined.
+type Object struct {
+	Source Source
+	Object runtime.Object
+
+	// node is the YAML the object was decoded from, for FieldLine.
+	node *yaml.Node
+}
+
+// FieldLine returns the line a field of the object was defined at, or zero
+// if the field is not in the manifest or the object was rendered. The path
+// is dotted, e.g. spec.template.spec.hostPID, and key[name] selects the
+// item of a list whose name is name, e.g. spec.containers[app].
+func (o Object) FieldLine(path string) int {
+	if o.node == nil || o.Source.Line == 0 {
+		return 0
+	}
+	node := o.node
+	for _, part := range strings.Split(path, ".") {
+		key, name, isItem := strings.Cut(part, "[")
+		if node.Kind != yaml.MappingNode {
+			return 0
+		}
+		if node = mappingValue(node, key); node == nil {
+			return 0
+		}
+		if !isItem {
+			continue
+		}
+		if node = namedItem(node, strings.TrimSuffix(name, "]")); node == nil {
+			return 0
+		}
+	}
+	return node.Line
+}
+
+// Kind returns the object's kind, e.g. Deployment.
+func (o Object) Kind() string {
+	return o.Object.GetObjectKind().GroupVersionKind().Kind
+}
+
+// Result is the outcome of loading manifests.
+type Result struct {
+	Objects []Object
+
+	// Files is the number of files and kustomizations read.
+	Files int
+
+	// Skipped counts documents of kinds the scanners do not know, such as
+	// custom resources, by kind.
+	Skipped map[string]int
+}
+
+// Load reads every path given. Directories are walked for .yaml, .yml and
+// .json files, except that a directory holding a kustomization file is
+// rendered with kustomize instead and not descended into. Stdin reads
+// standard input.
+func Load(paths []string, stdin io.Reader) (*Result, error) {
+	res := &Result{Skipped: map[string]int{}}
+	for _, p := range paths {
+		if p == Stdin {
+			if err := res.decodeFile(stdin, "<stdin>"); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		inputs, err := manifestInputs(p)
+		if err != nil {
+			return nil, err
+		}
+		for _, in := range inputs {
+			if in.kustomization {
+				if err := res.renderKustomization(in.path); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			f, err := os.Open(in.path)
+			if err != nil {
+				return nil, err
+			}
+			err = res.decodeFile(f, in.path)
+			f.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return res, nil
+}
+
+// manifestInput is a manifest file or a kustomization directory.
+type manifestInput struct {
+	path          string
+	kustomization bool
+}
+
+// manifestInputs returns path itself if it is a file or kustomization, or
+// the manifest files and kustomizations beneath it in lexical order.
+func manifestInputs(path string) ([]manifestInput, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []manifestInput{{path: path}}, nil
+	}
+
+	var inputs []manifestInput
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != path && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if kustomizationFile(p) != "" {
+				inputs = append(inputs, manifestInput{path: p, kustomization: true})
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".yaml", ".yml", ".json":
+			inputs = append(inputs, manifestInput{path: p})
+		}
+		return nil
+	})
+	return inputs, err
+}
+
+// Decode reads the documents in r, attributing them to file.
+func Decode(r io.Reader, file string) (*Result, error) {
+	res := &Result{Skipped: map[string]int{}}
+	if err := res.decodeFile(r, file); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (res *Result) decodeFile(r io.Reader, file string) error {
+	res.Files++
+	dec := yaml.NewDecoder(r)
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+			continue
+		}
+		root := doc.Content[0]
+		if err := res.decodeNode(root, Source{File: file, Line: root.Line, Template: helmTemplate(root)}); err != nil {
+			return err
+		}
+	}
+}
+
+// decodeNode decodes one object. List kinds, as written by kubectl get -o
+// yaml, are expanded so each item keeps its own line.
+func (res *Result) decodeNode(node *yaml.Node, src Source) error {
+	if kind := mappingValue(node, "kind"); kind != nil && strings.HasSuffix(kind.Value, "List") {
+		if items := mappingValue(node, "items"); items != nil && items.Kind == yaml.SequenceNode {
+			for _, item := range items.Content {
+				if item.Kind != yaml.MappingNode {
+					continue
+				}
+				itemSrc := src
+				if src.Line > 0 {
+					itemSrc.Line = item.Line
+				}
+				if err := res.decodeNode(item, itemSrc); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	var value any
+	if err := node.Decode(&value); err != nil {
+		return fmt.Errorf("%s: %w", src, err)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", src, err)
+	}
+
+	obj, gvk, err := scheme.Codecs.UniversalDeserializer().Decode(data, nil, nil)
+	if err != nil {
+		if runtime.IsNotRegisteredError(err) || runtime.IsMissingKind(err) || runtime.IsMissingVersion(err) {
+			kind := "unknown"
+			if k := mappingValue(node, "kind"); k != nil {
+				kind = k.Value
+			}
+			res.Skipped[kind]++
+			return nil
+		}
+		return fmt.Errorf("%s: %w", src, err)
+	}
+	obj.GetObjectKind().SetGroupVersionKind(*gvk)
+	res.Objects = append(res.Objects, Object{Source: src, Object: obj, node: node})
+	return nil
+}
+
+// mappingValue returns the value of key in a mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// namedItem returns the mapping in a sequence node whose name is name, or nil.
+func namedItem(node *yaml.Node, name string) *yaml.Node {
+	if node.Kind != yaml.SequenceNode {
+		return nil
+	}
+	for _, item := range node.Content {
+		if n := mappingValue(item, "name"); n != nil && n.Value == name {
+			return item
+		}
+	}
+	return nil
+}
+
+// helmTemplate returns the template named in a "# Source:" comment above
+// the document, which yaml attaches to its first key.
+func helmTemplate(node *yaml.Node) string {
+	comments := node.HeadComment
+	if len(node.Content) > 0 {
+		comments += "\n" + node.Content[0].HeadComment
+	}
+	for _, line := range strings.Split(comments, "\n") {
+		if t, ok := strings.CutPrefix(strings.TrimSpace(line), "# Source:"); ok {
+			return strings.TrimSpace(t)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,163 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+const helmOutput = `---
+# Source: web/templates/serviceaccount.yaml
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: web
+---
+# Source: web/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: nginx
+---
+# Source: web/templates/certificate.yaml
+apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: web
+---
+`
+
+func TestDecode_MultiDocument(t *testing.T) {
+	res, err := Decode(strings.NewReader(helmOutput), "<stdin>")
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(res.Objects) != 2 {
+		t.Fatalf("expected 2 objects, got %d", len(res.Objects))
+	}
+
+	sa, deploy := res.Objects[0], res.Objects[1]
+	if sa.Kind() != "ServiceAccount" || sa.Source.Line != 3 || sa.Source.Template != "web/templates/serviceaccount.yaml" {
+		t.Errorf("unexpected ServiceAccount: %s %+v", sa.Kind(), sa.Source)
+	}
+	d, ok := deploy.Object.(*appsv1.Deployment)
+	if !ok {
+		t.Fatalf("expected *appsv1.Deployment, got %T", deploy.Object)
+	}
+	if d.Spec.Template.Spec.Containers[0].Image != "nginx" || deploy.Source.Line != 9 {
+		t.Errorf("unexpected Deployment at %+v: %+v", deploy.Source, d.Spec.Template.Spec)
+	}
+	if got := deploy.Source.String(); got != "<stdin>:9 (web/templates/deployment.yaml)" {
+		t.Errorf("Source.String() = %q", got)
+	}
+	if res.Skipped["Certificate"] != 1 {
+		t.Errorf("expected the Certificate to be skipped, got %v", res.Skipped)
+	}
+}
+
+func TestDecode_ListItemsKeepTheirLines(t *testing.T) {
+	src := `apiVersion: v1
+kind: List
+items:
+- apiVersion: rbac.authorization.k8s.io/v1
+  kind: ClusterRole
+  metadata:
+    name: a
+- apiVersion: rbac.authorization.k8s.io/v1
+  kind: ClusterRole
+  metadata:
+    name: b
+`
+	res, err := Decode(strings.NewReader(src), "roles.yaml")
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(res.Objects) != 2 {
+		t.Fatalf("expected 2 objects, got %d", len(res.Objects))
+	}
+	for i, want := range []struct {
+		name string
+		line int
+	}{{"a", 4}, {"b", 8}} {
+		role := res.Objects[i].Object.(*rbacv1.ClusterRole)
+		if role.Name != want.name || res.Objects[i].Source.Line != want.line {
+			t.Errorf("item %d: got %s at line %d, want %s at line %d", i, role.Name, res.Objects[i].Source.Line, want.name, want.line)
+		}
+	}
+}
+
+func TestObject_FieldLine(t *testing.T) {
+	res, err := Decode(strings.NewReader(helmOutput), "<stdin>")
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	deploy := res.Objects[1]
+	for path, want := range map[string]int{
+		"spec.template.spec.containers[app]":       17,
+		"spec.template.spec.containers[app].image": 18,
+		"spec.template.spec.containers[db]":        0,
+		"spec.template.spec.hostPID":               0,
+		"metadata.name.first":                      0,
+	} {
+		if got := deploy.FieldLine(path); got != want {
+			t.Errorf("FieldLine(%q) = %d, want %d", path, got, want)
+		}
+	}
+
+	rendered := deploy
+	rendered.Source.Line = 0
+	if got := rendered.FieldLine("spec.template.spec.containers[app]"); got != 0 {
+		t.Errorf("expected no field lines for a rendered object, got %d", got)
+	}
+}
+
+func TestDecode_InvalidObject(t *testing.T) {
+	src := "apiVersion: v1\nkind: Pod\nmetadata:\n  name: p\nspec:\n  containers: nope\n"
+	_, err := Decode(strings.NewReader(src), "pod.yaml")
+	if err == nil || !strings.Contains(err.Error(), "pod.yaml:1") {
+		t.Errorf("expected an error located at pod.yaml:1, got %v", err)
+	}
+}
+
+func TestLoad_Directory(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("b/role.json", `{"apiVersion":"rbac.authorization.k8s.io/v1","kind":"Role","metadata":{"name":"r"}}`)
+	write("a.yml", "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: prod\n")
+	write("README.md", "apiVersion: v1\nkind: Namespace\n")
+	write(".git/config.yaml", "apiVersion: v1\nkind: Namespace\n")
+
+	res, err := Load([]string{dir, Stdin}, strings.NewReader("apiVersion: v1\nkind: ServiceAccount\nmetadata:\n  name: ci\n"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if res.Files != 3 {
+		t.Errorf("expected 3 files, got %d", res.Files)
+	}
+	var got []string
+	for _, o := range res.Objects {
+		got = append(got, o.Kind()+"@"+filepath.Base(o.Source.File))
+	}
+	want := "Namespace@a.yml Role@role.json ServiceAccount@<stdin>"
+	if strings.Join(got, " ") != want {
+		t.Errorf("objects = %v, want %s", got, want)
+	}
+}
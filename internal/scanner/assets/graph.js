@@ -0,0 +1,326 @@
+// Interactive threat graph renderer. Self-contained so the generated page
+// works offline; GRAPH is injected by the scanner.
+(function () {
+  "use strict";
+
+  var SVG_NS = "http://www.w3.org/2000/svg";
+  var RISKY = 7;
+  var KIND_COLORS = {
+    pod: "#2da44e", svc: "#0969da", ep: "#8c959f", sa: "#8250df", "iam-role": "#bf8700",
+    ing: "#1b7c83", gw: "#1b7c83", route: "#54aeff", secret: "#cf222e", cm: "#d4a72c",
+    node: "#6e7781", role: "#a475f9", clusterrole: "#a475f9", user: "#e16f24", group: "#e16f24",
+    resource: "#fa4549", internet: "#24292f"
+  };
+
+  var nodes = GRAPH.nodes || [];
+  var edges = GRAPH.edges || [];
+  var byId = {};
+  nodes.forEach(function (n, i) {
+    n.index = i;
+    n.out = [];
+    n.in = [];
+    byId[n.id] = n;
+  });
+  edges.forEach(function (e) {
+    e.source = byId[e.from];
+    e.target = byId[e.to];
+    e.source.out.push(e);
+    e.target.in.push(e);
+  });
+
+  // Layout: namespaces start on their own circle sector so they settle into
+  // clusters, then a Fruchterman-Reingold pass spreads the nodes out.
+  function layout() {
+    var namespaces = [];
+    nodes.forEach(function (n) {
+      if (namespaces.indexOf(n.namespace) < 0) namespaces.push(n.namespace);
+    });
+    var radius = 60 * Math.sqrt(nodes.length + 1);
+    nodes.forEach(function (n) {
+      var sector = namespaces.indexOf(n.namespace);
+      var angle = (2 * Math.PI * sector) / namespaces.length + Math.random() * 0.5;
+      var r = n.namespace ? radius * (0.5 + Math.random() * 0.5) : radius * Math.random() * 0.3;
+      n.x = Math.cos(angle) * r;
+      n.y = Math.sin(angle) * r;
+    });
+
+    var k = 80;
+    var iterations = Math.max(30, Math.min(300, Math.floor(2e7 / (nodes.length * nodes.length + 1))));
+    var temperature = radius / 4;
+    for (var it = 0; it < iterations; it++) {
+      nodes.forEach(function (n) { n.dx = 0; n.dy = 0; });
+      for (var i = 0; i < nodes.length; i++) {
+        for (var j = i + 1; j < nodes.length; j++) {
+          var a = nodes[i], b = nodes[j];
+          var dx = a.x - b.x, dy = a.y - b.y;
+          var d2 = dx * dx + dy * dy + 0.01;
+          var f = (k * k) / d2;
+          if (a.namespace !== b.namespace) f *= 1.5;
+          a.dx += dx * f; a.dy += dy * f;
+          b.dx -= dx * f; b.dy -= dy * f;
+        }
+      }
+      edges.forEach(function (e) {
+        var dx = e.source.x - e.target.x, dy = e.source.y - e.target.y;
+        var d = Math.sqrt(dx * dx + dy * dy) + 0.01;
+        var f = d / k;
+        e.source.dx -= dx * f; e.source.dy -= dy * f;
+        e.target.dx += dx * f; e.target.dy += dy * f;
+      });
+      nodes.forEach(function (n) {
+        var d = Math.sqrt(n.dx * n.dx + n.dy * n.dy) + 0.01;
+        var step = Math.min(d, temperature);
+        n.x += (n.dx / d) * step;
+        n.y += (n.dy / d) * step;
+      });
+      temperature *= 0.97;
+    }
+  }
+
+  function el(name, attrs, parent) {
+    var e = document.createElementNS(SVG_NS, name);
+    for (var a in attrs) e.setAttribute(a, attrs[a]);
+    if (parent) parent.appendChild(e);
+    return e;
+  }
+
+  var svg = document.getElementById("canvas");
+  var viewport = document.getElementById("viewport");
+  var edgeLayer = document.getElementById("edges");
+  var nodeLayer = document.getElementById("nodes");
+  var details = document.getElementById("details");
+  var view = { x: 0, y: 0, scale: 1 };
+
+  function render() {
+    edges.forEach(function (e) {
+      e.g = el("g", {}, edgeLayer);
+      e.line = el("line", {
+        "class": "edge", x1: e.source.x, y1: e.source.y, x2: e.target.x, y2: e.target.y,
+        "marker-end": "url(#arrow)"
+      }, e.g);
+      e.text = el("text", {
+        "class": "edge-label hidden", x: (e.source.x + e.target.x) / 2, y: (e.source.y + e.target.y) / 2
+      }, e.g);
+      e.text.textContent = e.label;
+    });
+    nodes.forEach(function (n) {
+      n.g = el("g", { "class": "node", transform: "translate(" + n.x + "," + n.y + ")" }, nodeLayer);
+      var r = 6 + Math.min(n.risk, 10);
+      el("circle", { r: r, fill: KIND_COLORS[n.kind] || "#6e7781" }, n.g);
+      var label = el("text", { x: r + 3, y: 4 }, n.g);
+      label.textContent = n.kind + ": " + n.name;
+      var title = el("title", {}, n.g);
+      title.textContent = n.id + (n.risk ? " (risk " + n.risk + ")" : "");
+      n.g.addEventListener("click", function (ev) {
+        ev.stopPropagation();
+        select(n);
+      });
+    });
+
+    var defs = el("defs", {}, svg);
+    var marker = el("marker", {
+      id: "arrow", viewBox: "0 0 10 10", refX: 18, refY: 5, markerWidth: 6, markerHeight: 6, orient: "auto"
+    }, defs);
+    el("path", { d: "M0,0 L10,5 L0,10 z", fill: "#8c959f" }, marker);
+  }
+
+  function applyView() {
+    viewport.setAttribute("transform", "translate(" + view.x + "," + view.y + ") scale(" + view.scale + ")");
+  }
+
+  function fit() {
+    var shown = nodes.filter(visible);
+    if (!shown.length) return;
+    var minX = Infinity, minY = Infinity, maxX = -Infinity, maxY = -Infinity;
+    shown.forEach(function (n) {
+      minX = Math.min(minX, n.x); minY = Math.min(minY, n.y);
+      maxX = Math.max(maxX, n.x); maxY = Math.max(maxY, n.y);
+    });
+    var w = svg.clientWidth, h = svg.clientHeight, pad = 80;
+    view.scale = Math.min(2, Math.min(w / (maxX - minX + 2 * pad), h / (maxY - minY + 2 * pad)));
+    view.x = w / 2 - ((minX + maxX) / 2) * view.scale;
+    view.y = h / 2 - ((minY + maxY) / 2) * view.scale;
+    applyView();
+  }
+
+  function centerOn(n) {
+    view.x = svg.clientWidth / 2 - n.x * view.scale;
+    view.y = svg.clientHeight / 2 - n.y * view.scale;
+    applyView();
+  }
+
+  // Pan by dragging the background, zoom around the cursor with the wheel.
+  var drag = null;
+  svg.addEventListener("mousedown", function (ev) {
+    drag = { x: ev.clientX - view.x, y: ev.clientY - view.y };
+    svg.classList.add("panning");
+  });
+  window.addEventListener("mousemove", function (ev) {
+    if (!drag) return;
+    view.x = ev.clientX - drag.x;
+    view.y = ev.clientY - drag.y;
+    applyView();
+  });
+  window.addEventListener("mouseup", function () {
+    drag = null;
+    svg.classList.remove("panning");
+  });
+  svg.addEventListener("wheel", function (ev) {
+    ev.preventDefault();
+    var rect = svg.getBoundingClientRect();
+    var mx = ev.clientX - rect.left, my = ev.clientY - rect.top;
+    var factor = Math.exp(-ev.deltaY * 0.0015);
+    var scale = Math.max(0.05, Math.min(8, view.scale * factor));
+    view.x = mx - ((mx - view.x) * scale) / view.scale;
+    view.y = my - ((my - view.y) * scale) / view.scale;
+    view.scale = scale;
+    applyView();
+  }, { passive: false });
+
+  // Filtering. A namespace filter keeps cluster-scoped nodes (IAM roles,
+  // nodes, the internet) that connect to something in the namespace.
+  var search = document.getElementById("search");
+  var nsSelect = document.getElementById("namespace");
+  var risky = document.getElementById("risky");
+  var edgeLabels = document.getElementById("labels");
+  var selected = null;
+
+  function inNamespace(n) {
+    var ns = nsSelect.value;
+    if (ns === "*" || n.namespace === ns) return true;
+    if (n.namespace) return false;
+    return n.out.concat(n.in).some(function (e) {
+      return e.source.namespace === ns || e.target.namespace === ns;
+    });
+  }
+
+  function visible(n) {
+    return inNamespace(n);
+  }
+
+  function update() {
+    var query = search.value.trim().toLowerCase();
+    var matches = [];
+    nodes.forEach(function (n) {
+      var show = visible(n);
+      var match = query !== "" && n.id.toLowerCase().indexOf(query) >= 0;
+      if (show && match) matches.push(n);
+      n.g.classList.toggle("hidden", !show);
+      n.g.classList.toggle("match", match);
+      n.g.classList.toggle("risky", risky.checked && n.risk >= RISKY);
+      n.g.classList.toggle("dim", (query !== "" && !match) || (risky.checked && n.risk < RISKY && !match));
+    });
+    var shownEdges = 0;
+    edges.forEach(function (e) {
+      var show = visible(e.source) && visible(e.target);
+      if (show) shownEdges++;
+      e.g.classList.toggle("hidden", !show);
+      e.g.classList.toggle("dim", query !== "" || risky.checked);
+      e.text.classList.toggle("hidden", !edgeLabels.checked);
+    });
+    document.getElementById("stats").textContent =
+      nodes.filter(visible).length + " nodes, " + shownEdges + " edges" +
+      (query ? ", " + matches.length + " matching" : "");
+    return matches;
+  }
+
+  search.addEventListener("input", function () {
+    var matches = update();
+    if (matches.length) centerOn(matches[0]);
+  });
+  search.addEventListener("keydown", function (ev) {
+    if (ev.key !== "Enter") return;
+    var matches = update();
+    if (matches.length) select(matches[0]);
+  });
+  nsSelect.addEventListener("change", function () { update(); fit(); });
+  risky.addEventListener("change", update);
+  edgeLabels.addEventListener("change", update);
+  document.getElementById("fit").addEventListener("click", fit);
+
+  // Details panel.
+  function text(tag, content, cls) {
+    var e = document.createElement(tag);
+    e.textContent = content;
+    if (cls) e.className = cls;
+    return e;
+  }
+
+  function nodeLink(n) {
+    var a = text("a", n.id);
+    a.addEventListener("click", function () {
+      select(n);
+      centerOn(n);
+    });
+    return a;
+  }
+
+  function select(n) {
+    if (selected) selected.g.classList.remove("selected");
+    selected = n;
+    n.g.classList.add("selected");
+
+    details.innerHTML = "";
+    details.appendChild(text("h2", n.id));
+    details.appendChild(text("div", n.kind + (n.risk ? ", risk " + n.risk + "/10" : ""), "muted"));
+
+    details.appendChild(text("h3", "Findings"));
+    if (!n.findings || !n.findings.length) {
+      details.appendChild(text("p", "No findings for this node.", "muted"));
+    } else {
+      var ul = document.createElement("ul");
+      n.findings.forEach(function (f) {
+        var li = document.createElement("li");
+        li.appendChild(text("span", "[" + f.severity + "] ", "sev sev-" + f.severity));
+        li.appendChild(document.createTextNode(f.message));
+        ul.appendChild(li);
+      });
+      details.appendChild(ul);
+    }
+
+    var keys = Object.keys(n.attrs || {}).sort();
+    if (keys.length) {
+      details.appendChild(text("h3", "Attributes"));
+      var table = document.createElement("table");
+      keys.forEach(function (k) {
+        var tr = document.createElement("tr");
+        tr.appendChild(text("td", k));
+        tr.appendChild(text("td", n.attrs[k]));
+        table.appendChild(tr);
+      });
+      details.appendChild(table);
+    }
+
+    [["Outgoing", n.out, "target"], ["Incoming", n.in, "source"]].forEach(function (group) {
+      if (!group[1].length) return;
+      details.appendChild(text("h3", group[0] + " (" + group[1].length + ")"));
+      var ul = document.createElement("ul");
+      group[1].forEach(function (e) {
+        var li = document.createElement("li");
+        li.appendChild(text("span", "[" + e.label + "] ", "muted"));
+        li.appendChild(nodeLink(e[group[2]]));
+        ul.appendChild(li);
+      });
+      details.appendChild(ul);
+    });
+  }
+
+  svg.addEventListener("click", function () {
+    if (selected) selected.g.classList.remove("selected");
+    selected = null;
+  });
+
+  var namespaces = {};
+  nodes.forEach(function (n) { if (n.namespace) namespaces[n.namespace] = true; });
+  Object.keys(namespaces).sort().forEach(function (ns) {
+    var opt = document.createElement("option");
+    opt.value = ns;
+    opt.textContent = ns;
+    nsSelect.appendChild(opt);
+  });
+
+  layout();
+  render();
+  update();
+  fit();
+})();
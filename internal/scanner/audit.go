@@ -12,6 +12,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/eks"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/khaugen7/eks-security-scanner/internal/graph"
 	"github.com/khaugen7/eks-security-scanner/internal/utils"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -30,6 +31,52 @@ type AWSAuthUser struct {
 	Groups   []string `yaml:"groups"`
 }
 
+// LoadAWSConfig loads the configuration every AWS call is made with. Offline
+// scans replace it with one that replays the responses saved in a snapshot.
+var LoadAWSConfig = func(ctx context.Context) (aws.Config, error) {
+	return config.LoadDefaultConfig(ctx)
+}
+
+// PrefetchAWS makes the AWS calls the scanners would make for the cluster:
+// EKS access entries, the policies and last use of each IAM role they or the
+// threat graph reference, and node instance profiles. Run it with a
+// recording LoadAWSConfig to capture everything an offline scan needs.
+func PrefetchAWS(clusterName string, client kubernetes.Interface) {
+	g := BuildThreatGraph("", client)
+	addNodeIAMRoles(g)
+
+	cfg, err := LoadAWSConfig(context.TODO())
+	if err != nil {
+		fmt.Printf("[!] Skipping AWS data: unable to load AWS config: %v\n", err)
+		return
+	}
+	iamClient := iam.NewFromConfig(cfg)
+
+	roles := make(map[string]bool)
+	roleARNs, err := GetIAMRolesFromEKSAccessEntries(clusterName)
+	if err != nil {
+		fmt.Printf("[!] Skipping EKS access entries: %v\n", err)
+	}
+	for _, arn := range roleARNs {
+		name := extractRoleName(arn)
+		roles[name] = true
+		if _, err := iamClient.GetRole(context.TODO(), &iam.GetRoleInput{RoleName: aws.String(name)}); err != nil {
+			fmt.Printf("[!] Unable to get role %s: %v\n", name, err)
+		}
+	}
+	for _, role := range g.NodesOfKind(graph.KindIAMRole) {
+		roles[role.Name] = true
+	}
+	for _, name := range sortedKeys(roles) {
+		if _, err := getRolePolicies(iamClient, name); err != nil {
+			fmt.Printf("[!] Unable to read policies of role %s: %v\n", name, err)
+		}
+	}
+}
+
+// AuditResources are the cluster resources RunAuditCheck reads.
+var AuditResources = []string{"clusterrolebindings"}
+
 func RunAuditCheck(clusterName string, client kubernetes.Interface) {
 	utils.PrintScannerHeader("IAM Audit")
 
@@ -44,7 +91,7 @@ func RunAuditCheck(clusterName string, client kubernetes.Interface) {
 }
 
 func CheckIAMPoliciesForRoles(roleARNs []string) {
-	cfg, err := config.LoadDefaultConfig(context.TODO())
+	cfg, err := LoadAWSConfig(context.TODO())
 	if err != nil {
 		fmt.Printf("unable to load AWS SDK config, %v", err)
 		return
@@ -79,7 +126,7 @@ func CheckIAMPoliciesForRoles(roleARNs []string) {
 }
 
 func CheckStaleRoles(roleARNs []string, thresholdDays int) {
-	cfg, err := config.LoadDefaultConfig(context.TODO())
+	cfg, err := LoadAWSConfig(context.TODO())
 	if err != nil {
 		fmt.Printf("Failed to load AWS config: %v\n", err)
 		return
@@ -146,8 +193,41 @@ func CheckClusterRoleBindings(client kubernetes.Interface) {
 	}
 }
 
+// clusterRoleBindingFindings reports ClusterRoleBindings to cluster-admin or
+// other admin roles, which grant their subjects access to every namespace,
+// as findings for the manifest scan.
+func clusterRoleBindingFindings(client kubernetes.Interface) ([]RiskFinding, error) {
+	crbs, err := client.RbacV1().ClusterRoleBindings().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []RiskFinding
+	for _, crb := range crbs.Items {
+		role := crb.RoleRef.Name
+		if !strings.Contains(role, "admin") || len(crb.Subjects) == 0 {
+			continue
+		}
+		severity := "HIGH"
+		if role == "cluster-admin" {
+			severity = "CRITICAL"
+		}
+		var subjects []string
+		for _, s := range crb.Subjects {
+			subjects = append(subjects, s.Kind+" "+displayName(s.Namespace, s.Name))
+		}
+		findings = append(findings, RiskFinding{
+			Severity: severity,
+			Message: fmt.Sprintf("[%s] ClusterRoleBinding %s grants %s cluster-wide to %s",
+				severity, crb.Name, role, strings.Join(subjects, ", ")),
+			Object: resourceID("ClusterRoleBinding", "", crb.Name),
+		})
+	}
+	return findings, nil
+}
+
 func GetIAMRolesFromEKSAccessEntries(clusterName string) ([]string, error) {
-	cfg, err := config.LoadDefaultConfig(context.TODO())
+	cfg, err := LoadAWSConfig(context.TODO())
 	if err != nil {
 		return nil, fmt.Errorf("unable to load AWS config: %w", err)
 	}
@@ -246,6 +326,108 @@ func isOverlyPermissive(policyJSON string) bool {
 	return false
 }
 
+// isAdminEquivalent reports whether a policy grants every action on every
+// resource, or full IAM access, which can be turned into the same thing.
+func isAdminEquivalent(policyJSON string) bool {
+	var doc struct {
+		Statement []struct {
+			Effect   string      `json:"Effect"`
+			Action   interface{} `json:"Action"`
+			Resource interface{} `json:"Resource"`
+		} `json:"Statement"`
+	}
+
+	if err := json.Unmarshal([]byte(policyJSON), &doc); err != nil {
+		return false
+	}
+
+	for _, stmt := range doc.Statement {
+		if stmt.Effect != "Allow" {
+			continue
+		}
+		allResources := false
+		for _, resource := range normalizeStringOrSlice(stmt.Resource) {
+			if resource == "*" {
+				allResources = true
+			}
+		}
+		if !allResources {
+			continue
+		}
+		for _, action := range normalizeStringOrSlice(stmt.Action) {
+			if action == "*" || action == "iam:*" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rolePolicy is a policy attached to or embedded in an IAM role.
+type rolePolicy struct {
+	Name     string
+	Inline   bool
+	Document string
+}
+
+// getRolePolicies fetches the documents of every managed and inline policy
+// on an IAM role.
+func getRolePolicies(client *iam.Client, roleName string) ([]rolePolicy, error) {
+	var policies []rolePolicy
+
+	attached, err := client.ListAttachedRolePolicies(context.TODO(), &iam.ListAttachedRolePoliciesInput{
+		RoleName: aws.String(roleName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing attached policies: %w", err)
+	}
+	for _, policy := range attached.AttachedPolicies {
+		doc, err := getPolicyDocument(client, *policy.PolicyArn)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, rolePolicy{Name: aws.ToString(policy.PolicyName), Document: doc})
+	}
+
+	inline, err := client.ListRolePolicies(context.TODO(), &iam.ListRolePoliciesInput{
+		RoleName: aws.String(roleName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing inline policies: %w", err)
+	}
+	for _, name := range inline.PolicyNames {
+		policy, err := client.GetRolePolicy(context.TODO(), &iam.GetRolePolicyInput{
+			RoleName:   aws.String(roleName),
+			PolicyName: aws.String(name),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("GetRolePolicy failed: %w", err)
+		}
+		doc, err := url.QueryUnescape(aws.ToString(policy.PolicyDocument))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode policy document: %w", err)
+		}
+		policies = append(policies, rolePolicy{Name: name, Inline: true, Document: doc})
+	}
+
+	return policies, nil
+}
+
+// roleIsAdminEquivalent checks the attached and inline policies of an IAM
+// role for admin-equivalent permissions.
+func roleIsAdminEquivalent(client *iam.Client, roleName string) (bool, error) {
+	policies, err := getRolePolicies(client, roleName)
+	if err != nil {
+		return false, err
+	}
+	for _, policy := range policies {
+		if isAdminEquivalent(policy.Document) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func normalizeStringOrSlice(field interface{}) []string {
 	switch v := field.(type) {
 	case string:
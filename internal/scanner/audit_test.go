@@ -2,7 +2,14 @@ package scanner
 
 import (
 	"reflect"
+	"strings"
 	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/khaugen7/eks-security-scanner/internal/testhelpers"
 )
 
 func TestExtractRoleName(t *testing.T) {
@@ -64,3 +71,64 @@ func TestIsOverlyPermissive(t *testing.T) {
 		})
 	}
 }
+
+func TestIsAdminEquivalent(t *testing.T) {
+	cases := []struct {
+		name     string
+		policy   string
+		expected bool
+	}{
+		{"admin", `{"Statement":[{"Effect":"Allow","Action":"*","Resource":"*"}]}`, true},
+		{"iam full access", `{"Statement":[{"Effect":"Allow","Action":["iam:*"],"Resource":["*"]}]}`, true},
+		{"service wildcard", `{"Statement":[{"Effect":"Allow","Action":"ec2:*","Resource":"*"}]}`, false},
+		{"scoped resource", `{"Statement":[{"Effect":"Allow","Action":"*","Resource":"arn:aws:s3:::bucket/*"}]}`, false},
+		{"deny", `{"Statement":[{"Effect":"Deny","Action":"*","Resource":"*"}]}`, false},
+		{"malformed", `not-json`, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := isAdminEquivalent(c.policy); got != c.expected {
+				t.Errorf("isAdminEquivalent(%s) = %v; want %v", c.name, got, c.expected)
+			}
+		})
+	}
+}
+
+func TestClusterRoleBindingFindings(t *testing.T) {
+	crb := func(name, role string) *rbacv1.ClusterRoleBinding {
+		return &rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Subjects:   []rbacv1.Subject{{Kind: "Group", Name: "ops"}},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: role},
+		}
+	}
+	client := fake.NewSimpleClientset(crb("a", "cluster-admin"), crb("b", "tenant-admin"), crb("c", "view"))
+
+	findings, err := clusterRoleBindingFindings(client)
+	if err != nil || len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %+v, %v", findings, err)
+	}
+	if findings[0].Severity != "CRITICAL" || !strings.Contains(findings[0].Message, "to Group ops") {
+		t.Errorf("unexpected cluster-admin finding: %+v", findings[0])
+	}
+	if findings[1].Severity != "HIGH" {
+		t.Errorf("unexpected tenant-admin finding: %+v", findings[1])
+	}
+}
+
+func TestCheckClusterRoleBindings(t *testing.T) {
+	client := fake.NewSimpleClientset(&rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "ops-admin"},
+		Subjects:   []rbacv1.Subject{{Kind: "Group", Name: "ops"}},
+		RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+	})
+
+	out := testhelpers.CaptureOutput(func() { CheckClusterRoleBindings(client) })
+	want := "\n[+] ClusterRoleBindings to cluster-admin or risky roles:\n" +
+		"- CRB: ops-admin binds to role: cluster-admin\n" +
+		"  -> Kind: Group, Name: ops, Namespace: \n"
+	if out != want {
+		t.Errorf("got output:\n%q\nwant:\n%q", out, want)
+	}
+}
@@ -0,0 +1,371 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/khaugen7/eks-security-scanner/internal/graph"
+	"github.com/khaugen7/eks-security-scanner/internal/utils"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// parseIdentity splits a --from reference of the form pod/<ns>/<name> or
+// sa/<ns>/<name>.
+func parseIdentity(ref string) (graph.Kind, string, string, error) {
+	parts := strings.Split(ref, "/")
+	if len(parts) != 3 || parts[1] == "" || parts[2] == "" {
+		return "", "", "", fmt.Errorf("expected pod/<namespace>/<name> or sa/<namespace>/<name>, got %q", ref)
+	}
+	switch parts[0] {
+	case "pod", "pods":
+		return graph.KindPod, parts[1], parts[2], nil
+	case "sa", "serviceaccount", "serviceaccounts":
+		return graph.KindServiceAccount, parts[1], parts[2], nil
+	}
+	return "", "", "", fmt.Errorf("unsupported kind %q: expected pod or sa", parts[0])
+}
+
+// BlastRadiusResources are the cluster resources RunBlastRadiusCheck reads.
+// Secrets are only listed by name.
+var BlastRadiusResources = append(slices.Clone(GraphResources), "secrets")
+
+func RunBlastRadiusCheck(from string, skipIAM bool, client kubernetes.Interface) {
+	utils.PrintScannerHeader("Blast Radius")
+
+	kind, namespace, name, err := parseIdentity(from)
+	if err != nil {
+		fmt.Printf("Invalid --from: %v\n", err)
+		return
+	}
+
+	// Pods that carry the compromised identity: the pod itself, or every pod
+	// running as the ServiceAccount.
+	var pods []corev1.Pod
+	saName := name
+	tokenMounted := true
+	if kind == graph.KindPod {
+		pod, err := client.CoreV1().Pods(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			fmt.Printf("Failed to get pod %s/%s: %v\n", namespace, name, err)
+			return
+		}
+		pods = []corev1.Pod{*pod}
+		saName = pod.Spec.ServiceAccountName
+		if saName == "" {
+			saName = "default"
+		}
+	} else {
+		podList, err := client.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			fmt.Printf("Failed to list pods: %v\n", err)
+			return
+		}
+		for _, pod := range podList.Items {
+			if pod.Spec.ServiceAccountName == name || (pod.Spec.ServiceAccountName == "" && name == "default") {
+				pods = append(pods, pod)
+			}
+		}
+	}
+	sa, err := client.CoreV1().ServiceAccounts(namespace).Get(context.TODO(), saName, metav1.GetOptions{})
+	if err != nil {
+		sa = &corev1.ServiceAccount{}
+	}
+	// Without the token the pod cannot act as its ServiceAccount in the API
+	if kind == graph.KindPod {
+		tokenMounted = tokenAutomounted(pods[0].Spec, *sa)
+	}
+
+	fmt.Printf("\n[+] Computing everything reachable from %s...\n", from)
+
+	var critical, high, med int
+	count := func(severity string) {
+		switch severity {
+		case "CRITICAL":
+			critical++
+		case "HIGH":
+			high++
+		default:
+			med++
+		}
+	}
+
+	// Identity: follow the Pod → SA → IAM role edges of the threat graph.
+	g := BuildThreatGraph(namespace, client)
+	startNode := graph.Node{Kind: kind, Namespace: namespace, Name: name}
+	identity := func(e graph.Edge, reverse bool) bool {
+		return !reverse && (e.Label == "uses" || e.Label == "assumes" || e.Label == "web-identity")
+	}
+
+	fmt.Printf("\n[+] Identity\n")
+	var iamRoles []*graph.Node
+	if n, ok := g.Node(startNode.ID()); ok {
+		fmt.Printf("    %s\n", formatNode(n))
+	} else {
+		fmt.Printf("    %s\n", formatNode(&startNode))
+	}
+	for _, id := range g.Reachable(startNode.ID(), 0, identity) {
+		n, _ := g.Node(id)
+		fmt.Printf("    %s\n", formatNode(n))
+		if n.Kind == graph.KindIAMRole {
+			iamRoles = append(iamRoles, n)
+		}
+	}
+	// A ServiceAccount without pods is not in the graph, but anyone who can
+	// run a pod as it still gets its IRSA role.
+	if arn := sa.Annotations[irsaRoleAnnotation]; arn != "" && len(iamRoles) == 0 {
+		role := newIAMRoleNode(arn)
+		fmt.Printf("    %s\n", formatNode(&role))
+		iamRoles = append(iamRoles, &role)
+	}
+	if kind == graph.KindServiceAccount {
+		fmt.Printf("    Pods running as this ServiceAccount: %d\n", len(pods))
+	}
+
+	// Kubernetes permissions through RBAC
+	fmt.Printf("\n[+] Kubernetes Permissions (RBAC)\n")
+	var rules []grantedRule
+	if !tokenMounted {
+		fmt.Printf("    The pod does not mount the token of ServiceAccount %s\n", saName)
+	} else if rbac, err := buildRBACIndex("", client); err != nil {
+		fmt.Printf("    Failed to read RBAC: %v\n", err)
+	} else {
+		rules = resolveRules(rbac.serviceAccountBindings(namespace, saName), client)
+	}
+	if len(rules) == 0 && tokenMounted {
+		fmt.Println("    No RBAC permissions granted to this ServiceAccount")
+	}
+	for _, r := range rules {
+		fmt.Printf("    - %s %s %s (%s)\n", strings.Join(r.Rule.Verbs, ","), ruleResources(r.Rule), r.Scope(), r.Binding)
+		for _, c := range ruleCapabilities(r.Rule) {
+			fmt.Printf("      [%s] %s%s %s\n", c.Severity, c.Name, c.Target(), r.Scope())
+			count(c.Severity)
+		}
+	}
+
+	// IAM permissions through IRSA
+	fmt.Printf("\n[+] AWS Permissions (IRSA)\n")
+	if len(iamRoles) == 0 {
+		fmt.Println("    No IAM role is mapped to this ServiceAccount")
+	} else if !tokenMounted {
+		fmt.Println("    The pod does not mount its API token, but the pod identity webhook still injects a web identity token for the role")
+	}
+	for _, role := range iamRoles {
+		fmt.Printf("    IAM role %s (%s)\n", role.Name, role.Attr("arn"))
+		if skipIAM {
+			continue
+		}
+		for _, line := range describeIAMRole(role.Name) {
+			fmt.Printf("      %s\n", line)
+			if strings.HasPrefix(line, "[CRITICAL]") {
+				count("CRITICAL")
+			}
+		}
+	}
+
+	// Secrets readable through RBAC or mounted into the pods
+	fmt.Printf("\n[+] Readable Secrets\n")
+	secrets := readableSecrets(rules, pods, client)
+	if len(secrets) == 0 {
+		fmt.Println("    None")
+	}
+	for _, id := range sortedKeys(secrets) {
+		fmt.Printf("    %s (%s)\n", id, strings.Join(secrets[id], "; "))
+	}
+
+	// Services the pods can connect to
+	fmt.Printf("\n[+] Reachable Services\n")
+	services, err := client.CoreV1().Services("").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		fmt.Printf("    Failed to list services: %v\n", err)
+	} else if len(pods) > 0 {
+		reachable, blocked := services.Items, 0
+		allPods, podErr := client.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{})
+		if policies, err := newPolicyEvaluator(client); err == nil && podErr == nil && len(policies.policies) > 0 {
+			reachable = reachableServices(policies, pods, services.Items, allPods.Items)
+			blocked = len(services.Items) - len(reachable)
+		}
+		byNamespace := make(map[string][]string)
+		for _, svc := range reachable {
+			byNamespace[svc.Namespace] = append(byNamespace[svc.Namespace], svc.Name)
+		}
+		fmt.Printf("    %d Services across %d namespaces (%d blocked by NetworkPolicies)\n", len(reachable), len(byNamespace), blocked)
+		for _, ns := range sortedKeys(byNamespace) {
+			fmt.Printf("    %s: %s\n", ns, strings.Join(byNamespace[ns], ", "))
+		}
+	}
+
+	// Escape to the node
+	fmt.Printf("\n[+] Node Escape\n")
+	escapes := 0
+	for _, pod := range pods {
+		for _, f := range nodeEscapeVectors(pod) {
+			fmt.Printf("    %s\n", f.Message)
+			count(f.Severity)
+			escapes++
+		}
+	}
+	if escapes == 0 {
+		fmt.Println("    No host-level privileges found")
+	}
+
+	fmt.Printf("\n[✓] Blast Radius Summary\n")
+	fmt.Printf("    Compromised Identity    : %s\n", from)
+	fmt.Printf("    RBAC Rules              : %d\n", len(rules))
+	fmt.Printf("    IAM Roles               : %d\n", len(iamRoles))
+	fmt.Printf("    Readable Secrets        : %d\n", len(secrets))
+	fmt.Printf("    Node Escape Vectors     : %d\n", escapes)
+	fmt.Printf("    Critical Findings       : %d\n", critical)
+	fmt.Printf("    High Severity Findings  : %d\n", high)
+	fmt.Printf("    Medium Severity Findings: %d\n", med)
+}
+
+func ruleResources(rule rbacv1.PolicyRule) string {
+	var resources []string
+	for _, group := range rule.APIGroups {
+		for _, r := range rule.Resources {
+			if group == "" {
+				resources = append(resources, r)
+			} else {
+				resources = append(resources, r+"."+group)
+			}
+		}
+	}
+	s := strings.Join(resources, ",")
+	if len(rule.ResourceNames) > 0 {
+		s += " [" + strings.Join(rule.ResourceNames, ",") + "]"
+	}
+	return s
+}
+
+// readableSecrets maps secret IDs to how the compromised identity can read
+// them: through RBAC or because they are mounted into its pods.
+func readableSecrets(rules []grantedRule, pods []corev1.Pod, client kubernetes.Interface) map[string][]string {
+	secrets := make(map[string][]string)
+	add := func(id, how string) {
+		for _, existing := range secrets[id] {
+			if existing == how {
+				return
+			}
+		}
+		secrets[id] = append(secrets[id], how)
+	}
+
+	for _, r := range rules {
+		readable := false
+		for _, verb := range []string{"get", "list", "watch"} {
+			if ruleAllows(r.Rule, verb, "", "secrets") {
+				readable = true
+			}
+		}
+		if !readable {
+			continue
+		}
+		how := fmt.Sprintf("RBAC via %s", r.Binding)
+		if len(r.Rule.ResourceNames) > 0 && r.Namespace != "" {
+			for _, name := range r.Rule.ResourceNames {
+				add(r.Namespace+"/"+name, how)
+			}
+			continue
+		}
+		list, err := client.CoreV1().Secrets(r.Namespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			continue
+		}
+		allowed := make(map[string]bool)
+		for _, name := range r.Rule.ResourceNames {
+			allowed[name] = true
+		}
+		for _, s := range list.Items {
+			if len(allowed) == 0 || allowed[s.Name] {
+				add(s.Namespace+"/"+s.Name, how)
+			}
+		}
+	}
+
+	for _, pod := range pods {
+		for _, ref := range podSecretRefs(pod) {
+			add(pod.Namespace+"/"+ref.Name, fmt.Sprintf("mounted in pod %s via %s", pod.Name, ref.Method))
+		}
+	}
+	return secrets
+}
+
+// reachableServices returns the services at least one of the compromised
+// pods can send traffic to under NetworkPolicies. Services without a selector
+// or without running backends cannot be evaluated and are kept.
+func reachableServices(policies *policyEvaluator, from []corev1.Pod, services []corev1.Service, allPods []corev1.Pod) []corev1.Service {
+	var reachable []corev1.Service
+	podsByLabel := newPodLabelIndex(allPods)
+	for _, svc := range services {
+		backends := podsByLabel.selected(svc.Namespace, svc.Spec.Selector)
+		if len(backends) == 0 || anyAllowed(policies, from, backends, svc) {
+			reachable = append(reachable, svc)
+		}
+	}
+	return reachable
+}
+
+func anyAllowed(policies *policyEvaluator, from, backends []corev1.Pod, svc corev1.Service) bool {
+	for _, src := range from {
+		for _, dst := range backends {
+			for _, port := range servicePorts(svc, dst) {
+				if policies.allowed(src, dst, port) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// nodeEscapeVectors lists the pod settings that let a compromised container
+// reach the underlying node.
+func nodeEscapeVectors(pod corev1.Pod) []RiskFinding {
+	var findings []RiskFinding
+	for _, v := range podEscapeVectors(pod) {
+		findings = append(findings, RiskFinding{
+			Severity: v.Severity,
+			Message:  fmt.Sprintf("[%s] Pod %s/%s: %s", v.Severity, pod.Namespace, pod.Name, v.Reason),
+		})
+	}
+	return findings
+}
+
+// describeIAMRole summarises the policies of an IAM role, flagging
+// admin-equivalent ones.
+func describeIAMRole(roleName string) []string {
+	cfg, err := LoadAWSConfig(context.TODO())
+	if err != nil {
+		return []string{fmt.Sprintf("[!] Unable to load AWS config: %v", err)}
+	}
+	policies, err := getRolePolicies(iam.NewFromConfig(cfg), roleName)
+	if err != nil {
+		return []string{fmt.Sprintf("[!] Unable to read policies: %v", err)}
+	}
+
+	var lines []string
+	for _, p := range policies {
+		kind := "managed"
+		if p.Inline {
+			kind = "inline"
+		}
+		switch {
+		case isAdminEquivalent(p.Document):
+			lines = append(lines, fmt.Sprintf("[CRITICAL] %s policy %s is admin-equivalent", kind, p.Name))
+		case isOverlyPermissive(p.Document):
+			lines = append(lines, fmt.Sprintf("- %s policy %s (uses wildcards)", kind, p.Name))
+		default:
+			lines = append(lines, fmt.Sprintf("- %s policy %s", kind, p.Name))
+		}
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "- no policies attached")
+	}
+	return lines
+}
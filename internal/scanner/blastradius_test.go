@@ -0,0 +1,216 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/khaugen7/eks-security-scanner/internal/graph"
+	"github.com/khaugen7/eks-security-scanner/internal/testhelpers"
+)
+
+func TestParseIdentity(t *testing.T) {
+	tests := []struct {
+		ref     string
+		kind    graph.Kind
+		wantErr bool
+	}{
+		{"pod/prod/api-7d9f", graph.KindPod, false},
+		{"sa/prod/api", graph.KindServiceAccount, false},
+		{"serviceaccount/prod/api", graph.KindServiceAccount, false},
+		{"deploy/prod/api", "", true},
+		{"pod/api", "", true},
+		{"pod//api", "", true},
+	}
+	for _, tt := range tests {
+		kind, ns, name, err := parseIdentity(tt.ref)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseIdentity(%q) error = %v, wantErr %v", tt.ref, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (kind != tt.kind || ns != "prod" || name == "") {
+			t.Errorf("parseIdentity(%q) = %s %s %s", tt.ref, kind, ns, name)
+		}
+	}
+}
+
+func TestNodeEscapeVectors(t *testing.T) {
+	privileged := true
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "ns1"},
+		Spec: corev1.PodSpec{
+			HostPID: true,
+			Containers: []corev1.Container{{
+				Name: "agent",
+				SecurityContext: &corev1.SecurityContext{
+					Privileged:   &privileged,
+					Capabilities: &corev1.Capabilities{Add: []corev1.Capability{"SYS_ADMIN", "NET_BIND_SERVICE"}},
+				},
+			}},
+			Volumes: []corev1.Volume{
+				{Name: "docker", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/run/docker.sock"}}},
+				{Name: "tmp", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/tmp/cache"}}},
+			},
+		},
+	}
+
+	findings := nodeEscapeVectors(pod)
+	if len(findings) != 5 {
+		t.Fatalf("expected 5 escape vectors, got %+v", findings)
+	}
+	for i, want := range []string{
+		"[CRITICAL] Pod ns1/agent: container agent is privileged",
+		"[HIGH] Pod ns1/agent: container agent adds capability SYS_ADMIN",
+		"[HIGH] Pod ns1/agent: hostPID",
+		"[CRITICAL] Pod ns1/agent: hostPath /var/run/docker.sock",
+		"[HIGH] Pod ns1/agent: hostPath /tmp/cache",
+	} {
+		if !strings.HasPrefix(findings[i].Message, want) {
+			t.Errorf("finding %d = %q, want prefix %q", i, findings[i].Message, want)
+		}
+	}
+}
+
+func TestRunBlastRadiusCheck(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-1", Namespace: "ns1"},
+		Spec: corev1.PodSpec{
+			ServiceAccountName: "api",
+			Containers: []corev1.Container{{
+				Name:    "api",
+				EnvFrom: []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "api-env"}}}},
+			}},
+		},
+	}
+	sa := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "api",
+			Namespace:   "ns1",
+			Annotations: map[string]string{"eks.amazonaws.com/role-arn": "arn:aws:iam::123456789012:role/ApiRole"},
+		},
+	}
+	role := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{Name: "secret-reader", Namespace: "ns1"},
+		Rules:      []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get", "list"}}},
+	}
+	rb := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-secrets", Namespace: "ns1"},
+		Subjects:   []rbacv1.Subject{{Kind: "ServiceAccount", Name: "api"}},
+		RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "secret-reader"},
+	}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "db-creds", Namespace: "ns1"}}
+	other := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "ns2"}}
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "payments", Namespace: "ns2"}}
+
+	var client kubernetes.Interface = fake.NewSimpleClientset(pod, sa, role, rb, secret, other, svc)
+	out := testhelpers.CaptureOutput(func() {
+		RunBlastRadiusCheck("pod/ns1/api-1", true, client)
+	})
+
+	for _, want := range []string{
+		"[SA]  ns1/api",
+		"[IAM] ApiRole",
+		"[HIGH] can-read-secrets in namespace ns1",
+		"ns1/db-creds (RBAC via RoleBinding ns1/api-secrets → Role secret-reader)",
+		"ns1/api-env (mounted in pod api-1 via envFrom)",
+		"ns2: payments",
+		"No host-level privileges found",
+		"Readable Secrets        : 2",
+		"High Severity Findings  : 1",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "ns2/other") {
+		t.Error("secret outside the Role's namespace should not be readable")
+	}
+}
+
+func TestRunBlastRadiusCheck_TokenNotMounted(t *testing.T) {
+	automount := false
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-1", Namespace: "ns1"},
+		Spec:       corev1.PodSpec{ServiceAccountName: "api"},
+	}
+	sa := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "api",
+			Namespace:   "ns1",
+			Annotations: map[string]string{"eks.amazonaws.com/role-arn": "arn:aws:iam::123456789012:role/ApiRole"},
+		},
+		AutomountServiceAccountToken: &automount,
+	}
+	rb := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-admin", Namespace: "ns1"},
+		Subjects:   []rbacv1.Subject{{Kind: "ServiceAccount", Name: "api"}},
+		RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "admin"},
+	}
+	admin := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{Name: "admin"},
+		Rules:      []rbacv1.PolicyRule{{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}},
+	}
+
+	var client kubernetes.Interface = fake.NewSimpleClientset(pod, sa, rb, admin)
+	out := testhelpers.CaptureOutput(func() {
+		RunBlastRadiusCheck("pod/ns1/api-1", true, client)
+	})
+
+	for _, want := range []string{
+		"The pod does not mount the token of ServiceAccount api",
+		"[IAM] ApiRole",
+		"IAM role ApiRole (arn:aws:iam::123456789012:role/ApiRole)",
+		"the pod identity webhook still injects a web identity token",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "api-admin") {
+		t.Errorf("unexpected RBAC permissions without a mounted token:\n%s", out)
+	}
+}
+
+func TestRunBlastRadiusCheck_ServiceAccountWithoutPods(t *testing.T) {
+	sa := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "deployer",
+			Namespace:   "ns1",
+			Annotations: map[string]string{"eks.amazonaws.com/role-arn": "arn:aws:iam::123456789012:role/Deployer"},
+		},
+	}
+
+	var client kubernetes.Interface = fake.NewSimpleClientset(sa)
+	out := testhelpers.CaptureOutput(func() {
+		RunBlastRadiusCheck("sa/ns1/deployer", true, client)
+	})
+
+	for _, want := range []string{
+		"[SA]  ns1/deployer",
+		"[IAM] Deployer",
+		"IAM role Deployer (arn:aws:iam::123456789012:role/Deployer)",
+		"Pods running as this ServiceAccount: 0",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "No IAM role is mapped") {
+		t.Errorf("IRSA role of a ServiceAccount without pods was missed:\n%s", out)
+	}
+}
+
+func TestRunBlastRadiusCheck_InvalidFrom(t *testing.T) {
+	var client kubernetes.Interface = fake.NewSimpleClientset()
+	out := testhelpers.CaptureOutput(func() {
+		RunBlastRadiusCheck("deploy/ns1/api", true, client)
+	})
+	if !strings.Contains(out, "Invalid --from") {
+		t.Errorf("expected invalid --from error, got:\n%s", out)
+	}
+}
@@ -0,0 +1,152 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/khaugen7/eks-security-scanner/internal/graph"
+)
+
+// dotShapes are the Graphviz node shapes for each kind. Kinds without an
+// entry are drawn as boxes.
+var dotShapes = map[graph.Kind]string{
+	graph.KindPod:            "ellipse",
+	graph.KindService:        "hexagon",
+	graph.KindEndpoint:       "circle",
+	graph.KindServiceAccount: "oval",
+	graph.KindIAMRole:        "component",
+	graph.KindIngress:        "invtrapezium",
+	graph.KindGateway:        "invtrapezium",
+	graph.KindRoute:          "parallelogram",
+	graph.KindSecret:         "cylinder",
+	graph.KindConfigMap:      "cylinder",
+	graph.KindNode:           "box3d",
+	graph.KindRole:           "note",
+	graph.KindClusterRole:    "note",
+	graph.KindUser:           "house",
+	graph.KindGroup:          "house",
+	graph.KindResource:       "octagon",
+	graph.KindInternet:       "doublecircle",
+}
+
+// dotSeverityStyles are the border and fill colors for a node's most severe
+// finding. HIGH shares the CRITICAL red: both are worth fixing before the
+// threat-modeling session ends.
+var dotSeverityStyles = map[string][2]string{
+	"CRITICAL": {"#cf222e", "#ffebe9"},
+	"HIGH":     {"#cf222e", "#ffebe9"},
+	"MED":      {"#bc4c00", "#fff1e5"},
+	"LOW":      {"#9a6700", "#fff8c5"},
+	"":         {"#57606a", "#ffffff"},
+}
+
+// PrintDOTGraph prints the graph in Graphviz DOT format. Nodes are shaped by
+// kind, colored by their most severe finding and grouped into one cluster
+// per namespace; a legend explains the colors.
+func PrintDOTGraph(g *graph.Graph) {
+	fmt.Print("\n[i] Render the following with Graphviz (dot -Tsvg), or use --format html for an interactive view:\n")
+
+	fmt.Println("digraph eks_threat_graph {")
+	fmt.Println("  rankdir=LR;")
+	fmt.Println(`  node [style=filled, fontname="Helvetica", fontsize=10];`)
+	fmt.Println(`  edge [fontname="Helvetica", fontsize=8, color="#8c959f"];`)
+
+	byNamespace := make(map[string][]*graph.Node)
+	for _, n := range g.Nodes() {
+		byNamespace[n.Namespace] = append(byNamespace[n.Namespace], n)
+	}
+	for _, n := range byNamespace[""] {
+		fmt.Printf("  %s\n", dotNode(g, n))
+	}
+	for _, ns := range sortedKeys(byNamespace) {
+		if ns == "" {
+			continue
+		}
+		fmt.Printf("  subgraph \"cluster_%s\" {\n", dotEscape(ns))
+		fmt.Printf("    label=\"%s\"; style=rounded; color=\"#d0d7de\";\n", dotEscape(ns))
+		for _, n := range byNamespace[ns] {
+			fmt.Printf("    %s\n", dotNode(g, n))
+		}
+		fmt.Println("  }")
+	}
+
+	for _, edge := range g.Edges() {
+		style := ""
+		switch {
+		case isEscapeLabel(edge.Label):
+			style = `, color="#cf222e", fontcolor="#cf222e", penwidth=2`
+		case edge.Label == "exposes":
+			style = `, color="#bc4c00", fontcolor="#bc4c00"`
+		}
+		fmt.Printf("  \"%s\" -> \"%s\" [label=\"%s\"%s];\n", dotEscape(edge.From), dotEscape(edge.To), dotEscape(edge.Label), style)
+	}
+
+	printDOTLegend()
+	fmt.Println("}")
+}
+
+func dotNode(g *graph.Graph, n *graph.Node) string {
+	shape, ok := dotShapes[n.Kind]
+	if !ok {
+		shape = "box"
+	}
+	colors := dotSeverityStyles[highestSeverity(nodeFindings(g, n))]
+	label := fmt.Sprintf("%s\\n%s", n.Kind, dotEscape(n.Name))
+	if n.Kind == graph.KindInternet {
+		label = n.Name
+	}
+	return fmt.Sprintf("\"%s\" [label=\"%s\", shape=%s, color=\"%s\", fillcolor=\"%s\"];",
+		dotEscape(n.ID()), label, shape, colors[0], colors[1])
+}
+
+func printDOTLegend() {
+	fmt.Println(`  subgraph "cluster_legend" {`)
+	fmt.Println(`    label="Legend"; style=rounded; color="#d0d7de";`)
+	for _, entry := range []struct{ severity, label string }{
+		{"CRITICAL", "critical or high finding"},
+		{"MED", "medium finding"},
+		{"LOW", "low finding"},
+		{"", "no findings"},
+	} {
+		colors := dotSeverityStyles[entry.severity]
+		fmt.Printf("    \"legend_%s\" [label=\"%s\", shape=box, color=\"%s\", fillcolor=\"%s\"];\n",
+			strings.ToLower(strings.ReplaceAll(entry.label, " ", "_")), entry.label, colors[0], colors[1])
+	}
+	fmt.Println(`    "legend_escape_from" [label="", shape=point];`)
+	fmt.Println(`    "legend_escape_to" [label="container escape", shape=plaintext, style=""];`)
+	fmt.Println(`    "legend_escape_from" -> "legend_escape_to" [color="#cf222e", penwidth=2];`)
+	fmt.Println(`    "legend_exposes_from" [label="", shape=point];`)
+	fmt.Println(`    "legend_exposes_to" [label="internet exposure", shape=plaintext, style=""];`)
+	fmt.Println(`    "legend_exposes_from" -> "legend_exposes_to" [color="#bc4c00"];`)
+	fmt.Println("  }")
+}
+
+// highestSeverity returns the most severe of the findings' severities, or ""
+// if there are none.
+func highestSeverity(findings []RiskFinding) string {
+	best := ""
+	for _, f := range findings {
+		if severityWeight(f.Severity) > severityWeight(best) {
+			best = f.Severity
+		}
+	}
+	return best
+}
+
+func severityWeight(severity string) int {
+	switch severity {
+	case "CRITICAL":
+		return 4
+	case "HIGH":
+		return 3
+	case "MED":
+		return 2
+	case "LOW":
+		return 1
+	}
+	return 0
+}
+
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
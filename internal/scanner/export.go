@@ -0,0 +1,157 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/khaugen7/eks-security-scanner/internal/graph"
+)
+
+// PrintGraphML prints the graph as GraphML for import into yEd, Gephi or
+// graph databases. Every node carries its kind, namespace, name and risk,
+// plus one data entry per attribute; edges carry their label.
+func PrintGraphML(g *graph.Graph) {
+	nodes := g.Nodes()
+
+	attrKeys := make(map[string]bool)
+	for _, n := range nodes {
+		for k := range n.Attrs {
+			attrKeys[k] = true
+		}
+	}
+	keys := sortedKeys(attrKeys)
+
+	fmt.Println(`<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Println("<!-- [i] Save this output as a .graphml file to import it -->")
+	fmt.Println(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`)
+	fmt.Println(`  <key id="kind" for="node" attr.name="kind" attr.type="string"/>`)
+	fmt.Println(`  <key id="namespace" for="node" attr.name="namespace" attr.type="string"/>`)
+	fmt.Println(`  <key id="name" for="node" attr.name="name" attr.type="string"/>`)
+	fmt.Println(`  <key id="risk" for="node" attr.name="risk" attr.type="int"/>`)
+	for i, k := range keys {
+		fmt.Printf("  <key id=\"a%d\" for=\"node\" attr.name=\"%s\" attr.type=\"string\"/>\n", i, xmlEscape(k))
+	}
+	fmt.Println(`  <key id="label" for="edge" attr.name="label" attr.type="string"/>`)
+	fmt.Println(`  <graph id="eks_threat_graph" edgedefault="directed">`)
+
+	for _, n := range nodes {
+		fmt.Printf("    <node id=\"%s\">\n", xmlEscape(n.ID()))
+		fmt.Printf("      <data key=\"kind\">%s</data>\n", xmlEscape(string(n.Kind)))
+		if n.Namespace != "" {
+			fmt.Printf("      <data key=\"namespace\">%s</data>\n", xmlEscape(n.Namespace))
+		}
+		fmt.Printf("      <data key=\"name\">%s</data>\n", xmlEscape(n.Name))
+		fmt.Printf("      <data key=\"risk\">%d</data>\n", n.Risk)
+		for i, k := range keys {
+			if v, ok := n.Attrs[k]; ok {
+				fmt.Printf("      <data key=\"a%d\">%s</data>\n", i, xmlEscape(v))
+			}
+		}
+		fmt.Println("    </node>")
+	}
+
+	for i, e := range g.Edges() {
+		fmt.Printf("    <edge id=\"e%d\" source=\"%s\" target=\"%s\">\n", i, xmlEscape(e.From), xmlEscape(e.To))
+		fmt.Printf("      <data key=\"label\">%s</data>\n", xmlEscape(e.Label))
+		fmt.Println("    </edge>")
+	}
+
+	fmt.Println("  </graph>")
+	fmt.Println("</graphml>")
+}
+
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// cypherLabels are the Neo4j node labels for each kind. Every node also gets
+// the shared EKSNode label, which carries the unique id used to MERGE on.
+var cypherLabels = map[graph.Kind]string{
+	graph.KindPod:            "Pod",
+	graph.KindService:        "Service",
+	graph.KindEndpoint:       "Endpoint",
+	graph.KindServiceAccount: "ServiceAccount",
+	graph.KindIAMRole:        "IAMRole",
+	graph.KindIngress:        "Ingress",
+	graph.KindSecret:         "Secret",
+	graph.KindConfigMap:      "ConfigMap",
+	graph.KindNode:           "Node",
+	graph.KindRole:           "Role",
+	graph.KindClusterRole:    "ClusterRole",
+	graph.KindUser:           "User",
+	graph.KindGroup:          "Group",
+	graph.KindResource:       "APIResource",
+	graph.KindInternet:       "Internet",
+	graph.KindGateway:        "Gateway",
+	graph.KindRoute:          "Route",
+}
+
+// PrintCypher prints the graph as Cypher statements for Neo4j. Nodes are
+// merged on their ID and relationships on their endpoints, type and label,
+// so loading a later scan updates properties instead of duplicating data.
+// Nodes and relationships that disappeared from the cluster are not removed.
+func PrintCypher(g *graph.Graph) {
+	fmt.Println("// [i] Pipe the following into cypher-shell to load the graph into Neo4j")
+	fmt.Println("CREATE CONSTRAINT eks_node_id IF NOT EXISTS FOR (n:EKSNode) REQUIRE n.id IS UNIQUE;")
+
+	for _, n := range g.Nodes() {
+		props := []string{
+			"n.kind = " + cypherString(string(n.Kind)),
+			"n.namespace = " + cypherString(n.Namespace),
+			"n.name = " + cypherString(n.Name),
+			"n.risk = " + strconv.Itoa(n.Risk),
+		}
+		for _, k := range sortedKeys(n.Attrs) {
+			props = append(props, fmt.Sprintf("n.%s = %s", cypherIdent(k), cypherString(n.Attrs[k])))
+		}
+
+		label := cypherLabels[n.Kind]
+		if label == "" {
+			label = "Other"
+		}
+		fmt.Printf("MERGE (n:EKSNode {id: %s}) SET n:%s, %s;\n", cypherString(n.ID()), label, strings.Join(props, ", "))
+	}
+
+	for _, e := range g.Edges() {
+		fmt.Printf("MATCH (a:EKSNode {id: %s}), (b:EKSNode {id: %s}) MERGE (a)-[:%s {label: %s}]->(b);\n",
+			cypherString(e.From), cypherString(e.To), cypherRelType(e.Label), cypherString(e.Label))
+	}
+}
+
+// cypherRelType turns an edge label into a relationship type: routes-to
+// becomes ROUTES_TO. Labels with a detail suffix such as hostPath:/var/run or
+// cap:SYS_ADMIN share the type of their prefix; the full label is kept as a
+// property.
+func cypherRelType(label string) string {
+	if i := strings.Index(label, ":"); i > 0 {
+		label = label[:i]
+	}
+	var b strings.Builder
+	for _, r := range label {
+		switch {
+		case r >= 'a' && r <= 'z':
+			b.WriteRune(r - 'a' + 'A')
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+// cypherString quotes s as a Cypher string literal.
+func cypherString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// cypherIdent quotes a property name, since attribute names such as
+// external-ingress are not valid bare identifiers.
+func cypherIdent(s string) string {
+	return "`" + strings.ReplaceAll(s, "`", "``") + "`"
+}
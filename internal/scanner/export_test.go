@@ -0,0 +1,145 @@
+package scanner
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/khaugen7/eks-security-scanner/internal/graph"
+	"github.com/khaugen7/eks-security-scanner/internal/testhelpers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func exportTestGraph() *graph.Graph {
+	pod := graph.Node{Kind: graph.KindPod, Namespace: "shop", Name: "web-1", Attrs: map[string]string{"external-ingress": "denied"}}
+	node := graph.Node{Kind: graph.KindNode, Name: "ip-10-0-1-5", Risk: 8}
+	g := graph.New()
+	g.AddEdge(graph.Internet, graph.Node{Kind: graph.KindService, Namespace: "shop", Name: "o'brien & co"}, "exposes")
+	g.AddEdge(pod, node, "hostPath:/var/run/docker.sock")
+	return g
+}
+
+func TestPrintGraphML(t *testing.T) {
+	out := testhelpers.CaptureOutput(func() { PrintGraphML(exportTestGraph()) })
+
+	var doc struct {
+		Keys  []struct{ ID, AttrName string } `xml:"key"`
+		Graph struct {
+			Nodes []struct {
+				ID string `xml:"id,attr"`
+			} `xml:"node"`
+			Edges []struct {
+				Source string `xml:"source,attr"`
+				Target string `xml:"target,attr"`
+			} `xml:"edge"`
+		} `xml:"graph"`
+	}
+	if err := xml.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("output is not valid XML: %v\n%s", err, out)
+	}
+	if len(doc.Graph.Nodes) != 4 || len(doc.Graph.Edges) != 2 {
+		t.Errorf("got %d nodes and %d edges, want 4 and 2", len(doc.Graph.Nodes), len(doc.Graph.Edges))
+	}
+
+	for _, want := range []string{
+		`<key id="a0" for="node" attr.name="external-ingress" attr.type="string"/>`,
+		`<node id="svc/shop/o&#39;brien &amp; co">`,
+		`<data key="risk">8</data>`,
+		`<data key="a0">denied</data>`,
+		`<edge id="e1" source="pod/shop/web-1" target="node/ip-10-0-1-5">`,
+		`<data key="label">hostPath:/var/run/docker.sock</data>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestRunGraphCheck_GraphMLIsPipeable(t *testing.T) {
+	// A node with an instance ID makes the graph look up node IAM roles,
+	// which warns when AWS is unreachable.
+	loadAWSConfig := LoadAWSConfig
+	LoadAWSConfig = func(context.Context) (aws.Config, error) { return aws.Config{}, errors.New("no credentials") }
+	t.Cleanup(func() { LoadAWSConfig = loadAWSConfig })
+
+	client := fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "ip-10-0-1-5"}, Spec: corev1.NodeSpec{ProviderID: "aws:///us-east-1a/i-0123456789abcdef0"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "web"}, Spec: corev1.PodSpec{NodeName: "ip-10-0-1-5"}},
+	)
+	out := testhelpers.CaptureOutput(func() { RunGraphCheck("ns1", GraphOptions{Format: "graphml"}, client) })
+
+	var doc struct {
+		Graph struct {
+			Nodes []struct {
+				ID string `xml:"id,attr"`
+			} `xml:"node"`
+		} `xml:"graph"`
+	}
+	if err := xml.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("RunGraphCheck output is not valid XML: %v\n%s", err, out)
+	}
+	if !strings.HasPrefix(out, "<?xml") {
+		t.Errorf("output should start with the XML declaration:\n%s", out)
+	}
+	if len(doc.Graph.Nodes) == 0 {
+		t.Error("expected nodes in the graph")
+	}
+}
+
+func TestExport_RouteKindDoesNotShadowNodeKind(t *testing.T) {
+	route := gatewayObject{Kind: "HTTPRoute", Metadata: metav1.ObjectMeta{Name: "web", Namespace: "shop"}}
+	g := graph.New()
+	addGatewayEdges(g, nil, []gatewayObject{route})
+
+	graphML := testhelpers.CaptureOutput(func() { PrintGraphML(g) })
+	if n := strings.Count(graphML, `attr.name="kind"`); n != 1 {
+		t.Errorf("got %d kind keys, want 1:\n%s", n, graphML)
+	}
+	if !strings.Contains(graphML, `attr.name="route-kind"`) {
+		t.Errorf("missing route-kind key:\n%s", graphML)
+	}
+
+	cypher := testhelpers.CaptureOutput(func() { PrintCypher(g) })
+	if !strings.Contains(cypher, "SET n:Route, n.kind = 'route', n.namespace = 'shop', n.name = 'web', n.risk = 0, n.`route-kind` = 'HTTPRoute';") {
+		t.Errorf("route node kind overwritten:\n%s", cypher)
+	}
+}
+
+func TestPrintCypher(t *testing.T) {
+	out := testhelpers.CaptureOutput(func() { PrintCypher(exportTestGraph()) })
+
+	for _, want := range []string{
+		"CREATE CONSTRAINT eks_node_id IF NOT EXISTS FOR (n:EKSNode) REQUIRE n.id IS UNIQUE;",
+		"MERGE (n:EKSNode {id: 'internet'}) SET n:Internet, n.kind = 'internet', n.namespace = '', n.name = 'internet', n.risk = 0;",
+		`MERGE (n:EKSNode {id: 'svc/shop/o\'brien & co'}) SET n:Service,`,
+		"SET n:Pod, n.kind = 'pod', n.namespace = 'shop', n.name = 'web-1', n.risk = 0, n.`external-ingress` = 'denied';",
+		"MATCH (a:EKSNode {id: 'pod/shop/web-1'}), (b:EKSNode {id: 'node/ip-10-0-1-5'}) MERGE (a)-[:HOSTPATH {label: 'hostPath:/var/run/docker.sock'}]->(b);",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "CREATE (") {
+		t.Error("statements must MERGE so reloading a scan does not duplicate data")
+	}
+}
+
+func TestCypherRelType(t *testing.T) {
+	tests := map[string]string{
+		"uses":             "USES",
+		"routes-to":        "ROUTES_TO",
+		"can-read-secrets": "CAN_READ_SECRETS",
+		"cap:SYS_ADMIN":    "CAP",
+		"hostPID":          "HOSTPID",
+	}
+	for label, want := range tests {
+		if got := cypherRelType(label); got != want {
+			t.Errorf("cypherRelType(%q) = %q, want %q", label, got, want)
+		}
+	}
+}
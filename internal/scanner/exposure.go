@@ -0,0 +1,187 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/khaugen7/eks-security-scanner/internal/snapshot"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Load balancer schemes as used by the AWS Load Balancer Controller.
+const (
+	schemeInternetFacing = "internet-facing"
+	schemeInternal       = "internal"
+)
+
+// serviceScheme reports whether a LoadBalancer Service gets an internet-facing
+// or internal load balancer. The in-tree cloud provider defaults to
+// internet-facing; the AWS Load Balancer Controller, which handles Services
+// with the "external" type or its load balancer class, defaults to internal.
+func serviceScheme(svc corev1.Service) string {
+	if scheme := svc.Annotations["service.beta.kubernetes.io/aws-load-balancer-scheme"]; scheme != "" {
+		return scheme
+	}
+	if svc.Annotations["service.beta.kubernetes.io/aws-load-balancer-internal"] == "true" {
+		return schemeInternal
+	}
+	lbClass := svc.Spec.LoadBalancerClass != nil && *svc.Spec.LoadBalancerClass == "service.k8s.aws/nlb"
+	if lbClass || svc.Annotations["service.beta.kubernetes.io/aws-load-balancer-type"] == "external" {
+		return schemeInternal
+	}
+	return schemeInternetFacing
+}
+
+// ingressScheme reports whether an Ingress is reachable from the internet.
+// ALB Ingresses default to internal unless alb.ingress.kubernetes.io/scheme
+// says otherwise. Other controllers are assumed to be internet-facing since
+// their exposure depends on how the controller itself is published.
+func ingressScheme(ing networkingv1.Ingress) string {
+	if scheme := ing.Annotations["alb.ingress.kubernetes.io/scheme"]; scheme != "" {
+		return scheme
+	}
+	if ingressClass(ing) == "alb" {
+		return schemeInternal
+	}
+	return schemeInternetFacing
+}
+
+// ingressClass returns the Ingress class, from the spec or the older
+// kubernetes.io/ingress.class annotation.
+func ingressClass(ing networkingv1.Ingress) string {
+	if ing.Spec.IngressClassName != nil {
+		return *ing.Spec.IngressClassName
+	}
+	return ing.Annotations["kubernetes.io/ingress.class"]
+}
+
+// gatewayObject is the subset of a Gateway API Gateway or Route the graph
+// needs. The Gateway API types are not part of client-go, so objects are
+// decoded from the raw API response.
+type gatewayObject struct {
+	Kind     string            `json:"kind"`
+	Metadata metav1.ObjectMeta `json:"metadata"`
+	Spec     struct {
+		GatewayClassName string       `json:"gatewayClassName"`
+		ParentRefs       []gatewayRef `json:"parentRefs"`
+		Rules            []struct {
+			BackendRefs []gatewayRef `json:"backendRefs"`
+		} `json:"rules"`
+	} `json:"spec"`
+}
+
+// gatewayRef is a parentRef or backendRef. Kind and Namespace default to
+// Gateway/Service and the referring object's namespace.
+type gatewayRef struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+func (r gatewayRef) namespaceOr(def string) string {
+	if r.Namespace == "" {
+		return def
+	}
+	return r.Namespace
+}
+
+type gatewayList struct {
+	Items []gatewayObject `json:"items"`
+}
+
+// routeResources are the stable Gateway API route kinds.
+var routeResources = []struct {
+	Resource string
+	Kind     string
+}{
+	{"httproutes", "HTTPRoute"},
+	{"grpcroutes", "GRPCRoute"},
+}
+
+// gatewayLister is implemented by clients that already hold the Gateway API
+// list responses, such as the snapshot client.
+type gatewayLister interface {
+	GatewayList(resource string) ([]byte, bool)
+}
+
+// listGatewayResources fetches Gateways and Routes in namespace (all
+// namespaces if empty). Clusters without the Gateway API CRDs, and clients
+// that cannot make raw requests, return nothing.
+func listGatewayResources(namespace string, client kubernetes.Interface) ([]gatewayObject, []gatewayObject) {
+	if lister, ok := client.(gatewayLister); ok {
+		return listCollectedGatewayResources(namespace, lister)
+	}
+
+	rest := client.Discovery().RESTClient()
+	if rest == nil {
+		return nil, nil
+	}
+
+	list := func(resource, kind string) []gatewayObject {
+		path := []string{snapshot.GatewayAPIPath}
+		if namespace != "" {
+			path = append(path, "namespaces", namespace)
+		}
+		data, err := rest.Get().AbsPath(append(path, resource)...).DoRaw(context.TODO())
+		if err != nil {
+			return nil
+		}
+		items, err := decodeGatewayList(data, kind)
+		if err != nil {
+			return nil
+		}
+		return items
+	}
+
+	gateways := list("gateways", "Gateway")
+	var routes []gatewayObject
+	for _, r := range routeResources {
+		routes = append(routes, list(r.Resource, r.Kind)...)
+	}
+	return gateways, routes
+}
+
+func listCollectedGatewayResources(namespace string, lister gatewayLister) ([]gatewayObject, []gatewayObject) {
+	list := func(resource, kind string) []gatewayObject {
+		data, ok := lister.GatewayList(resource)
+		if !ok {
+			return nil
+		}
+		items, err := decodeGatewayList(data, kind)
+		if err != nil {
+			return nil
+		}
+		var inNamespace []gatewayObject
+		for _, item := range items {
+			if namespace == "" || item.Metadata.Namespace == namespace {
+				inNamespace = append(inNamespace, item)
+			}
+		}
+		return inNamespace
+	}
+
+	gateways := list("gateways", "Gateway")
+	var routes []gatewayObject
+	for _, r := range routeResources {
+		routes = append(routes, list(r.Resource, r.Kind)...)
+	}
+	return gateways, routes
+}
+
+// decodeGatewayList decodes a list response. List items usually omit their
+// kind, so it is filled in from the list being fetched.
+func decodeGatewayList(data []byte, kind string) ([]gatewayObject, error) {
+	var list gatewayList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	for i := range list.Items {
+		if list.Items[i].Kind == "" {
+			list.Items[i].Kind = kind
+		}
+	}
+	return list.Items, nil
+}
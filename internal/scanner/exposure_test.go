@@ -0,0 +1,230 @@
+package scanner
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/khaugen7/eks-security-scanner/internal/graph"
+)
+
+func TestServiceScheme(t *testing.T) {
+	nlbClass := "service.k8s.aws/nlb"
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		class       *string
+		want        string
+	}{
+		{"in-tree default", nil, nil, schemeInternetFacing},
+		{"legacy internal annotation", map[string]string{"service.beta.kubernetes.io/aws-load-balancer-internal": "true"}, nil, schemeInternal},
+		{"controller default", map[string]string{"service.beta.kubernetes.io/aws-load-balancer-type": "external"}, nil, schemeInternal},
+		{"controller class", nil, &nlbClass, schemeInternal},
+		{"explicit scheme", map[string]string{
+			"service.beta.kubernetes.io/aws-load-balancer-type":   "external",
+			"service.beta.kubernetes.io/aws-load-balancer-scheme": "internet-facing",
+		}, nil, schemeInternetFacing},
+	}
+	for _, tt := range tests {
+		svc := corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer, LoadBalancerClass: tt.class},
+		}
+		if got := serviceScheme(svc); got != tt.want {
+			t.Errorf("%s: serviceScheme = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIngressScheme(t *testing.T) {
+	alb := "alb"
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		class       *string
+		want        string
+	}{
+		{"non-ALB controller", nil, nil, schemeInternetFacing},
+		{"ALB default", nil, &alb, schemeInternal},
+		{"ALB legacy class annotation", map[string]string{"kubernetes.io/ingress.class": "alb"}, nil, schemeInternal},
+		{"ALB internet-facing", map[string]string{"alb.ingress.kubernetes.io/scheme": "internet-facing"}, &alb, schemeInternetFacing},
+	}
+	for _, tt := range tests {
+		ing := networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations},
+			Spec:       networkingv1.IngressSpec{IngressClassName: tt.class},
+		}
+		if got := ingressScheme(ing); got != tt.want {
+			t.Errorf("%s: ingressScheme = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeGatewayList(t *testing.T) {
+	data := []byte(`{
+		"apiVersion": "gateway.networking.k8s.io/v1",
+		"kind": "HTTPRouteList",
+		"items": [{
+			"metadata": {"name": "shop", "namespace": "web"},
+			"spec": {
+				"parentRefs": [{"name": "public", "namespace": "infra"}],
+				"rules": [{"backendRefs": [{"name": "frontend", "port": 80}]}]
+			}
+		}]
+	}`)
+
+	items, err := decodeGatewayList(data, "HTTPRoute")
+	if err != nil {
+		t.Fatalf("decodeGatewayList: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(items))
+	}
+	route := items[0]
+	if route.Kind != "HTTPRoute" || route.Metadata.Name != "shop" {
+		t.Errorf("unexpected route %+v", route)
+	}
+	if len(route.Spec.ParentRefs) != 1 || route.Spec.ParentRefs[0].namespaceOr("web") != "infra" {
+		t.Errorf("unexpected parentRefs %+v", route.Spec.ParentRefs)
+	}
+	if len(route.Spec.Rules) != 1 || route.Spec.Rules[0].BackendRefs[0].Name != "frontend" {
+		t.Errorf("unexpected rules %+v", route.Spec.Rules)
+	}
+
+	if _, err := decodeGatewayList([]byte("not json"), "HTTPRoute"); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestAddGatewayEdges(t *testing.T) {
+	gateways, err := decodeGatewayList([]byte(`{"items": [
+		{"metadata": {"name": "public", "namespace": "infra"}, "spec": {"gatewayClassName": "amazon-vpc-lattice"}}
+	]}`), "Gateway")
+	if err != nil {
+		t.Fatal(err)
+	}
+	routes, err := decodeGatewayList([]byte(`{"items": [
+		{"metadata": {"name": "shop", "namespace": "web"}, "spec": {
+			"parentRefs": [{"name": "public", "namespace": "infra"}, {"kind": "Service", "name": "mesh"}],
+			"rules": [{"backendRefs": [{"name": "frontend"}, {"kind": "ServiceImport", "name": "remote"}]}]
+		}}
+	]}`), "HTTPRoute")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := graph.New()
+	addGatewayEdges(g, gateways, routes)
+
+	edges := make(map[string]bool)
+	for _, e := range g.Edges() {
+		edges[e.From+" -"+e.Label+"-> "+e.To] = true
+	}
+	for _, want := range []string{
+		"internet -exposes-> gw/infra/public",
+		"gw/infra/public -attaches-> route/web/shop",
+		"route/web/shop -backend-ref-> svc/web/frontend",
+	} {
+		if !edges[want] {
+			t.Errorf("missing edge %s", want)
+		}
+	}
+	if len(edges) != 3 {
+		t.Errorf("expected non-Gateway parents and non-Service backends to be skipped, got %v", edges)
+	}
+	if n, _ := g.Node("gw/infra/public"); n.Attr("class") != "amazon-vpc-lattice" {
+		t.Errorf("missing gateway class: %v", n.Attrs)
+	}
+}
+
+func TestBuildThreatGraph_Exposure(t *testing.T) {
+	alb := "alb"
+	public := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "public", Namespace: "ns1"},
+		Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+	}
+	internal := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "internal",
+			Namespace:   "ns1",
+			Annotations: map[string]string{"service.beta.kubernetes.io/aws-load-balancer-scheme": "internal"},
+		},
+		Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+	}
+	nodePort := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "debug", Namespace: "ns1"},
+		Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeNodePort},
+	}
+	internetALB := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "shop",
+			Namespace:   "ns1",
+			Annotations: map[string]string{"alb.ingress.kubernetes.io/scheme": "internet-facing"},
+		},
+		Spec: networkingv1.IngressSpec{IngressClassName: &alb},
+	}
+	internalALB := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "admin", Namespace: "ns1"},
+		Spec:       networkingv1.IngressSpec{IngressClassName: &alb},
+	}
+
+	var client kubernetes.Interface = fake.NewSimpleClientset(public, internal, nodePort, internetALB, internalALB)
+	g := BuildThreatGraph("ns1", client)
+
+	var exposed []string
+	for _, e := range g.Out(graph.Internet.ID()) {
+		exposed = append(exposed, e.To)
+	}
+	want := []string{"svc/ns1/debug", "svc/ns1/public", "ing/ns1/shop"}
+	if len(exposed) != len(want) {
+		t.Fatalf("internet exposes %v, want %v", exposed, want)
+	}
+	for i := range want {
+		if exposed[i] != want[i] {
+			t.Errorf("internet exposes %v, want %v", exposed, want)
+			break
+		}
+	}
+	if n, _ := g.Node("ing/ns1/admin"); n.Attr("scheme") != schemeInternal {
+		t.Errorf("expected ALB Ingress without scheme to be internal, got %q", n.Attr("scheme"))
+	}
+}
+
+// collectedGateways serves Gateway API lists the way the snapshot client does.
+type collectedGateways struct {
+	*fake.Clientset
+	lists map[string]string
+}
+
+func (c collectedGateways) GatewayList(resource string) ([]byte, bool) {
+	data, ok := c.lists[resource]
+	return []byte(data), ok
+}
+
+func TestListGatewayResources_Collected(t *testing.T) {
+	client := collectedGateways{
+		Clientset: fake.NewSimpleClientset(),
+		lists: map[string]string{
+			"gateways": `{"items":[
+				{"metadata":{"namespace":"shop","name":"public"}},
+				{"metadata":{"namespace":"other","name":"internal"}}]}`,
+			"httproutes": `{"items":[{"metadata":{"namespace":"shop","name":"web"}}]}`,
+		},
+	}
+
+	gateways, routes := listGatewayResources("shop", client)
+	if len(gateways) != 1 || gateways[0].Metadata.Name != "public" || gateways[0].Kind != "Gateway" {
+		t.Errorf("gateways = %+v; want shop/public only", gateways)
+	}
+	if len(routes) != 1 || routes[0].Kind != "HTTPRoute" {
+		t.Errorf("routes = %+v; want one HTTPRoute", routes)
+	}
+
+	if gateways, _ := listGatewayResources("", client); len(gateways) != 2 {
+		t.Errorf("expected gateways from all namespaces, got %d", len(gateways))
+	}
+}
@@ -3,142 +3,628 @@ package scanner
 import (
 	"context"
 	"fmt"
-	"sort"
+	"io"
+	"os"
+	"strconv"
 	"strings"
 
+	"github.com/khaugen7/eks-security-scanner/internal/graph"
 	"github.com/khaugen7/eks-security-scanner/internal/utils"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-type GraphEdge struct {
-	From  string
-	To    string
-	Label string
+// GraphOptions controls how the threat graph is rendered.
+type GraphOptions struct {
+	// Format is one of ascii, dot, mermaid, graphml, cypher or html.
+	// Unknown formats fall back to ascii.
+	Format string
+
+	// OutFile is where the html format writes its page. The text formats
+	// always print to stdout.
+	OutFile string
+
+	// Focus limits the graph to the neighbourhood of one node, given by ID
+	// (pod/prod/api-7d9f) or an unambiguous name, within Depth hops in
+	// either direction. Depth zero means no limit.
+	Focus string
+	Depth int
+
+	// Kinds keeps only nodes of the listed kinds, e.g. pod, sa, iam-role.
+	Kinds []string
+
+	// OnlyRisky keeps only nodes with a risk score or finding.
+	OnlyRisky bool
+
+	// CollapseReplicas merges pods owned by the same controller into one
+	// node named after it, e.g. deployment/web.
+	CollapseReplicas bool
+
+	// SkipIAM disables the AWS lookups of the IAM roles behind each node's
+	// instance profile and of the policies that mark a role as admin.
+	SkipIAM bool
+}
+
+// DefaultHTMLGraphFile is the page written by the html format when no
+// output file is given.
+const DefaultHTMLGraphFile = "eks-threat-graph.html"
+
+// GraphResources are the cluster resources the threat graph is built from,
+// including the RBAC and NetworkPolicy reads made while annotating it.
+var GraphResources = []string{
+	"pods", "serviceaccounts", "services", "endpointslices", "ingresses", "nodes",
+	"rolebindings", "clusterrolebindings", "roles", "clusterroles",
+	"networkpolicies", "namespaces", "gateways", "httproutes", "grpcroutes",
 }
 
-func RunGraphCheck(outputFormat string, namespace string, client kubernetes.Interface) {
-	utils.PrintScannerHeader("Threat Graph")
+// RunGraphCheck builds the threat graph and prints it in opts.Format. Only
+// the ascii format is meant to be read in the terminal: for the others the
+// banner and any warnings go to stderr, so the output can be piped straight
+// into Graphviz, cypher-shell or an XML parser.
+func RunGraphCheck(namespace string, opts GraphOptions, client kubernetes.Interface) {
+	format := strings.ToLower(opts.Format)
+	var diag io.Writer = os.Stdout
+	if format != "" && format != "ascii" {
+		diag = os.Stderr
+	}
+	utils.FprintScannerHeader(diag, "Threat Graph")
+
+	g := BuildThreatGraph(namespace, client)
+	if !opts.SkipIAM {
+		addNodeIAMRoles(g)
+		annotateAdminRoles(g)
+	}
+
+	g, err := filterGraph(g, opts)
+	if err != nil {
+		fmt.Fprintf(diag, "Failed to filter graph: %v\n", err)
+		return
+	}
+
+	switch format {
+	case "dot":
+		PrintDOTGraph(g)
+	case "mermaid":
+		PrintMermaidGraph(g)
+	case "graphml":
+		PrintGraphML(g)
+	case "cypher":
+		PrintCypher(g)
+	case "html":
+		out := opts.OutFile
+		if out == "" {
+			out = DefaultHTMLGraphFile
+		}
+		if err := WriteHTMLGraph(out, g); err != nil {
+			fmt.Printf("Failed to write HTML graph: %v\n", err)
+			return
+		}
+		nodes, edges := g.Len()
+		fmt.Printf("[✓] Interactive threat graph written to %s (%d nodes, %d edges)\n", out, nodes, edges)
+	default:
+		PrintASCIIGraph(g)
+	}
+}
 
+// BuildThreatGraph collects the resources in namespace (all namespaces if
+// empty) and links them into a threat graph.
+func BuildThreatGraph(namespace string, client kubernetes.Interface) *graph.Graph {
 	pods, _ := client.CoreV1().Pods(namespace).List(context.TODO(), v1.ListOptions{})
+	serviceAccounts, _ := client.CoreV1().ServiceAccounts(namespace).List(context.TODO(), v1.ListOptions{})
 	services, _ := client.CoreV1().Services(namespace).List(context.TODO(), v1.ListOptions{})
-	endpoints, _ := client.CoreV1().Endpoints(namespace).List(context.TODO(), v1.ListOptions{})
+	slices, _ := client.DiscoveryV1().EndpointSlices(namespace).List(context.TODO(), v1.ListOptions{})
+	ingresses, _ := client.NetworkingV1().Ingresses(namespace).List(context.TODO(), v1.ListOptions{})
+	nodes, _ := client.CoreV1().Nodes().List(context.TODO(), v1.ListOptions{})
+	rbac, _ := buildRBACIndex(namespace, client)
+	policies, _ := newPolicyEvaluator(client)
+	if policies != nil && len(policies.policies) == 0 {
+		policies = nil
+	}
 
-	var edges []GraphEdge
+	nodesByName := make(map[string]corev1.Node)
+	for _, node := range nodes.Items {
+		nodesByName[node.Name] = node
+	}
+	sas := newServiceAccountIndex(serviceAccounts.Items)
+	podsByLabel := newPodLabelIndex(pods.Items)
+
+	g := graph.New()
 
 	// Pod → SA → IAM Role
 	for _, pod := range pods.Items {
-		podID := fmt.Sprintf("pod/%s/%s", pod.Namespace, pod.Name)
+		podNode := g.AddNode(newPodNode(pod))
 
 		saName := pod.Spec.ServiceAccountName
 		if saName == "" {
 			saName = "default"
 		}
-		saID := fmt.Sprintf("sa/%s/%s", pod.Namespace, saName)
-		edges = append(edges, GraphEdge{From: podID, To: saID, Label: "uses"})
+		saNode := newServiceAccountNode(pod.Namespace, saName, rbac)
+		// A pod that does not mount the token cannot act as its ServiceAccount
+		// in the API, but the pod identity webhook still injects a web
+		// identity token, so it keeps the ServiceAccount's IAM role.
+		sa, _ := sas.get(pod.Namespace, saName)
+		if tokenAutomounted(pod.Spec, sa) {
+			g.AddEdge(*podNode, saNode, "uses")
+		} else {
+			g.AddEdge(*podNode, saNode, "uses-no-token")
+			if iamArn := webIdentityRoleARN(pod.Spec, sa); iamArn != "" {
+				g.AddEdge(*podNode, newIAMRoleNode(iamArn), "web-identity")
+			}
+		}
 
-		// Check for IRSA annotation
-		sa, err := client.CoreV1().ServiceAccounts(pod.Namespace).Get(context.TODO(), saName, v1.GetOptions{})
-		if err == nil {
-			iamArn := sa.Annotations["eks.amazonaws.com/role-arn"]
-			if iamArn != "" {
-				roleID := fmt.Sprintf("iam-role/%s", strings.Split(iamArn, "/")[1])
-				edges = append(edges, GraphEdge{From: saID, To: roleID, Label: "assumes"})
+		// Pod → Secret/ConfigMap, one edge per mount method
+		for _, ref := range podSecretRefs(pod) {
+			g.AddEdge(*podNode, graph.Node{Kind: graph.KindSecret, Namespace: pod.Namespace, Name: ref.Name}, ref.Method)
+		}
+		for _, ref := range podConfigMapRefs(pod) {
+			g.AddEdge(*podNode, graph.Node{Kind: graph.KindConfigMap, Namespace: pod.Namespace, Name: ref.Name}, ref.Method)
+		}
+
+		// Pod → Node, one edge per escape vector
+		if pod.Spec.NodeName != "" {
+			node, ok := nodesByName[pod.Spec.NodeName]
+			if !ok {
+				node.Name = pod.Spec.NodeName
+			}
+			for _, v := range podEscapeVectors(pod) {
+				g.AddEdge(*podNode, newNodeNode(node), v.Label)
 			}
 		}
+
+		// Check for IRSA annotation
+		if iamArn := sa.Annotations[irsaRoleAnnotation]; iamArn != "" {
+			g.AddEdge(saNode, newIAMRoleNode(iamArn), "assumes")
+		}
+	}
+
+	// Secrets shared by many pods widen the impact of any one compromise
+	for _, secret := range g.NodesOfKind(graph.KindSecret) {
+		secret.Attrs["pods"] = strconv.Itoa(len(consumers(g, secret.ID())))
 	}
 
 	// Pod → Service
 	for _, svc := range services.Items {
-		svcID := fmt.Sprintf("svc/%s/%s", svc.Namespace, svc.Name)
+		svcNode := graph.Node{
+			Kind:      graph.KindService,
+			Namespace: svc.Namespace,
+			Name:      svc.Name,
+			Attrs:     map[string]string{"type": string(svc.Spec.Type)},
+		}
+		switch svc.Spec.Type {
+		case corev1.ServiceTypeLoadBalancer:
+			svcNode.Attrs["scheme"] = serviceScheme(svc)
+			if svcNode.Attrs["scheme"] == schemeInternetFacing {
+				g.AddEdge(graph.Internet, svcNode, "exposes")
+			}
+		case corev1.ServiceTypeNodePort:
+			g.AddEdge(graph.Internet, svcNode, "exposes")
+		}
+		g.AddNode(svcNode)
+
+		for _, pod := range podsByLabel.selected(svc.Namespace, svc.Spec.Selector) {
+			g.AddEdge(newPodNode(pod), svcNode, "matches")
+			if policies != nil {
+				annotateIngressPolicy(g, policies, svc, pod)
+			}
+		}
+	}
+
+	// Service → Pod through EndpointSlices
+	for _, slice := range slices.Items {
+		addEndpointSliceEdges(g, slice)
+	}
+
+	// Ingress → Service
+	for _, ing := range ingresses.Items {
+		ingNode := graph.Node{
+			Kind:      graph.KindIngress,
+			Namespace: ing.Namespace,
+			Name:      ing.Name,
+			Attrs:     map[string]string{},
+		}
+		if class := ingressClass(ing); class != "" {
+			ingNode.Attrs["class"] = class
+		}
+		ingNode.Attrs["scheme"] = ingressScheme(ing)
+		g.AddNode(ingNode)
+		if ingNode.Attrs["scheme"] == schemeInternetFacing {
+			g.AddEdge(graph.Internet, ingNode, "exposes")
+		}
+
+		for _, backend := range ingressServiceBackends(ing) {
+			svcNode := graph.Node{Kind: graph.KindService, Namespace: ing.Namespace, Name: backend}
+			g.AddEdge(ingNode, svcNode, "backend")
+		}
+	}
+
+	// Internet → Gateway → Route → Service
+	gateways, routes := listGatewayResources(namespace, client)
+	addGatewayEdges(g, gateways, routes)
+
+	// Subject → Role → Resource
+	if rbac != nil {
+		addRBACEdges(g, namespace, rbac, client)
+	}
+
+	return g
+}
+
+// addGatewayEdges links Gateway API Gateways to the Routes attached to them
+// and Routes to their backend Services. Gateways are treated as
+// internet-facing since their exposure depends on the implementation.
+func addGatewayEdges(g *graph.Graph, gateways, routes []gatewayObject) {
+	for _, gw := range gateways {
+		gwNode := graph.Node{
+			Kind:      graph.KindGateway,
+			Namespace: gw.Metadata.Namespace,
+			Name:      gw.Metadata.Name,
+			Attrs:     map[string]string{"class": gw.Spec.GatewayClassName},
+		}
+		g.AddEdge(graph.Internet, gwNode, "exposes")
+	}
+
+	for _, route := range routes {
+		// The route's kind is not stored as "kind", which the exports use
+		// for the node kind.
+		routeNode := graph.Node{
+			Kind:      graph.KindRoute,
+			Namespace: route.Metadata.Namespace,
+			Name:      route.Metadata.Name,
+			Attrs:     map[string]string{"route-kind": route.Kind},
+		}
+		g.AddNode(routeNode)
+
+		for _, parent := range route.Spec.ParentRefs {
+			if parent.Kind != "" && parent.Kind != "Gateway" {
+				continue
+			}
+			gwNode := graph.Node{Kind: graph.KindGateway, Namespace: parent.namespaceOr(route.Metadata.Namespace), Name: parent.Name}
+			g.AddEdge(gwNode, routeNode, "attaches")
+		}
+		for _, rule := range route.Spec.Rules {
+			for _, backend := range rule.BackendRefs {
+				if backend.Kind != "" && backend.Kind != "Service" {
+					continue
+				}
+				svcNode := graph.Node{Kind: graph.KindService, Namespace: backend.namespaceOr(route.Metadata.Namespace), Name: backend.Name}
+				g.AddEdge(routeNode, svcNode, "backend-ref")
+			}
+		}
+	}
+}
+
+// addRBACEdges links every bound subject to its Roles and ClusterRoles and
+// each role to the resources its rules grant dangerous access to. ServiceAccounts
+// outside namespace are skipped when a namespace is given.
+func addRBACEdges(g *graph.Graph, namespace string, rbac *rbacIndex, client kubernetes.Interface) {
+	granted := make(map[string]bool)
 
-		for _, pod := range pods.Items {
-			if pod.Namespace != svc.Namespace {
+	for _, s := range rbac.subjects {
+		var subject graph.Node
+		switch s.Kind {
+		case rbacv1.ServiceAccountKind:
+			if namespace != "" && s.Namespace != namespace {
 				continue
 			}
-			if selectorMatches(svc.Spec.Selector, pod.Labels) {
-				podID := fmt.Sprintf("pod/%s/%s", pod.Namespace, pod.Name)
-				edges = append(edges, GraphEdge{From: podID, To: svcID, Label: "matches"})
+			subject = newServiceAccountNode(s.Namespace, s.Name, rbac)
+		case rbacv1.UserKind:
+			subject = graph.Node{Kind: graph.KindUser, Name: s.Name}
+		case rbacv1.GroupKind:
+			subject = graph.Node{Kind: graph.KindGroup, Name: s.Name}
+		default:
+			continue
+		}
+
+		for _, b := range rbac.subjectBindings(s) {
+			role := newRoleNode(b)
+			g.AddEdge(subject, role, "bound-to")
+
+			if granted[role.ID()] {
+				continue
 			}
+			granted[role.ID()] = true
+			for _, r := range resolveRules([]rbacBinding{b}, client) {
+				for _, c := range ruleCapabilities(r.Rule) {
+					g.AddEdge(role, newResourceNode(r.Namespace, c), c.Name)
+				}
+			}
+		}
+	}
+}
+
+// newRoleNode returns the role a binding grants. A ClusterRole referenced by
+// a RoleBinding only applies in the binding's namespace, so it gets its own
+// namespaced node rather than sharing the cluster-wide one.
+func newRoleNode(b rbacBinding) graph.Node {
+	n := graph.Node{Kind: graph.KindRole, Namespace: b.Namespace, Name: b.RoleRef.Name}
+	if b.RoleRef.Kind == "ClusterRole" {
+		n.Kind = graph.KindClusterRole
+	}
+	return n
+}
+
+// newResourceNode is the resource a capability applies to, scoped to
+// namespace or cluster-wide if empty. Capabilities limited to named objects
+// get their own node, e.g. secrets/app-config.
+func newResourceNode(namespace string, c grantedCapability) graph.Node {
+	name := c.Resource
+	if c.Group != "" && c.Group != "*" {
+		name += "." + c.Group
+	}
+	if len(c.ResourceNames) > 0 {
+		name += "/" + strings.Join(c.ResourceNames, ",")
+	}
+	risk := 8
+	if c.Severity == "CRITICAL" {
+		risk = 10
+	}
+	return graph.Node{
+		Kind:      graph.KindResource,
+		Namespace: namespace,
+		Name:      name,
+		Attrs:     map[string]string{"capability": c.Name},
+		Risk:      risk,
+	}
+}
+
+// annotateIngressPolicy records on a Service's pod whether NetworkPolicies
+// isolate it for ingress and, if so, whether traffic from the internet, as
+// forwarded by load balancers that preserve the client address, is still
+// admitted on one of the Service's target ports.
+func annotateIngressPolicy(g *graph.Graph, policies *policyEvaluator, svc corev1.Service, pod corev1.Pod) {
+	if !policies.isolated(pod, networkingv1.PolicyTypeIngress) {
+		return
+	}
+	n, _ := g.Node(newPodNode(pod).ID())
+	n.Attrs["ingress-isolated"] = "true"
+	if n.Attrs["external-ingress"] == "allowed" {
+		return
+	}
+	n.Attrs["external-ingress"] = "denied"
+	for _, port := range servicePorts(svc, pod) {
+		if policies.ingressAllowed(nil, pod, port) {
+			n.Attrs["external-ingress"] = "allowed"
+			return
 		}
 	}
+}
+
+// addEndpointSliceEdges links a Service to the pods behind its ready
+// endpoints. Endpoints without a Pod targetRef, e.g. in manually managed
+// slices, are kept as address nodes.
+func addEndpointSliceEdges(g *graph.Graph, slice discoveryv1.EndpointSlice) {
+	svcName := slice.Labels[discoveryv1.LabelServiceName]
+	if svcName == "" {
+		return
+	}
+	svcNode := graph.Node{Kind: graph.KindService, Namespace: slice.Namespace, Name: svcName}
 
-	// Service → Endpoints
-	for _, ep := range endpoints.Items {
-		svcID := fmt.Sprintf("svc/%s/%s", ep.Namespace, ep.Name)
-		for _, subset := range ep.Subsets {
-			for _, addr := range subset.Addresses {
-				epID := fmt.Sprintf("ep/%s/%s", ep.Namespace, addr.IP)
-				edges = append(edges, GraphEdge{From: svcID, To: epID, Label: "routes-to"})
+	for _, ep := range slice.Endpoints {
+		if ep.Conditions.Ready != nil && !*ep.Conditions.Ready {
+			continue
+		}
+		if ref := ep.TargetRef; ref != nil && ref.Kind == "Pod" {
+			podNamespace := ref.Namespace
+			if podNamespace == "" {
+				podNamespace = slice.Namespace
 			}
+			podNode := graph.Node{Kind: graph.KindPod, Namespace: podNamespace, Name: ref.Name}
+			g.AddEdge(svcNode, podNode, "routes-to")
+			continue
+		}
+		for _, addr := range ep.Addresses {
+			epNode := graph.Node{Kind: graph.KindEndpoint, Namespace: slice.Namespace, Name: addr}
+			g.AddEdge(svcNode, epNode, "routes-to")
 		}
 	}
+}
 
-	if strings.ToLower(outputFormat) == "dot" {
-		PrintDOTGraph(edges)
-	} else {
-		PrintASCIIGraph(edges)
+// consumers returns the distinct pods with an edge into id.
+func consumers(g *graph.Graph, id string) []string {
+	var pods []string
+	seen := make(map[string]bool)
+	for _, e := range g.In(id) {
+		if n, _ := g.Node(e.From); n.Kind == graph.KindPod && !seen[e.From] {
+			seen[e.From] = true
+			pods = append(pods, e.From)
+		}
 	}
+	return pods
 }
 
-func selectorMatches(selector, labels map[string]string) bool {
-	for k, v := range selector {
-		if labels[k] != v {
-			return false
+// ingressServiceBackends returns the distinct Service names an Ingress routes
+// to, default backend first.
+func ingressServiceBackends(ing networkingv1.Ingress) []string {
+	var names []string
+	seen := make(map[string]bool)
+	add := func(b *networkingv1.IngressBackend) {
+		if b == nil || b.Service == nil || seen[b.Service.Name] {
+			return
+		}
+		seen[b.Service.Name] = true
+		names = append(names, b.Service.Name)
+	}
+
+	add(ing.Spec.DefaultBackend)
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, p := range rule.HTTP.Paths {
+			add(&p.Backend)
 		}
 	}
-	return true
+	return names
 }
 
-func PrintDOTGraph(edges []GraphEdge) {
-	fmt.Print("\n[i] Copy the following DOT graph to https://edotor.net to visualize:\n")
+// newServiceAccountNode records cluster-admin grants on the ServiceAccount.
+// A RoleBinding to cluster-admin only grants admin within its namespace.
+func newServiceAccountNode(namespace, name string, rbac *rbacIndex) graph.Node {
+	n := graph.Node{Kind: graph.KindServiceAccount, Namespace: namespace, Name: name, Attrs: map[string]string{}}
+	if rbac == nil {
+		return n
+	}
+	for _, b := range rbac.serviceAccountBindings(namespace, name) {
+		if b.RoleRef.Kind != "ClusterRole" || b.RoleRef.Name != "cluster-admin" {
+			continue
+		}
+		if b.Kind == "ClusterRoleBinding" {
+			n.Attrs["cluster-admin"] = b.Name
+			n.Risk = 10
+			break
+		}
+		n.Attrs["namespace-admin"] = b.Name
+		n.Risk = max(n.Risk, 7)
+	}
+	return n
+}
 
-	fmt.Println("digraph eks_threat_graph {")
-	fmt.Println("  rankdir=LR;")
+// irsaRoleAnnotation maps a ServiceAccount to an IAM role through IRSA.
+const irsaRoleAnnotation = "eks.amazonaws.com/role-arn"
 
-	for _, edge := range edges {
-		fmt.Printf("  \"%s\" -> \"%s\" [label=\"%s\"];\n", edge.From, edge.To, edge.Label)
+// newIAMRoleNode returns the node for the IAM role with the given ARN.
+func newIAMRoleNode(arn string) graph.Node {
+	return graph.Node{
+		Kind:  graph.KindIAMRole,
+		Name:  extractRoleName(arn),
+		Attrs: map[string]string{"arn": arn},
 	}
-	fmt.Println("}")
 }
 
-func PrintASCIIGraph(edges []GraphEdge) {
-	graph := make(map[string][]GraphEdge)
+// webIdentityRoleARN returns the IAM role a pod can assume with a web
+// identity token: its ServiceAccount's IRSA role, which the pod identity
+// webhook injects a token for, or the AWS_ROLE_ARN set on a container of a
+// pod that projects its own ServiceAccount token.
+func webIdentityRoleARN(spec corev1.PodSpec, sa corev1.ServiceAccount) string {
+	if arn := sa.Annotations[irsaRoleAnnotation]; arn != "" {
+		return arn
+	}
+	if !projectsServiceAccountToken(spec) {
+		return ""
+	}
+	for _, c := range allContainers(spec) {
+		for _, env := range c.Env {
+			if env.Name == "AWS_ROLE_ARN" && env.Value != "" {
+				return env.Value
+			}
+		}
+	}
+	return ""
+}
 
-	for _, edge := range edges {
-		graph[edge.From] = append(graph[edge.From], edge)
+// projectsServiceAccountToken reports whether the pod mounts a projected
+// ServiceAccount token of its own, such as the aws-iam-token volume.
+func projectsServiceAccountToken(spec corev1.PodSpec) bool {
+	for _, v := range spec.Volumes {
+		if v.Projected == nil {
+			continue
+		}
+		for _, src := range v.Projected.Sources {
+			if src.ServiceAccountToken != nil {
+				return true
+			}
+		}
 	}
+	return false
+}
 
-	sources := make([]string, 0, len(graph))
-	for src := range graph {
-		sources = append(sources, src)
+// newPodNode records the pod's host-level privileges as attributes and
+// scores it by the most severe one.
+func newPodNode(pod corev1.Pod) graph.Node {
+	n := graph.Node{
+		Kind:      graph.KindPod,
+		Namespace: pod.Namespace,
+		Name:      pod.Name,
+		Attrs:     map[string]string{},
 	}
-	sort.Strings(sources)
 
+	flag := func(attr string, risk int) {
+		n.Attrs[attr] = "true"
+		n.Risk = max(n.Risk, risk)
+	}
+	for _, c := range allContainers(pod.Spec) {
+		if c.SecurityContext != nil && c.SecurityContext.Privileged != nil && *c.SecurityContext.Privileged {
+			flag("privileged", 10)
+		}
+	}
+	if pod.Spec.HostPID {
+		flag("hostPID", 8)
+	}
+	if pod.Spec.HostNetwork {
+		flag("hostNetwork", 7)
+	}
+	if pod.Spec.HostIPC {
+		flag("hostIPC", 5)
+	}
+	for _, v := range pod.Spec.Volumes {
+		if v.HostPath != nil {
+			flag("hostPath", 7)
+		}
+	}
+	if owner := podOwner(pod); owner != "" {
+		n.Attrs["owner"] = owner
+	}
+	return n
+}
+
+// podOwner returns the workload that manages the pod as kind/name, e.g.
+// deployment/web, or "" for bare pods. Pods of a Deployment are owned by a
+// ReplicaSet named after it plus the pod-template-hash label.
+func podOwner(pod corev1.Pod) string {
+	ref := v1.GetControllerOf(&pod)
+	if ref == nil {
+		return ""
+	}
+	kind, name := strings.ToLower(ref.Kind), ref.Name
+	if hash := pod.Labels["pod-template-hash"]; kind == "replicaset" && hash != "" && strings.HasSuffix(name, "-"+hash) {
+		kind, name = "deployment", strings.TrimSuffix(name, "-"+hash)
+	}
+	return kind + "/" + name
+}
+
+func PrintASCIIGraph(g *graph.Graph) {
 	fmt.Print("\n\nThreat Graph (ASCII Format):\n")
 
-	for _, src := range sources {
-		fmt.Printf("%s\n", formatNode(src))
+	for _, src := range g.Sources() {
+		from, _ := g.Node(src)
+		fmt.Printf("%s\n", formatNode(from))
 
-		for _, edge := range graph[src] {
-			fmt.Printf("  └─[%s]→ %s\n", edge.Label, formatNode(edge.To))
+		for _, edge := range g.Out(src) {
+			to, _ := g.Node(edge.To)
+			fmt.Printf("  └─[%s]→ %s\n", edge.Label, formatNode(to))
 		}
 	}
 }
 
-func formatNode(id string) string {
-	switch {
-	case strings.HasPrefix(id, "pod/"):
-		return fmt.Sprintf("[POD] %s", strings.TrimPrefix(id, "pod/"))
-	case strings.HasPrefix(id, "svc/"):
-		return fmt.Sprintf("[SVC] %s", strings.TrimPrefix(id, "svc/"))
-	case strings.HasPrefix(id, "ep/"):
-		return fmt.Sprintf("[EP]  %s", strings.TrimPrefix(id, "ep/"))
-	case strings.HasPrefix(id, "sa/"):
-		return fmt.Sprintf("[SA]  %s", strings.TrimPrefix(id, "sa/"))
-	case strings.HasPrefix(id, "iam-role/"):
-		return fmt.Sprintf("[IAM] %s", strings.TrimPrefix(id, "iam-role/"))
-	default:
-		return id
+// nodeTags are the fixed-width ASCII labels for each node kind.
+var nodeTags = map[graph.Kind]string{
+	graph.KindPod:            "[POD]",
+	graph.KindService:        "[SVC]",
+	graph.KindEndpoint:       "[EP] ",
+	graph.KindServiceAccount: "[SA] ",
+	graph.KindIAMRole:        "[IAM]",
+	graph.KindIngress:        "[ING]",
+	graph.KindRole:           "[ROL]",
+	graph.KindClusterRole:    "[CRL]",
+	graph.KindUser:           "[USR]",
+	graph.KindGroup:          "[GRP]",
+	graph.KindResource:       "[RES]",
+	graph.KindInternet:       "[NET]",
+	graph.KindGateway:        "[GW] ",
+	graph.KindRoute:          "[RTE]",
+	graph.KindNode:           "[NOD]",
+	graph.KindSecret:         "[SEC]",
+	graph.KindConfigMap:      "[CM] ",
+}
+
+func formatNode(n *graph.Node) string {
+	tag, ok := nodeTags[n.Kind]
+	if !ok {
+		return n.ID()
 	}
+	return fmt.Sprintf("%s %s", tag, n.DisplayName())
 }
@@ -1,49 +1,71 @@
 package scanner
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/khaugen7/eks-security-scanner/internal/graph"
 	"github.com/khaugen7/eks-security-scanner/internal/testhelpers"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
 )
 
-func TestSelectorMatches(t *testing.T) {
-	sel := map[string]string{"app": "foo", "tier": "backend"}
-	good := map[string]string{"app": "foo", "tier": "backend", "x": "y"}
-	if !selectorMatches(sel, good) {
-		t.Error("expected selectorMatches to return true")
-	}
-	if selectorMatches(sel, map[string]string{"app": "foo"}) {
-		t.Error("expected false when missing a key")
+func TestFormatNode(t *testing.T) {
+	cases := []struct {
+		in   graph.Node
+		want string
+	}{
+		{graph.Node{Kind: graph.KindPod, Namespace: "ns", Name: "p"}, "[POD] ns/p"},
+		{graph.Node{Kind: graph.KindService, Namespace: "ns", Name: "s"}, "[SVC] ns/s"},
+		{graph.Node{Kind: graph.KindEndpoint, Namespace: "ns", Name: "1.2.3.4"}, "[EP]  ns/1.2.3.4"},
+		{graph.Node{Kind: graph.KindServiceAccount, Namespace: "ns", Name: "sa"}, "[SA]  ns/sa"},
+		{graph.Node{Kind: graph.KindIAMRole, Name: "X"}, "[IAM] X"},
+		{graph.Node{Kind: graph.KindClusterRole, Name: "view"}, "[CRL] view"},
+		{graph.Node{Kind: graph.KindResource, Namespace: "ns", Name: "secrets"}, "[RES] ns/secrets"},
+		{graph.Node{Kind: "foo", Name: "bar"}, "foo/bar"},
 	}
-	if selectorMatches(sel, map[string]string{"app": "bar", "tier": "backend"}) {
-		t.Error("expected false when value differs")
+	for _, c := range cases {
+		if got := formatNode(&c.in); got != c.want {
+			t.Errorf("formatNode(%q) = %q; want %q", c.in.ID(), got, c.want)
+		}
 	}
 }
 
-func TestFormatNode(t *testing.T) {
-	cases := []struct{ in, want string }{
-		{"pod/ns/p", "[POD] ns/p"},
-		{"svc/ns/s", "[SVC] ns/s"},
-		{"ep/ns/1.2.3.4", "[EP]  ns/1.2.3.4"},
-		{"sa/ns/sa", "[SA]  ns/sa"},
-		{"iam-role/X", "[IAM] X"},
-		{"foo/bar", "foo/bar"},
+func TestNewPodNode(t *testing.T) {
+	tru := true
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "p", Namespace: "ns"},
+		Spec: corev1.PodSpec{
+			HostNetwork: true,
+			Containers:  []corev1.Container{{Name: "c", SecurityContext: &corev1.SecurityContext{Privileged: &tru}}},
+		},
 	}
-	for _, c := range cases {
-		if got := formatNode(c.in); got != c.want {
-			t.Errorf("formatNode(%q) = %q; want %q", c.in, got, c.want)
-		}
+	n := newPodNode(pod)
+	if n.ID() != "pod/ns/p" {
+		t.Errorf("unexpected ID %q", n.ID())
+	}
+	if n.Attr("privileged") != "true" || n.Attr("hostNetwork") != "true" {
+		t.Errorf("missing privilege attributes: %v", n.Attrs)
+	}
+	if n.Risk != 10 {
+		t.Errorf("Risk = %d; want 10", n.Risk)
 	}
 }
 
 func TestPrintDOTGraph(t *testing.T) {
-	edges := []GraphEdge{{From: "pod/ns/p", To: "sa/ns/sa", Label: "uses"}}
-	out := testhelpers.CaptureOutput(func() { PrintDOTGraph(edges) })
+	g := graph.New()
+	g.AddEdge(graph.Node{Kind: graph.KindPod, Namespace: "ns", Name: "p"}, graph.Node{Kind: graph.KindServiceAccount, Namespace: "ns", Name: "sa"}, "uses")
+	out := testhelpers.CaptureOutput(func() { PrintDOTGraph(g) })
 	if !strings.Contains(out, "digraph eks_threat_graph") {
 		t.Error("missing DOT header")
 	}
@@ -53,11 +75,15 @@ func TestPrintDOTGraph(t *testing.T) {
 }
 
 func TestPrintASCIIGraph(t *testing.T) {
-	edges := []GraphEdge{
-		{From: "pod/ns/p", To: "svc/ns/s", Label: "matches"},
-		{From: "svc/ns/s", To: "ep/ns/1.2.3.4", Label: "routes-to"},
-	}
-	out := testhelpers.CaptureOutput(func() { PrintASCIIGraph(edges) })
+	pod := graph.Node{Kind: graph.KindPod, Namespace: "ns", Name: "p"}
+	svc := graph.Node{Kind: graph.KindService, Namespace: "ns", Name: "s"}
+	ep := graph.Node{Kind: graph.KindEndpoint, Namespace: "ns", Name: "1.2.3.4"}
+	g := graph.New()
+	g.AddEdge(pod, svc, "matches")
+	g.AddEdge(svc, ep, "routes-to")
+	g.AddEdge(pod, svc, "matches")
+
+	out := testhelpers.CaptureOutput(func() { PrintASCIIGraph(g) })
 	if !strings.Contains(out, "Threat Graph (ASCII Format):") {
 		t.Error("missing ASCII header")
 	}
@@ -65,6 +91,9 @@ func TestPrintASCIIGraph(t *testing.T) {
 		!strings.Contains(out, "└─[matches]→ [SVC] ns/s") {
 		t.Error("missing pod→svc line")
 	}
+	if strings.Count(out, "└─[matches]→") != 1 {
+		t.Error("expected duplicate edge to be printed once")
+	}
 	if !strings.Contains(out, "[SVC] ns/s") ||
 		!strings.Contains(out, "└─[routes-to]→ [EP]  ns/1.2.3.4") {
 		t.Error("missing svc→ep line")
@@ -88,13 +117,20 @@ func TestRunGraphCheck_ASCII(t *testing.T) {
 		ObjectMeta: metav1.ObjectMeta{Name: "svc1", Namespace: "ns1"},
 		Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "x"}},
 	}
-	eps := &corev1.Endpoints{
-		ObjectMeta: metav1.ObjectMeta{Name: "svc1", Namespace: "ns1"},
-		Subsets:    []corev1.EndpointSubset{{Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}}}},
+	eps := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "svc1-abc12",
+			Namespace: "ns1",
+			Labels:    map[string]string{discoveryv1.LabelServiceName: "svc1"},
+		},
+		Endpoints: []discoveryv1.Endpoint{{
+			Addresses: []string{"10.0.0.1"},
+			TargetRef: &corev1.ObjectReference{Kind: "Pod", Namespace: "ns1", Name: "pod1"},
+		}},
 	}
 
 	var client kubernetes.Interface = fake.NewSimpleClientset(sa, pod, svc, eps)
-	out := testhelpers.CaptureOutput(func() { RunGraphCheck("ascii", "ns1", client) })
+	out := testhelpers.CaptureOutput(func() { RunGraphCheck("ns1", GraphOptions{Format: "ascii", SkipIAM: true}, client) })
 
 	// Pod→SA
 	if !strings.Contains(out, "[POD] ns1/pod1") ||
@@ -110,9 +146,9 @@ func TestRunGraphCheck_ASCII(t *testing.T) {
 	if !strings.Contains(out, "└─[matches]→ [SVC] ns1/svc1") {
 		t.Error("pod→svc edge missing")
 	}
-	// Svc→Ep
-	if !strings.Contains(out, "└─[routes-to]→ [EP]  ns1/10.0.0.1") {
-		t.Error("svc→ep edge missing")
+	// Svc→Pod through the EndpointSlice targetRef
+	if !strings.Contains(out, "└─[routes-to]→ [POD] ns1/pod1") {
+		t.Error("svc→pod edge missing")
 	}
 }
 
@@ -132,13 +168,20 @@ func TestRunGraphCheck_DOT(t *testing.T) {
 		ObjectMeta: metav1.ObjectMeta{Name: "svc1", Namespace: "ns1"},
 		Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "x"}},
 	}
-	eps := &corev1.Endpoints{
-		ObjectMeta: metav1.ObjectMeta{Name: "svc1", Namespace: "ns1"},
-		Subsets:    []corev1.EndpointSubset{{Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}}}},
+	eps := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "svc1-abc12",
+			Namespace: "ns1",
+			Labels:    map[string]string{discoveryv1.LabelServiceName: "svc1"},
+		},
+		Endpoints: []discoveryv1.Endpoint{{
+			Addresses: []string{"10.0.0.1"},
+			TargetRef: &corev1.ObjectReference{Kind: "Pod", Namespace: "ns1", Name: "pod1"},
+		}},
 	}
 
 	var client kubernetes.Interface = fake.NewSimpleClientset(sa, pod, svc, eps)
-	out := testhelpers.CaptureOutput(func() { RunGraphCheck("dot", "ns1", client) })
+	out := testhelpers.CaptureOutput(func() { RunGraphCheck("ns1", GraphOptions{Format: "dot", SkipIAM: true}, client) })
 
 	if !strings.Contains(out, "digraph eks_threat_graph") {
 		t.Error("missing dot graph header")
@@ -147,3 +190,289 @@ func TestRunGraphCheck_DOT(t *testing.T) {
 		t.Error("missing uses edge")
 	}
 }
+
+func TestRunGraphCheck_SkipIAM(t *testing.T) {
+	loadAWSConfig := LoadAWSConfig
+	LoadAWSConfig = func(context.Context) (aws.Config, error) {
+		t.Error("AWS config loaded despite SkipIAM")
+		return aws.Config{}, nil
+	}
+	t.Cleanup(func() { LoadAWSConfig = loadAWSConfig })
+
+	client := fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "ip-10-0-1-5"}, Spec: corev1.NodeSpec{ProviderID: "aws:///us-east-1a/i-0123456789abcdef0"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "web"}, Spec: corev1.PodSpec{NodeName: "ip-10-0-1-5", HostPID: true}},
+	)
+	out := testhelpers.CaptureOutput(func() { RunGraphCheck("ns1", GraphOptions{SkipIAM: true}, client) })
+	if !strings.Contains(out, "ip-10-0-1-5") {
+		t.Errorf("expected the node in the graph:\n%s", out)
+	}
+}
+
+func TestRunGraphCheck_AdminRoles(t *testing.T) {
+	// A stub IAM endpoint giving the role one inline Allow */* policy
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		action := r.Form.Get("Action")
+		var result string
+		switch action {
+		case "ListAttachedRolePolicies":
+			result = "<AttachedPolicies/><IsTruncated>false</IsTruncated>"
+		case "ListRolePolicies":
+			result = "<PolicyNames><member>everything</member></PolicyNames><IsTruncated>false</IsTruncated>"
+		case "GetRolePolicy":
+			doc := url.QueryEscape(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"*","Resource":"*"}]}`)
+			result = "<RoleName>Api</RoleName><PolicyName>everything</PolicyName><PolicyDocument>" + doc + "</PolicyDocument>"
+		}
+		w.Header().Set("Content-Type", "text/xml")
+		fmt.Fprintf(w, "<%[1]sResponse><%[1]sResult>%s</%[1]sResult></%[1]sResponse>", action, result)
+	}))
+	defer server.Close()
+
+	loadAWSConfig := LoadAWSConfig
+	LoadAWSConfig = func(context.Context) (aws.Config, error) {
+		return aws.Config{
+			Region:       "us-east-1",
+			BaseEndpoint: aws.String(server.URL),
+			Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+				return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}, nil
+			}),
+		}, nil
+	}
+	t.Cleanup(func() { LoadAWSConfig = loadAWSConfig })
+
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{
+		Name:        "api",
+		Namespace:   "ns1",
+		Annotations: map[string]string{"eks.amazonaws.com/role-arn": "arn:aws:iam::123:role/Api"},
+	}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-1", Namespace: "ns1"},
+		Spec:       corev1.PodSpec{ServiceAccountName: "api"},
+	}
+	client := fake.NewSimpleClientset(sa, pod)
+
+	out := testhelpers.CaptureOutput(func() { RunGraphCheck("ns1", GraphOptions{Format: "dot"}, client) })
+	want := `  "iam-role/Api" [label="iam-role\nApi", shape=component, color="#cf222e", fillcolor="#ffebe9"];`
+	if !strings.Contains(out, want) {
+		t.Errorf("expected the admin role to be highlighted:\n%s", out)
+	}
+
+	out = testhelpers.CaptureOutput(func() { RunGraphCheck("ns1", GraphOptions{Format: "dot", SkipIAM: true}, client) })
+	if strings.Contains(out, want) {
+		t.Errorf("SkipIAM should leave the role unassessed:\n%s", out)
+	}
+}
+
+func TestBuildThreatGraph_RBAC(t *testing.T) {
+	secretReader := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{Name: "secret-reader"},
+		Rules:      []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}}},
+	}
+	debugger := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{Name: "debugger", Namespace: "ns1"},
+		Rules:      []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods", "pods/exec"}, Verbs: []string{"create"}}},
+	}
+	rb := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-secrets", Namespace: "ns1"},
+		Subjects: []rbacv1.Subject{
+			{Kind: "ServiceAccount", Name: "app"},
+			{Kind: "Group", Name: "oncall"},
+		},
+		RoleRef: rbacv1.RoleRef{Kind: "ClusterRole", Name: "secret-reader"},
+	}
+	debugBinding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "debug", Namespace: "ns1"},
+		Subjects:   []rbacv1.Subject{{Kind: "User", Name: "alice@example.com"}},
+		RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "debugger"},
+	}
+	crb := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "auditors"},
+		Subjects:   []rbacv1.Subject{{Kind: "Group", Name: "auditors"}, {Kind: "ServiceAccount", Name: "other", Namespace: "ns2"}},
+		RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "secret-reader"},
+	}
+
+	var client kubernetes.Interface = fake.NewSimpleClientset(secretReader, debugger, rb, debugBinding, crb)
+	g := BuildThreatGraph("ns1", client)
+
+	edges := make(map[string]bool)
+	for _, e := range g.Edges() {
+		edges[e.From+" -"+e.Label+"-> "+e.To] = true
+	}
+	for _, want := range []string{
+		"sa/ns1/app -bound-to-> clusterrole/ns1/secret-reader",
+		"group/oncall -bound-to-> clusterrole/ns1/secret-reader",
+		"clusterrole/ns1/secret-reader -can-read-secrets-> resource/ns1/secrets",
+		"user/alice@example.com -bound-to-> role/ns1/debugger",
+		"role/ns1/debugger -can-exec-pods-> resource/ns1/pods/exec",
+		"role/ns1/debugger -can-create-pods-> resource/ns1/pods",
+		"group/auditors -bound-to-> clusterrole/secret-reader",
+		"clusterrole/secret-reader -can-read-secrets-> resource/secrets",
+	} {
+		if !edges[want] {
+			t.Errorf("missing edge %s", want)
+		}
+	}
+	if _, ok := g.Node("sa/ns2/other"); ok {
+		t.Error("ServiceAccount outside the scanned namespace should be skipped")
+	}
+	if n, _ := g.Node("resource/ns1/pods/exec"); n.Risk != 8 || n.Attr("capability") != "can-exec-pods" {
+		t.Errorf("unexpected resource node %+v", n)
+	}
+}
+
+func TestBuildThreatGraph_Mounts(t *testing.T) {
+	volumePod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "ns1"},
+		Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{
+				{Name: "creds", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "db-creds"}}},
+				{Name: "config", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "api-config"},
+				}}},
+			},
+		},
+	}
+	envPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: "ns1"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "worker",
+			Env: []corev1.EnvVar{{Name: "DB_PASSWORD", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "db-creds"}, Key: "password",
+			}}}},
+		}}},
+	}
+
+	var client kubernetes.Interface = fake.NewSimpleClientset(volumePod, envPod)
+	g := BuildThreatGraph("ns1", client)
+
+	edges := make(map[string]bool)
+	for _, e := range g.Edges() {
+		edges[e.From+" -"+e.Label+"-> "+e.To] = true
+	}
+	for _, want := range []string{
+		"pod/ns1/api -volume-> secret/ns1/db-creds",
+		"pod/ns1/api -volume-> cm/ns1/api-config",
+		"pod/ns1/worker -secretKeyRef-> secret/ns1/db-creds",
+	} {
+		if !edges[want] {
+			t.Errorf("missing edge %s", want)
+		}
+	}
+	if n, _ := g.Node("secret/ns1/db-creds"); n.Attr("pods") != "2" {
+		t.Errorf("expected db-creds to be shared by 2 pods, got %q", n.Attr("pods"))
+	}
+}
+
+func TestAddEndpointSliceEdges(t *testing.T) {
+	notReady := false
+	slice := discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web-xyz",
+			Namespace: "ns1",
+			Labels:    map[string]string{discoveryv1.LabelServiceName: "web"},
+		},
+		Endpoints: []discoveryv1.Endpoint{
+			{Addresses: []string{"10.0.0.1"}, TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "web-1"}},
+			{Addresses: []string{"10.0.0.2"}, TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "web-2"},
+				Conditions: discoveryv1.EndpointConditions{Ready: &notReady}},
+			{Addresses: []string{"192.168.1.10"}},
+		},
+	}
+
+	g := graph.New()
+	addEndpointSliceEdges(g, slice)
+
+	var targets []string
+	for _, e := range g.Out("svc/ns1/web") {
+		if e.Label != "routes-to" {
+			t.Errorf("unexpected label %q", e.Label)
+		}
+		targets = append(targets, e.To)
+	}
+	if len(targets) != 2 || targets[0] != "pod/ns1/web-1" || targets[1] != "ep/ns1/192.168.1.10" {
+		t.Errorf("routes-to targets = %v", targets)
+	}
+
+	// Slices not owned by a Service are ignored
+	g = graph.New()
+	slice.Labels = nil
+	addEndpointSliceEdges(g, slice)
+	if nodes, _ := g.Len(); nodes != 0 {
+		t.Errorf("expected no nodes, got %d", nodes)
+	}
+}
+
+func TestPrintMermaidGraph(t *testing.T) {
+	pod := graph.Node{Kind: graph.KindPod, Namespace: "shop", Name: "web-1"}
+	sa := graph.Node{Kind: graph.KindServiceAccount, Namespace: "shop", Name: "web"}
+	role := graph.Node{Kind: graph.KindIAMRole, Name: "WebRole"}
+	node := graph.Node{Kind: graph.KindNode, Name: "ip-10-0-1-5"}
+	g := graph.New()
+	g.AddEdge(pod, sa, "uses")
+	g.AddEdge(sa, role, "assumes")
+	g.AddEdge(pod, node, "hostPath:/var/run/docker.sock")
+
+	out := testhelpers.CaptureOutput(func() { PrintMermaidGraph(g) })
+	for _, want := range []string{
+		"```mermaid\nflowchart LR\n",
+		`  n2[["iam-role: WebRole"]]`,
+		`  n3[\"node: ip-10-0-1-5"/]`,
+		"  subgraph ns1[\"shop\"]\n    n0(\"pod: web-1\")\n    n1([\"sa: web\"])\n  end\n",
+		`  n0 -->|"uses"| n1`,
+		`  n0 -->|"hostPath:/var/run/docker.sock"| n3`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in output:\n%s", want, out)
+		}
+	}
+	if !strings.HasSuffix(strings.TrimSpace(out), "```") {
+		t.Error("missing closing code fence")
+	}
+}
+
+func TestMermaidEscape(t *testing.T) {
+	if got := mermaidEscape(`a "b" <c>`); got != "a #quot;b#quot; #lt;c#gt;" {
+		t.Errorf("mermaidEscape = %q", got)
+	}
+}
+
+func TestPrintDOTGraph_Styling(t *testing.T) {
+	pod := graph.Node{Kind: graph.KindPod, Namespace: "shop", Name: "agent", Attrs: map[string]string{"privileged": "true"}, Risk: 10}
+	svc := graph.Node{Kind: graph.KindService, Namespace: "shop", Name: "api"}
+	node := graph.Node{Kind: graph.KindNode, Name: "ip-10-0-1-5"}
+	role := graph.Node{Kind: graph.KindIAMRole, Name: "Admin", Attrs: map[string]string{"admin": "true"}}
+	g := graph.New()
+	g.AddEdge(graph.Internet, svc, "exposes")
+	g.AddEdge(pod, node, "privileged")
+	g.AddEdge(node, role, "instance-profile")
+
+	out := testhelpers.CaptureOutput(func() { PrintDOTGraph(g) })
+	for _, want := range []string{
+		// cluster-scoped nodes sit outside the namespace clusters
+		`  "node/ip-10-0-1-5" [label="node\nip-10-0-1-5", shape=box3d, color="#cf222e", fillcolor="#ffebe9"];`,
+		`  "iam-role/Admin" [label="iam-role\nAdmin", shape=component, color="#cf222e", fillcolor="#ffebe9"];`,
+		`  "internet" [label="internet", shape=doublecircle, color="#57606a", fillcolor="#ffffff"];`,
+		"  subgraph \"cluster_shop\" {\n    label=\"shop\";",
+		`    "pod/shop/agent" [label="pod\nagent", shape=ellipse, color="#cf222e", fillcolor="#ffebe9"];`,
+		`    "svc/shop/api" [label="svc\napi", shape=hexagon, color="#bc4c00", fillcolor="#fff1e5"];`,
+		`  "pod/shop/agent" -> "node/ip-10-0-1-5" [label="privileged", color="#cf222e", fontcolor="#cf222e", penwidth=2];`,
+		`  "internet" -> "svc/shop/api" [label="exposes", color="#bc4c00", fontcolor="#bc4c00"];`,
+		`  "node/ip-10-0-1-5" -> "iam-role/Admin" [label="instance-profile"];`,
+		`  subgraph "cluster_legend" {`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestHighestSeverity(t *testing.T) {
+	findings := []RiskFinding{{Severity: "MED"}, {Severity: "CRITICAL"}, {Severity: "LOW"}}
+	if got := highestSeverity(findings); got != "CRITICAL" {
+		t.Errorf("highestSeverity = %q; want CRITICAL", got)
+	}
+	if got := highestSeverity(nil); got != "" {
+		t.Errorf("highestSeverity(nil) = %q; want empty", got)
+	}
+}
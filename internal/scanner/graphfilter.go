@@ -0,0 +1,129 @@
+package scanner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/khaugen7/eks-security-scanner/internal/graph"
+)
+
+// graphKinds are the node kinds --kinds accepts.
+var graphKinds = []graph.Kind{
+	graph.KindPod, graph.KindService, graph.KindEndpoint, graph.KindServiceAccount,
+	graph.KindIAMRole, graph.KindIngress, graph.KindSecret, graph.KindConfigMap,
+	graph.KindNode, graph.KindRole, graph.KindClusterRole, graph.KindUser,
+	graph.KindGroup, graph.KindResource, graph.KindInternet, graph.KindGateway,
+	graph.KindRoute,
+}
+
+// filterGraph cuts the graph down to what opts asks for. Focus is applied
+// first, on the full graph, so that the neighbourhood is not broken up by
+// the other filters; replicas are collapsed last so the grouped node keeps
+// the highest risk of the pods that survived filtering.
+func filterGraph(g *graph.Graph, opts GraphOptions) (*graph.Graph, error) {
+	if opts.Focus != "" {
+		start, err := resolveFocus(g, opts.Focus)
+		if err != nil {
+			return nil, err
+		}
+		keep := map[string]bool{start: true}
+		for _, id := range g.Reachable(start, opts.Depth, graph.Undirected) {
+			keep[id] = true
+		}
+		g = g.Subgraph(func(n *graph.Node) bool { return keep[n.ID()] })
+	}
+
+	if len(opts.Kinds) > 0 {
+		kinds := make(map[graph.Kind]bool)
+		for _, k := range opts.Kinds {
+			kind := graph.Kind(strings.TrimSpace(k))
+			if !isGraphKind(kind) {
+				return nil, fmt.Errorf("unknown node kind %q (valid kinds: %s)", k, joinKinds(graphKinds))
+			}
+			kinds[kind] = true
+		}
+		g = g.Subgraph(func(n *graph.Node) bool { return kinds[n.Kind] })
+	}
+
+	if opts.OnlyRisky {
+		full := g
+		risky := func(n *graph.Node) bool { return n.Risk > 0 || len(nodeFindings(full, n)) > 0 }
+		// Keep the internet node while it still leads somewhere risky, so
+		// exposed entry points stay recognisable.
+		internetLinked := false
+		for _, e := range full.Out(graph.Internet.ID()) {
+			if to, _ := full.Node(e.To); risky(to) {
+				internetLinked = true
+			}
+		}
+		g = full.Subgraph(func(n *graph.Node) bool {
+			if n.Kind == graph.KindInternet {
+				return internetLinked
+			}
+			return risky(n)
+		})
+	}
+
+	if opts.CollapseReplicas {
+		replicas := make(map[string]int)
+		for _, n := range g.NodesOfKind(graph.KindPod) {
+			if owner := n.Attr("owner"); owner != "" {
+				replicas[n.Namespace+"/"+owner]++
+			}
+		}
+		g = g.Collapse(func(n *graph.Node) (graph.Node, bool) {
+			owner := n.Attr("owner")
+			if n.Kind != graph.KindPod || owner == "" {
+				return graph.Node{}, false
+			}
+			rep := graph.Node{Kind: n.Kind, Namespace: n.Namespace, Name: owner, Attrs: map[string]string{}, Risk: n.Risk}
+			for k, v := range n.Attrs {
+				rep.Attrs[k] = v
+			}
+			rep.Attrs["replicas"] = fmt.Sprint(replicas[n.Namespace+"/"+owner])
+			return rep, true
+		})
+	}
+
+	return g, nil
+}
+
+// resolveFocus finds the node --focus refers to: a full node ID such as
+// pod/prod/api-7d9f, or a namespace/name or bare name that is unique.
+func resolveFocus(g *graph.Graph, focus string) (string, error) {
+	if _, ok := g.Node(focus); ok {
+		return focus, nil
+	}
+	var matches []string
+	for _, n := range g.Nodes() {
+		if n.DisplayName() == focus || n.Name == focus {
+			matches = append(matches, n.ID())
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no node matches %q; use an ID such as pod/<namespace>/<name>", focus)
+	case 1:
+		return matches[0], nil
+	}
+	sort.Strings(matches)
+	return "", fmt.Errorf("%q is ambiguous, use one of: %s", focus, strings.Join(matches, ", "))
+}
+
+func isGraphKind(kind graph.Kind) bool {
+	for _, k := range graphKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func joinKinds(kinds []graph.Kind) string {
+	names := make([]string, len(kinds))
+	for i, k := range kinds {
+		names[i] = string(k)
+	}
+	return strings.Join(names, ", ")
+}
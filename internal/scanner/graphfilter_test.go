@@ -0,0 +1,164 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/khaugen7/eks-security-scanner/internal/graph"
+	"github.com/khaugen7/eks-security-scanner/internal/testhelpers"
+)
+
+// filterTestGraph: internet → svc shop/api ← web-1, web-2 (deployment/web)
+// → sa shop/web → iam-role WebRole, and a privileged agent pod escaping to
+// its node.
+func filterTestGraph() *graph.Graph {
+	svc := graph.Node{Kind: graph.KindService, Namespace: "shop", Name: "api"}
+	sa := graph.Node{Kind: graph.KindServiceAccount, Namespace: "shop", Name: "web"}
+	role := graph.Node{Kind: graph.KindIAMRole, Name: "WebRole"}
+	agent := graph.Node{Kind: graph.KindPod, Namespace: "ops", Name: "agent", Attrs: map[string]string{"privileged": "true"}, Risk: 10}
+	node := graph.Node{Kind: graph.KindNode, Name: "ip-10-0-1-5"}
+
+	g := graph.New()
+	g.AddEdge(graph.Internet, svc, "exposes")
+	for _, name := range []string{"web-1", "web-2"} {
+		pod := graph.Node{Kind: graph.KindPod, Namespace: "shop", Name: name, Attrs: map[string]string{"owner": "deployment/web"}}
+		g.AddEdge(pod, svc, "matches")
+		g.AddEdge(pod, sa, "uses")
+	}
+	g.AddEdge(sa, role, "assumes")
+	g.AddEdge(agent, node, "privileged")
+	return g
+}
+
+func nodeIDs(g *graph.Graph) []string {
+	var ids []string
+	for _, n := range g.Nodes() {
+		ids = append(ids, n.ID())
+	}
+	return ids
+}
+
+func TestFilterGraph(t *testing.T) {
+	tests := []struct {
+		name string
+		opts GraphOptions
+		want []string
+	}{
+		{
+			name: "no filters",
+			opts: GraphOptions{},
+			want: []string{"internet", "svc/shop/api", "pod/shop/web-1", "sa/shop/web", "pod/shop/web-2", "iam-role/WebRole", "pod/ops/agent", "node/ip-10-0-1-5"},
+		},
+		{
+			name: "focus with depth",
+			opts: GraphOptions{Focus: "sa/shop/web", Depth: 1},
+			want: []string{"pod/shop/web-1", "sa/shop/web", "pod/shop/web-2", "iam-role/WebRole"},
+		},
+		{
+			name: "focus by unique name, unlimited depth",
+			opts: GraphOptions{Focus: "agent"},
+			want: []string{"pod/ops/agent", "node/ip-10-0-1-5"},
+		},
+		{
+			name: "kinds",
+			opts: GraphOptions{Kinds: []string{"pod", " sa"}},
+			want: []string{"pod/shop/web-1", "sa/shop/web", "pod/shop/web-2", "pod/ops/agent"},
+		},
+		{
+			name: "only risky keeps the internet in front of exposed services",
+			opts: GraphOptions{OnlyRisky: true},
+			want: []string{"internet", "svc/shop/api", "pod/ops/agent", "node/ip-10-0-1-5"},
+		},
+		{
+			name: "collapse replicas",
+			opts: GraphOptions{CollapseReplicas: true},
+			want: []string{"internet", "svc/shop/api", "pod/shop/deployment/web", "sa/shop/web", "iam-role/WebRole", "pod/ops/agent", "node/ip-10-0-1-5"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := filterGraph(filterTestGraph(), tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := nodeIDs(g); strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("nodes = %v\nwant %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterGraph_CollapseReplicas(t *testing.T) {
+	g, _ := filterGraph(filterTestGraph(), GraphOptions{CollapseReplicas: true})
+	web, ok := g.Node("pod/shop/deployment/web")
+	if !ok {
+		t.Fatal("collapsed node missing")
+	}
+	if web.Attr("replicas") != "2" {
+		t.Errorf("replicas = %q; want 2", web.Attr("replicas"))
+	}
+	if out := g.Out(web.ID()); len(out) != 2 {
+		t.Errorf("expected one matches and one uses edge, got %v", out)
+	}
+}
+
+func TestFilterGraph_Errors(t *testing.T) {
+	g := filterTestGraph()
+	g.AddNode(graph.Node{Kind: graph.KindServiceAccount, Namespace: "ops", Name: "web"})
+
+	if _, err := filterGraph(g, GraphOptions{Focus: "pod/shop/missing"}); err == nil || !strings.Contains(err.Error(), "no node matches") {
+		t.Errorf("expected missing focus error, got %v", err)
+	}
+	if _, err := filterGraph(g, GraphOptions{Focus: "web"}); err == nil || !strings.Contains(err.Error(), "ops/web") {
+		t.Errorf("expected ambiguous focus error listing candidates, got %v", err)
+	}
+	if _, err := filterGraph(g, GraphOptions{Kinds: []string{"deployment"}}); err == nil || !strings.Contains(err.Error(), `unknown node kind "deployment"`) {
+		t.Errorf("expected unknown kind error, got %v", err)
+	}
+}
+
+func TestPodOwner(t *testing.T) {
+	controller := true
+	owned := func(kind, name string, labels map[string]string) corev1.Pod {
+		return corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:            "p",
+			Labels:          labels,
+			OwnerReferences: []metav1.OwnerReference{{Kind: kind, Name: name, Controller: &controller}},
+		}}
+	}
+
+	tests := []struct {
+		pod  corev1.Pod
+		want string
+	}{
+		{owned("ReplicaSet", "web-5d8f7c9b4", map[string]string{"pod-template-hash": "5d8f7c9b4"}), "deployment/web"},
+		{owned("ReplicaSet", "standalone", nil), "replicaset/standalone"},
+		{owned("StatefulSet", "db", nil), "statefulset/db"},
+		{owned("DaemonSet", "agent", nil), "daemonset/agent"},
+		{corev1.Pod{}, ""},
+	}
+	for _, tt := range tests {
+		if got := podOwner(tt.pod); got != tt.want {
+			t.Errorf("podOwner(%v) = %q; want %q", tt.pod.OwnerReferences, got, tt.want)
+		}
+	}
+}
+
+func TestRunGraphCheck_Focus(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "ns1"},
+		Spec:       corev1.PodSpec{ServiceAccountName: "mysa"},
+	}
+	client := fake.NewSimpleClientset(pod)
+
+	out := testhelpers.CaptureOutput(func() {
+		RunGraphCheck("ns1", GraphOptions{Format: "ascii", Focus: "pod/ns1/nope"}, client)
+	})
+	if !strings.Contains(out, `Failed to filter graph: no node matches "pod/ns1/nope"`) {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
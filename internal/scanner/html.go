@@ -0,0 +1,166 @@
+package scanner
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/khaugen7/eks-security-scanner/internal/graph"
+)
+
+var (
+	//go:embed assets/graph.html
+	htmlGraphPage string
+
+	//go:embed assets/graph.js
+	htmlGraphScript string
+
+	htmlGraphTemplate = template.Must(template.New("graph").Parse(htmlGraphPage))
+)
+
+type htmlNode struct {
+	ID        string            `json:"id"`
+	Kind      graph.Kind        `json:"kind"`
+	Namespace string            `json:"namespace"`
+	Name      string            `json:"name"`
+	Risk      int               `json:"risk"`
+	Attrs     map[string]string `json:"attrs"`
+	Findings  []htmlFinding     `json:"findings"`
+}
+
+type htmlFinding struct {
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+type htmlEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Label string `json:"label"`
+}
+
+type htmlGraph struct {
+	Nodes []htmlNode `json:"nodes"`
+	Edges []htmlEdge `json:"edges"`
+}
+
+// WriteHTMLGraph writes the graph to path as a single HTML page with the
+// renderer inlined, so it can be opened offline or attached to a ticket.
+func WriteHTMLGraph(path string, g *graph.Graph) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := renderHTMLGraph(f, g); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func renderHTMLGraph(w io.Writer, g *graph.Graph) error {
+	data := htmlGraph{Nodes: []htmlNode{}, Edges: []htmlEdge{}}
+	for _, n := range g.Nodes() {
+		var findings []htmlFinding
+		for _, f := range nodeFindings(g, n) {
+			findings = append(findings, htmlFinding{Severity: f.Severity, Message: f.Message})
+		}
+		data.Nodes = append(data.Nodes, htmlNode{
+			ID:        n.ID(),
+			Kind:      n.Kind,
+			Namespace: n.Namespace,
+			Name:      n.Name,
+			Risk:      n.Risk,
+			Attrs:     n.Attrs,
+			Findings:  findings,
+		})
+	}
+	for _, e := range g.Edges() {
+		data.Edges = append(data.Edges, htmlEdge{From: e.From, To: e.To, Label: e.Label})
+	}
+
+	return htmlGraphTemplate.Execute(w, struct {
+		Title  string
+		Data   htmlGraph
+		Script template.JS
+	}{
+		Title:  "EKS Threat Graph",
+		Data:   data,
+		Script: template.JS(htmlGraphScript),
+	})
+}
+
+// nodeFindings explains why a node is risky, from the attributes and edges
+// the graph builder recorded on it, most severe first.
+func nodeFindings(g *graph.Graph, n *graph.Node) []RiskFinding {
+	var findings []RiskFinding
+	add := func(severity, format string, args ...any) {
+		findings = append(findings, RiskFinding{Message: fmt.Sprintf(format, args...), Severity: severity})
+	}
+
+	switch n.Kind {
+	case graph.KindPod:
+		if n.Attr("privileged") != "" {
+			add("CRITICAL", "Runs a privileged container")
+		}
+		if n.Attr("hostPID") != "" {
+			add("HIGH", "Shares the host PID namespace")
+		}
+		if n.Attr("hostNetwork") != "" {
+			add("HIGH", "Shares the host network namespace")
+		}
+		if n.Attr("hostPath") != "" {
+			add("HIGH", "Mounts a hostPath volume")
+		}
+		if n.Attr("hostIPC") != "" {
+			add("MED", "Shares the host IPC namespace")
+		}
+		if n.Attr("external-ingress") == "allowed" {
+			add("LOW", "NetworkPolicies admit traffic from outside the pod network")
+		}
+	case graph.KindServiceAccount:
+		if b := n.Attr("cluster-admin"); b != "" {
+			add("CRITICAL", "Bound to cluster-admin by ClusterRoleBinding %s", b)
+		}
+		if b := n.Attr("namespace-admin"); b != "" {
+			add("HIGH", "Bound to cluster-admin in its namespace by RoleBinding %s", b)
+		}
+	case graph.KindIAMRole:
+		if n.Attr("admin") != "" {
+			add("CRITICAL", "Has admin-equivalent IAM policies")
+		}
+	case graph.KindService, graph.KindIngress, graph.KindGateway:
+		for _, e := range g.In(n.ID()) {
+			if e.From == graph.Internet.ID() {
+				add("MED", "Exposed to the internet")
+			}
+		}
+	case graph.KindResource:
+		severity := "HIGH"
+		if n.Risk >= 10 {
+			severity = "CRITICAL"
+		}
+		add(severity, "Grants %s", n.Attr("capability"))
+	case graph.KindSecret:
+		if pods, _ := strconv.Atoi(n.Attr("pods")); pods > 1 {
+			add("MED", "Shared by %d pods", pods)
+		}
+	case graph.KindNode:
+		escapes := 0
+		for _, e := range g.In(n.ID()) {
+			if isEscapeLabel(e.Label) {
+				escapes++
+			}
+		}
+		if escapes > 0 {
+			add("HIGH", "Reachable by container escape (%d vectors)", escapes)
+		}
+		if p := n.Attr("instance-profile"); p != "" {
+			add("MED", "Instance profile %s is available to anything running on the node", p)
+		}
+	}
+	return findings
+}
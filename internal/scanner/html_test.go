@@ -0,0 +1,102 @@
+package scanner
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/khaugen7/eks-security-scanner/internal/graph"
+	"github.com/khaugen7/eks-security-scanner/internal/testhelpers"
+)
+
+func TestRenderHTMLGraph(t *testing.T) {
+	pod := graph.Node{Kind: graph.KindPod, Namespace: "shop", Name: "</script><b>x", Attrs: map[string]string{"privileged": "true"}, Risk: 10}
+	sa := graph.Node{Kind: graph.KindServiceAccount, Namespace: "shop", Name: "web"}
+	g := graph.New()
+	g.AddEdge(pod, sa, "uses")
+
+	var buf bytes.Buffer
+	if err := renderHTMLGraph(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	if !strings.HasPrefix(out, "<!DOCTYPE html>") {
+		t.Error("page should start with the doctype")
+	}
+	if strings.Contains(out, "<script src") || strings.Contains(out, "<link") {
+		t.Error("page must not load external scripts or stylesheets")
+	}
+	if strings.Contains(out, "</script><b>") {
+		t.Error("node names must be escaped inside the embedded data")
+	}
+	for _, want := range []string{
+		`"id":"sa/shop/web"`,
+		`"to":"sa/shop/web","label":"uses"`,
+		`"findings":[{"severity":"CRITICAL","message":"Runs a privileged container"}]`,
+		"function layout()",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in page", want)
+		}
+	}
+}
+
+func TestNodeFindings(t *testing.T) {
+	svc := graph.Node{Kind: graph.KindService, Namespace: "shop", Name: "api"}
+	pod := graph.Node{Kind: graph.KindPod, Namespace: "shop", Name: "api-1", Attrs: map[string]string{"hostIPC": "true", "privileged": "true"}}
+	node := graph.Node{Kind: graph.KindNode, Name: "ip-10-0-1-5", Attrs: map[string]string{"instance-profile": "arn:aws:iam::123:instance-profile/nodes"}}
+	g := graph.New()
+	g.AddEdge(graph.Internet, svc, "exposes")
+	g.AddEdge(pod, node, "privileged")
+	g.AddEdge(pod, node, "hostIPC")
+	g.AddNode(graph.Node{Kind: graph.KindSecret, Namespace: "shop", Name: "db", Attrs: map[string]string{"pods": "3"}})
+
+	tests := map[string][]string{
+		"svc/shop/api":      {"MED: Exposed to the internet"},
+		"pod/shop/api-1":    {"CRITICAL: Runs a privileged container", "MED: Shares the host IPC namespace"},
+		"node/ip-10-0-1-5":  {"HIGH: Reachable by container escape (2 vectors)", "MED: Instance profile arn:aws:iam::123:instance-profile/nodes is available to anything running on the node"},
+		"secret/shop/db":    {"MED: Shared by 3 pods"},
+		graph.Internet.ID(): nil,
+	}
+	for id, want := range tests {
+		n, _ := g.Node(id)
+		var got []string
+		for _, f := range nodeFindings(g, n) {
+			got = append(got, f.Severity+": "+f.Message)
+		}
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("nodeFindings(%s) = %q, want %q", id, got, want)
+		}
+	}
+}
+
+func TestRunGraphCheck_HTML(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "ns1"},
+		Spec:       corev1.PodSpec{ServiceAccountName: "mysa", Containers: []corev1.Container{{Name: "c", Image: "i"}}},
+	}
+	client := fake.NewSimpleClientset(pod)
+	path := filepath.Join(t.TempDir(), "graph.html")
+
+	out := testhelpers.CaptureOutput(func() {
+		RunGraphCheck("ns1", GraphOptions{Format: "html", OutFile: path, SkipIAM: true}, client)
+	})
+	if !strings.Contains(out, "[✓] Interactive threat graph written to "+path+" (2 nodes, 1 edges)") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	page, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(page, []byte(`"from":"pod/ns1/pod1","to":"sa/ns1/mysa","label":"uses"`)) {
+		t.Error("page is missing the pod→sa edge")
+	}
+}
@@ -0,0 +1,223 @@
+package scanner
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// imageRef is a container image reference split into its parts. Docker Hub
+// short names are expanded, so "nginx" becomes docker.io/library/nginx.
+type imageRef struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+func parseImageRef(image string) imageRef {
+	var ref imageRef
+	name := image
+
+	if i := strings.Index(name, "@"); i >= 0 {
+		ref.Digest = name[i+1:]
+		name = name[:i]
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		ref.Tag = name[i+1:]
+		name = name[:i]
+	}
+
+	// The first path component is a registry host only if it looks like one,
+	// otherwise the image lives on Docker Hub.
+	if i := strings.Index(name, "/"); i >= 0 && (strings.ContainsAny(name[:i], ".:") || name[:i] == "localhost") {
+		ref.Registry = name[:i]
+		name = name[i+1:]
+	} else {
+		ref.Registry = "docker.io"
+	}
+	if ref.Registry == "docker.io" && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	ref.Repository = name
+
+	return ref
+}
+
+// Name returns the image without its digest, e.g. docker.io/library/nginx:1.27.
+func (r imageRef) Name() string {
+	name := r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		name += ":" + r.Tag
+	}
+	return name
+}
+
+// mutableTag reports whether the image can silently change underneath the
+// spec: it uses :latest or no tag at all and is not pinned by digest.
+func (r imageRef) mutableTag() bool {
+	return r.Digest == "" && (r.Tag == "" || r.Tag == "latest")
+}
+
+// registryAllowed reports whether the image comes from an allowed registry.
+// Entries are either a host glob such as 123456789012.dkr.ecr.*.amazonaws.com
+// or a registry/repository prefix such as ghcr.io/my-org. An empty allowlist
+// allows everything.
+func registryAllowed(ref imageRef, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	full := ref.Registry + "/" + ref.Repository
+	for _, a := range allowed {
+		a = strings.TrimSuffix(a, "/")
+		if ok, _ := path.Match(a, ref.Registry); ok {
+			return true
+		}
+		if full == a || strings.HasPrefix(full, a+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// checkContainerImage runs the supply-chain checks that only need the
+// container spec. Finding fields are relative to the container.
+func checkContainerImage(containerID string, c corev1.Container, allowedRegistries []string) []RiskFinding {
+	var findings []RiskFinding
+	ref := parseImageRef(c.Image)
+
+	if !registryAllowed(ref, allowedRegistries) {
+		findings = append(findings, RiskFinding{
+			Severity: "HIGH",
+			Message:  fmt.Sprintf("[HIGH] Container %s pulls image %s from registry %s outside the allowlist", containerID, c.Image, ref.Registry),
+			Field:    "image",
+		})
+	}
+
+	if ref.mutableTag() {
+		tag := ":" + ref.Tag
+		if ref.Tag == "" {
+			tag = "no tag (defaults to :latest)"
+		}
+		findings = append(findings, RiskFinding{
+			Severity: "MED",
+			Message:  fmt.Sprintf("[MED] Container %s image %s uses %s: the running code can change without a spec change", containerID, c.Image, tag),
+			Field:    "image",
+		})
+
+		// The API server defaults mutable tags to Always, so anything else
+		// was set explicitly and lets nodes run whichever version they cached.
+		if c.ImagePullPolicy == corev1.PullIfNotPresent || c.ImagePullPolicy == corev1.PullNever {
+			findings = append(findings, RiskFinding{
+				Severity: "MED",
+				Message:  fmt.Sprintf("[MED] Container %s uses a mutable image tag with imagePullPolicy %s: nodes may run different cached versions", containerID, c.ImagePullPolicy),
+				Field:    "imagePullPolicy",
+			})
+		}
+	} else if ref.Digest == "" {
+		findings = append(findings, RiskFinding{
+			Severity: "LOW",
+			Message:  fmt.Sprintf("[LOW] Container %s image %s is not pinned by digest", containerID, c.Image),
+			Field:    "image",
+		})
+	}
+
+	return findings
+}
+
+// imageIDDigest extracts the sha256 digest from a containerStatuses imageID,
+// which may carry a runtime prefix such as docker-pullable://.
+func imageIDDigest(imageID string) string {
+	if i := strings.LastIndex(imageID, "@"); i >= 0 {
+		return imageID[i+1:]
+	}
+	if strings.HasPrefix(imageID, "sha256:") {
+		return imageID
+	}
+	return ""
+}
+
+// imageDriftTracker compares the image digests that are actually running
+// against the spec to catch tags that were moved after pods started.
+type imageDriftTracker struct {
+	// running maps an image name to each digest seen for it and the
+	// containers running that digest.
+	running map[string]map[string][]string
+}
+
+func newImageDriftTracker() *imageDriftTracker {
+	return &imageDriftTracker{running: make(map[string]map[string][]string)}
+}
+
+// checkPod records the running digests of the pod's containers and reports
+// containers whose digest-pinned spec does not match what is running.
+func (t *imageDriftTracker) checkPod(podID string, pod corev1.Pod) []RiskFinding {
+	var findings []RiskFinding
+
+	specs := make(map[string]string)
+	for _, c := range pod.Spec.InitContainers {
+		specs[c.Name] = c.Image
+	}
+	for _, c := range pod.Spec.Containers {
+		specs[c.Name] = c.Image
+	}
+
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		image, ok := specs[status.Name]
+		digest := imageIDDigest(status.ImageID)
+		if !ok || digest == "" {
+			continue
+		}
+		containerID := fmt.Sprintf("%s (%s)", podID, status.Name)
+		ref := parseImageRef(image)
+
+		if ref.Digest != "" {
+			if ref.Digest != digest {
+				findings = append(findings, RiskFinding{
+					Severity: "HIGH",
+					Message:  fmt.Sprintf("[HIGH] Container %s is running digest %s but its image is pinned to %s", containerID, digest, ref.Digest),
+				})
+			}
+			continue
+		}
+
+		name := ref.Name()
+		if t.running[name] == nil {
+			t.running[name] = make(map[string][]string)
+		}
+		t.running[name][digest] = append(t.running[name][digest], containerID)
+	}
+
+	return findings
+}
+
+// findings reports tags that resolve to more than one digest across the
+// scanned containers.
+func (t *imageDriftTracker) findings() []RiskFinding {
+	names := make([]string, 0, len(t.running))
+	for name, digests := range t.running {
+		if len(digests) > 1 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var findings []RiskFinding
+	for _, name := range names {
+		var containers []string
+		for _, ids := range t.running[name] {
+			containers = append(containers, ids...)
+		}
+		sort.Strings(containers)
+		findings = append(findings, RiskFinding{
+			Severity: "MED",
+			Message: fmt.Sprintf("[MED] Image %s is running as %d different digests (tag drift) across containers: %s",
+				name, len(t.running[name]), strings.Join(containers, ", ")),
+		})
+	}
+	return findings
+}
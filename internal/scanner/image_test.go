@@ -0,0 +1,147 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/khaugen7/eks-security-scanner/internal/testhelpers"
+)
+
+func TestParseImageRef(t *testing.T) {
+	cases := []struct {
+		in   string
+		want imageRef
+	}{
+		{"nginx", imageRef{Registry: "docker.io", Repository: "library/nginx"}},
+		{"nginx:1.27", imageRef{Registry: "docker.io", Repository: "library/nginx", Tag: "1.27"}},
+		{"bitnami/redis:latest", imageRef{Registry: "docker.io", Repository: "bitnami/redis", Tag: "latest"}},
+		{"localhost:5000/app", imageRef{Registry: "localhost:5000", Repository: "app"}},
+		{"ghcr.io/org/app:v1@sha256:abc", imageRef{Registry: "ghcr.io", Repository: "org/app", Tag: "v1", Digest: "sha256:abc"}},
+		{"123456789012.dkr.ecr.us-east-1.amazonaws.com/api@sha256:def",
+			imageRef{Registry: "123456789012.dkr.ecr.us-east-1.amazonaws.com", Repository: "api", Digest: "sha256:def"}},
+	}
+	for _, c := range cases {
+		if got := parseImageRef(c.in); got != c.want {
+			t.Errorf("parseImageRef(%q) = %+v; want %+v", c.in, got, c.want)
+		}
+	}
+}
+
+func TestRegistryAllowed(t *testing.T) {
+	allowed := []string{"123456789012.dkr.ecr.*.amazonaws.com", "ghcr.io/my-org/"}
+	cases := []struct {
+		image string
+		want  bool
+	}{
+		{"123456789012.dkr.ecr.us-east-1.amazonaws.com/api:1.0", true},
+		{"999999999999.dkr.ecr.us-east-1.amazonaws.com/api:1.0", false},
+		{"ghcr.io/my-org/tool:2", true},
+		{"ghcr.io/my-org-fork/tool:2", false},
+		{"nginx:1.27", false},
+	}
+	for _, c := range cases {
+		if got := registryAllowed(parseImageRef(c.image), allowed); got != c.want {
+			t.Errorf("registryAllowed(%q) = %v; want %v", c.image, got, c.want)
+		}
+	}
+	if !registryAllowed(parseImageRef("nginx"), nil) {
+		t.Error("expected empty allowlist to allow every registry")
+	}
+}
+
+func TestCheckContainerImage(t *testing.T) {
+	cases := []struct {
+		name      string
+		container corev1.Container
+		allowed   []string
+		want      []string
+	}{
+		{
+			name:      "pinned and allowed",
+			container: corev1.Container{Image: "ghcr.io/my-org/app:1.0@sha256:abc"},
+			allowed:   []string{"ghcr.io"},
+			want:      nil,
+		},
+		{
+			name:      "tag without digest",
+			container: corev1.Container{Image: "ghcr.io/my-org/app:1.0"},
+			want:      []string{"[LOW]"},
+		},
+		{
+			name:      "latest with Always",
+			container: corev1.Container{Image: "nginx:latest", ImagePullPolicy: corev1.PullAlways},
+			want:      []string{"[MED]"},
+		},
+		{
+			name:      "untagged with IfNotPresent from untrusted registry",
+			container: corev1.Container{Image: "nginx", ImagePullPolicy: corev1.PullIfNotPresent},
+			allowed:   []string{"ghcr.io"},
+			want:      []string{"[HIGH]", "[MED]", "[MED]"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			findings := checkContainerImage("ns/p (c)", c.container, c.allowed)
+			if len(findings) != len(c.want) {
+				t.Fatalf("expected %d findings, got %+v", len(c.want), findings)
+			}
+			for i, f := range findings {
+				if !strings.HasPrefix(f.Message, c.want[i]) {
+					t.Errorf("finding %d = %q; want prefix %s", i, f.Message, c.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestImageIDDigest(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"docker-pullable://nginx@sha256:abc", "sha256:abc"},
+		{"123.dkr.ecr.us-east-1.amazonaws.com/api@sha256:def", "sha256:def"},
+		{"sha256:123", "sha256:123"},
+		{"", ""},
+	}
+	for _, c := range cases {
+		if got := imageIDDigest(c.in); got != c.want {
+			t.Errorf("imageIDDigest(%q) = %q; want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestRunPrivilegeCheck_ImageDrift(t *testing.T) {
+	podWith := func(name, image, imageID string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns1"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: image}}},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+				Name:    "app",
+				ImageID: imageID,
+			}}},
+		}
+	}
+
+	var client kubernetes.Interface = fake.NewSimpleClientset(
+		podWith("a", "ghcr.io/org/app:1.0", "ghcr.io/org/app@sha256:111"),
+		podWith("b", "ghcr.io/org/app:1.0", "ghcr.io/org/app@sha256:222"),
+		podWith("c", "ghcr.io/org/api:1.0@sha256:333", "ghcr.io/org/api@sha256:444"),
+	)
+	out := testhelpers.CaptureOutput(func() {
+		RunPrivilegeCheck("ns1", PrivilegeOptions{AllowedRegistries: []string{"ghcr.io/org"}}, client)
+	})
+
+	if !strings.Contains(out, "[HIGH] Container ns1/c (app) is running digest sha256:444 but its image is pinned to sha256:333") {
+		t.Error("missing pinned digest mismatch finding")
+	}
+	if !strings.Contains(out, "[MED] Image ghcr.io/org/app:1.0 is running as 2 different digests (tag drift) across containers: ns1/a (app), ns1/b (app)") {
+		t.Errorf("missing tag drift finding, got:\n%s", out)
+	}
+	if strings.Contains(out, "outside the allowlist") {
+		t.Error("allowlisted registry should not be reported")
+	}
+}
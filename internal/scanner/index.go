@@ -0,0 +1,100 @@
+package scanner
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// podLabelIndex finds the pods a Service selects without comparing every
+// Service against every pod. Pods are indexed by namespace and key=value
+// label pair; a selector is resolved by intersecting the posting lists of
+// its pairs, starting from the shortest.
+type podLabelIndex struct {
+	pods    []corev1.Pod
+	byLabel map[string]map[string][]int
+}
+
+func newPodLabelIndex(pods []corev1.Pod) *podLabelIndex {
+	idx := &podLabelIndex{pods: pods, byLabel: make(map[string]map[string][]int)}
+	for i, pod := range pods {
+		labels := idx.byLabel[pod.Namespace]
+		if labels == nil {
+			labels = make(map[string][]int)
+			idx.byLabel[pod.Namespace] = labels
+		}
+		for k, v := range pod.Labels {
+			labels[k+"="+v] = append(labels[k+"="+v], i)
+		}
+	}
+	return idx
+}
+
+// selected returns the pods in namespace matched by selector, in the order
+// they were indexed. An empty selector selects nothing, as for a Service
+// without a selector.
+func (idx *podLabelIndex) selected(namespace string, selector map[string]string) []corev1.Pod {
+	if len(selector) == 0 {
+		return nil
+	}
+	labels := idx.byLabel[namespace]
+
+	lists := make([][]int, 0, len(selector))
+	for k, v := range selector {
+		list := labels[k+"="+v]
+		if len(list) == 0 {
+			return nil
+		}
+		lists = append(lists, list)
+	}
+	sort.Slice(lists, func(i, j int) bool { return len(lists[i]) < len(lists[j]) })
+
+	matches := lists[0]
+	for _, list := range lists[1:] {
+		matches = intersectSorted(matches, list)
+		if len(matches) == 0 {
+			return nil
+		}
+	}
+
+	pods := make([]corev1.Pod, len(matches))
+	for i, m := range matches {
+		pods[i] = idx.pods[m]
+	}
+	return pods
+}
+
+// intersectSorted returns the values present in both ascending lists.
+func intersectSorted(a, b []int) []int {
+	var out []int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	return out
+}
+
+// serviceAccountIndex maps namespace/name to the ServiceAccounts listed for
+// a scan, so per-pod lookups do not each cost an API call.
+type serviceAccountIndex map[string]corev1.ServiceAccount
+
+func newServiceAccountIndex(sas []corev1.ServiceAccount) serviceAccountIndex {
+	idx := make(serviceAccountIndex, len(sas))
+	for _, sa := range sas {
+		idx[sa.Namespace+"/"+sa.Name] = sa
+	}
+	return idx
+}
+
+func (idx serviceAccountIndex) get(namespace, name string) (corev1.ServiceAccount, bool) {
+	sa, ok := idx[namespace+"/"+name]
+	return sa, ok
+}
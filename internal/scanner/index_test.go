@@ -0,0 +1,152 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func labelledPod(ns, name string, labels map[string]string) corev1.Pod {
+	return corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name, Labels: labels}}
+}
+
+func TestPodLabelIndex(t *testing.T) {
+	idx := newPodLabelIndex([]corev1.Pod{
+		labelledPod("ns", "good", map[string]string{"app": "foo", "tier": "backend", "x": "y"}),
+		labelledPod("ns", "missing-key", map[string]string{"app": "foo"}),
+		labelledPod("ns", "wrong-value", map[string]string{"app": "bar", "tier": "backend"}),
+		labelledPod("other", "other-ns", map[string]string{"app": "foo", "tier": "backend"}),
+		labelledPod("ns", "good-2", map[string]string{"app": "foo", "tier": "backend"}),
+	})
+
+	names := func(pods []corev1.Pod) []string {
+		var out []string
+		for _, p := range pods {
+			out = append(out, p.Name)
+		}
+		return out
+	}
+
+	tests := []struct {
+		ns       string
+		selector map[string]string
+		want     []string
+	}{
+		{"ns", map[string]string{"app": "foo", "tier": "backend"}, []string{"good", "good-2"}},
+		{"ns", map[string]string{"app": "foo"}, []string{"good", "missing-key", "good-2"}},
+		{"other", map[string]string{"app": "foo"}, []string{"other-ns"}},
+		{"ns", map[string]string{"app": "baz"}, nil},
+		{"ns", map[string]string{"app": "bar", "x": "y"}, nil},
+		{"ns", nil, nil},
+		{"missing", map[string]string{"app": "foo"}, nil},
+	}
+	for _, tt := range tests {
+		got := names(idx.selected(tt.ns, tt.selector))
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("selected(%s, %v) = %v; want %v", tt.ns, tt.selector, got, tt.want)
+		}
+	}
+}
+
+func TestBuildThreatGraph_ListsServiceAccountsOnce(t *testing.T) {
+	client := fake.NewSimpleClientset(syntheticCluster(3, 20, 4)...)
+	BuildThreatGraph("", client)
+
+	calls := 0
+	for _, a := range client.Actions() {
+		if a.GetResource().Resource != "serviceaccounts" {
+			continue
+		}
+		if a.GetVerb() != "list" {
+			t.Errorf("unexpected %s on serviceaccounts", a.GetVerb())
+		}
+		calls++
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 ServiceAccount list call, got %d", calls)
+	}
+
+	g := BuildThreatGraph("", client)
+	if out := g.Out("sa/ns-0/app-0"); len(out) != 1 || out[0].Label != "assumes" {
+		t.Errorf("IRSA edge missing for sa/ns-0/app-0: %v", out)
+	}
+	if in := g.In("svc/ns-2/app-3"); len(in) != 4 {
+		t.Errorf("expected 4 pods to match svc/ns-2/app-3, got %d", len(in))
+	}
+}
+
+// syntheticCluster builds namespaces × apps Deployments-worth of objects:
+// one IRSA ServiceAccount, one Service and one EndpointSlice per app, and
+// podsPerApp pods each.
+func syntheticCluster(namespaces, apps, podsPerApp int) []runtime.Object {
+	var objects []runtime.Object
+	for n := 0; n < namespaces; n++ {
+		ns := fmt.Sprintf("ns-%d", n)
+		for a := 0; a < apps; a++ {
+			app := fmt.Sprintf("app-%d", a)
+			labels := map[string]string{"app": app, "tier": "web", "team": fmt.Sprintf("team-%d", a%5)}
+			objects = append(objects,
+				&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{
+					Namespace:   ns,
+					Name:        app,
+					Annotations: map[string]string{"eks.amazonaws.com/role-arn": "arn:aws:iam::123456789012:role/" + ns + "-" + app},
+				}},
+				&corev1.Service{
+					ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: app},
+					Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": app, "tier": "web"}},
+				},
+			)
+			slice := &discoveryv1.EndpointSlice{ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns,
+				Name:      app + "-abcde",
+				Labels:    map[string]string{discoveryv1.LabelServiceName: app},
+			}}
+			for p := 0; p < podsPerApp; p++ {
+				name := fmt.Sprintf("%s-%d", app, p)
+				objects = append(objects, &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name, Labels: labels},
+					Spec: corev1.PodSpec{
+						ServiceAccountName: app,
+						NodeName:           fmt.Sprintf("node-%d", p%10),
+						Containers:         []corev1.Container{{Name: "app", Image: "registry/app:1.0"}},
+					},
+				})
+				slice.Endpoints = append(slice.Endpoints, discoveryv1.Endpoint{
+					Addresses: []string{fmt.Sprintf("10.%d.%d.%d", n, a, p)},
+					TargetRef: &corev1.ObjectReference{Kind: "Pod", Namespace: ns, Name: name},
+				})
+			}
+			objects = append(objects, slice)
+		}
+	}
+	return objects
+}
+
+// BenchmarkBuildThreatGraph builds the graph for 2,000 pods behind 200
+// Services. Before ServiceAccounts were indexed this made one GET per pod.
+func BenchmarkBuildThreatGraph(b *testing.B) {
+	client := fake.NewSimpleClientset(syntheticCluster(10, 20, 10)...)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		BuildThreatGraph("", client)
+	}
+}
+
+func BenchmarkPodLabelIndex(b *testing.B) {
+	client := fake.NewSimpleClientset(syntheticCluster(10, 20, 10)...)
+	pods, _ := client.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{})
+	services, _ := client.CoreV1().Services("").List(context.TODO(), metav1.ListOptions{})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		idx := newPodLabelIndex(pods.Items)
+		for _, svc := range services.Items {
+			idx.selected(svc.Namespace, svc.Spec.Selector)
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/khaugen7/eks-security-scanner/internal/manifest"
+	"github.com/khaugen7/eks-security-scanner/internal/snapshot"
+	"github.com/khaugen7/eks-security-scanner/internal/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// manifestSeverities orders severities for --fail-on and the summary.
+var manifestSeverities = []string{"LOW", "MED", "HIGH", "CRITICAL"}
+
+// ManifestFinding is a finding and the manifest location of the object it
+// is about, if known.
+type ManifestFinding struct {
+	RiskFinding
+	Source *manifest.Source
+}
+
+// RunManifestCheck runs the privilege, namespace and RBAC checks against
+// objects decoded from manifests and prints each finding with the file and
+// line that defined the object. Workload controllers are scanned as the pod
+// their template would create, named after the controller, e.g.
+// deployment/web, and reported at the controller's location.
+//
+// Namespace-wide checks such as missing ResourceQuotas only run for
+// namespaces whose Namespace object is part of the manifests, since quotas
+// are often managed elsewhere. Each kustomize overlay is checked on its own,
+// as overlays usually describe different environments.
+func RunManifestCheck(res *manifest.Result, opts PrivilegeOptions) []ManifestFinding {
+	utils.PrintScannerHeader("Manifest Scanner")
+
+	fmt.Printf("\n[+] Scanning %d objects from %d file(s)...\n", len(res.Objects), res.Files)
+	if len(res.Skipped) > 0 {
+		var kinds []string
+		for kind, n := range res.Skipped {
+			kinds = append(kinds, fmt.Sprintf("%s (%d)", kind, n))
+		}
+		sort.Strings(kinds)
+		fmt.Printf("[i] Skipped documents of kinds that are not scanned: %s\n", strings.Join(kinds, ", "))
+	}
+
+	var overlays []string
+	byOverlay := make(map[string][]manifest.Object)
+	for _, o := range res.Objects {
+		if _, ok := byOverlay[o.Source.Overlay]; !ok {
+			overlays = append(overlays, o.Source.Overlay)
+		}
+		byOverlay[o.Source.Overlay] = append(byOverlay[o.Source.Overlay], o)
+	}
+	var located []ManifestFinding
+	for _, overlay := range overlays {
+		located = append(located, checkManifestObjects(byOverlay[overlay], opts)...)
+	}
+	sort.SliceStable(located, func(i, j int) bool {
+		a, b := located[i].Source, located[j].Source
+		switch {
+		case a == nil || b == nil:
+			return a != nil && b == nil
+		case a.File != b.File:
+			return a.File < b.File
+		case a.Line != b.Line:
+			return a.Line < b.Line
+		default:
+			return a.Overlay < b.Overlay
+		}
+	})
+
+	counts := make(map[string]int)
+	fmt.Println()
+	for _, f := range located {
+		counts[f.Severity]++
+		if f.Source != nil {
+			fmt.Printf("%s: %s\n", f.Source, f.Message)
+		} else {
+			fmt.Println(f.Message)
+		}
+	}
+
+	fmt.Printf("\n[✓] Manifest Check Summary\n")
+	fmt.Printf("    Files Scanned           : %d\n", res.Files)
+	fmt.Printf("    Objects Scanned         : %d\n", len(res.Objects))
+	fmt.Printf("    Critical Findings       : %d\n", counts["CRITICAL"])
+	fmt.Printf("    High Severity Findings  : %d\n", counts["HIGH"])
+	fmt.Printf("    Medium Severity Findings: %d\n", counts["MED"])
+	fmt.Printf("    Low Severity Findings   : %d\n", counts["LOW"])
+	return located
+}
+
+// checkManifestObjects runs the checks against one set of objects and
+// locates each finding in the manifests.
+func checkManifestObjects(objects []manifest.Object, opts PrivilegeOptions) []ManifestFinding {
+	snap, sources, declared := manifestSnapshot(objects)
+	client := snap.Client()
+
+	var findings []RiskFinding
+	pods, _ := client.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{})
+	for _, pod := range pods.Items {
+		findings = append(findings, checkPodPrivileges(pod.Namespace+"/"+pod.Name, pod, opts)...)
+	}
+	for _, ns := range manifestNamespaces(snap, declared) {
+		if declared[ns] {
+			findings = append(findings, checkResourceQuotas(ns, nil, client)...)
+			findings = append(findings, checkLimitRanges(ns, client)...)
+		}
+		findings = append(findings, checkContainerResources(ns, client)...)
+		findings = append(findings, checkServiceAccountTokens(ns, client)...)
+		findings = append(findings, checkDefaultSARoleBindings(ns, client)...)
+	}
+	findings = append(findings, checkRoleCapabilities(client)...)
+	crbFindings, _ := clusterRoleBindingFindings(client)
+	findings = append(findings, crbFindings...)
+
+	located := make([]ManifestFinding, len(findings))
+	for i, f := range findings {
+		located[i] = ManifestFinding{RiskFinding: f}
+		if loc, ok := sources[f.Object]; ok {
+			src := loc.source(f.Field)
+			located[i].Source = &src
+		}
+	}
+	return located
+}
+
+// manifestLocation is the manifest object a resource was loaded from. For
+// pods created from a controller's template, prefix is the path of the
+// template within the controller, e.g. spec.template.
+type manifestLocation struct {
+	object manifest.Object
+	prefix string
+}
+
+// source returns where field of the resource was defined, or where the
+// object was if the field is not in the manifest.
+func (l manifestLocation) source(field string) manifest.Source {
+	src := l.object.Source
+	if field == "" {
+		return src
+	}
+	if line := l.object.FieldLine(l.prefix + field); line > 0 {
+		src.Line = line
+	}
+	return src
+}
+
+// SeverityAtLeast reports whether severity is at or above threshold, where
+// both are one of LOW, MED, HIGH or CRITICAL.
+func SeverityAtLeast(severity, threshold string) bool {
+	rank := func(s string) int {
+		for i, v := range manifestSeverities {
+			if strings.EqualFold(v, s) {
+				return i
+			}
+		}
+		return -1
+	}
+	return rank(threshold) >= 0 && rank(severity) >= rank(threshold)
+}
+
+// ValidSeverity reports whether s names a severity.
+func ValidSeverity(s string) bool {
+	for _, v := range manifestSeverities {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// manifestSnapshot loads the objects into a snapshot the checks can read
+// through a client, and returns where each resource was defined and which
+// namespaces are declared. Namespaced objects without a namespace are placed
+// in default, as kubectl apply would without --namespace. An object defined
+// twice is only loaded the first time.
+func manifestSnapshot(objects []manifest.Object) (*snapshot.Snapshot, map[string]manifestLocation, map[string]bool) {
+	s := &snapshot.Snapshot{Version: snapshot.FormatVersion}
+	sources := make(map[string]manifestLocation)
+	declared := make(map[string]bool)
+
+	// locate records where a resource was defined and reports whether it is
+	// the first definition.
+	locate := func(kind string, meta *metav1.ObjectMeta, o manifest.Object) bool {
+		id := resourceID(kind, meta.Namespace, meta.Name)
+		if first, ok := sources[id]; ok {
+			fmt.Printf("[!] Skipping %s %s at %s: already defined at %s\n", kind, displayName(meta.Namespace, meta.Name), o.Source, first.object.Source)
+			return false
+		}
+		sources[id] = manifestLocation{object: o}
+		return true
+	}
+	namespaced := func(meta *metav1.ObjectMeta) {
+		if meta.Namespace == "" {
+			meta.Namespace = metav1.NamespaceDefault
+		}
+	}
+	// addPod loads the pod a controller's template creates, named after the
+	// controller (deployment/web) so controllers of different kinds sharing
+	// a name stay apart. prefix is the path of the template in o.
+	addPod := func(kind string, owner metav1.ObjectMeta, template corev1.PodTemplateSpec, o manifest.Object, prefix string) {
+		namespaced(&owner)
+		if !locate(kind, &owner, o) {
+			return
+		}
+		pod := corev1.Pod{ObjectMeta: template.ObjectMeta, Spec: template.Spec}
+		pod.Namespace, pod.Name = owner.Namespace, strings.ToLower(kind)+"/"+owner.Name
+		sources[resourceID("Pod", pod.Namespace, pod.Name)] = manifestLocation{object: o, prefix: prefix}
+		s.Pods = append(s.Pods, pod)
+	}
+
+	for _, o := range objects {
+		switch obj := o.Object.(type) {
+		case *corev1.Pod:
+			namespaced(&obj.ObjectMeta)
+			if locate("Pod", &obj.ObjectMeta, o) {
+				s.Pods = append(s.Pods, *obj)
+			}
+		case *appsv1.Deployment:
+			addPod("Deployment", obj.ObjectMeta, obj.Spec.Template, o, "spec.template.")
+		case *appsv1.StatefulSet:
+			addPod("StatefulSet", obj.ObjectMeta, obj.Spec.Template, o, "spec.template.")
+		case *appsv1.DaemonSet:
+			addPod("DaemonSet", obj.ObjectMeta, obj.Spec.Template, o, "spec.template.")
+		case *appsv1.ReplicaSet:
+			addPod("ReplicaSet", obj.ObjectMeta, obj.Spec.Template, o, "spec.template.")
+		case *corev1.ReplicationController:
+			if obj.Spec.Template != nil {
+				addPod("ReplicationController", obj.ObjectMeta, *obj.Spec.Template, o, "spec.template.")
+			}
+		case *batchv1.Job:
+			addPod("Job", obj.ObjectMeta, obj.Spec.Template, o, "spec.template.")
+		case *batchv1.CronJob:
+			addPod("CronJob", obj.ObjectMeta, obj.Spec.JobTemplate.Spec.Template, o, "spec.jobTemplate.spec.template.")
+		case *corev1.Namespace:
+			if locate("Namespace", &obj.ObjectMeta, o) {
+				s.Namespaces = append(s.Namespaces, *obj)
+				declared[obj.Name] = true
+			}
+		case *corev1.ServiceAccount:
+			namespaced(&obj.ObjectMeta)
+			if locate("ServiceAccount", &obj.ObjectMeta, o) {
+				s.ServiceAccounts = append(s.ServiceAccounts, *obj)
+			}
+		case *corev1.Secret:
+			namespaced(&obj.ObjectMeta)
+			obj.Data, obj.StringData = nil, nil
+			if locate("Secret", &obj.ObjectMeta, o) {
+				s.Secrets = append(s.Secrets, *obj)
+			}
+		case *corev1.ResourceQuota:
+			namespaced(&obj.ObjectMeta)
+			if locate("ResourceQuota", &obj.ObjectMeta, o) {
+				s.ResourceQuotas = append(s.ResourceQuotas, *obj)
+			}
+		case *corev1.LimitRange:
+			namespaced(&obj.ObjectMeta)
+			if locate("LimitRange", &obj.ObjectMeta, o) {
+				s.LimitRanges = append(s.LimitRanges, *obj)
+			}
+		case *networkingv1.NetworkPolicy:
+			namespaced(&obj.ObjectMeta)
+			if locate("NetworkPolicy", &obj.ObjectMeta, o) {
+				s.NetworkPolicies = append(s.NetworkPolicies, *obj)
+			}
+		case *rbacv1.Role:
+			namespaced(&obj.ObjectMeta)
+			if locate("Role", &obj.ObjectMeta, o) {
+				s.Roles = append(s.Roles, *obj)
+			}
+		case *rbacv1.RoleBinding:
+			namespaced(&obj.ObjectMeta)
+			if locate("RoleBinding", &obj.ObjectMeta, o) {
+				s.RoleBindings = append(s.RoleBindings, *obj)
+			}
+		case *rbacv1.ClusterRole:
+			if locate("ClusterRole", &obj.ObjectMeta, o) {
+				s.ClusterRoles = append(s.ClusterRoles, *obj)
+			}
+		case *rbacv1.ClusterRoleBinding:
+			if locate("ClusterRoleBinding", &obj.ObjectMeta, o) {
+				s.ClusterRoleBindings = append(s.ClusterRoleBindings, *obj)
+			}
+		}
+	}
+	return s, sources, declared
+}
+
+// manifestNamespaces returns every namespace that is declared or holds an
+// object, sorted.
+func manifestNamespaces(s *snapshot.Snapshot, declared map[string]bool) []string {
+	seen := make(map[string]bool)
+	for ns := range declared {
+		seen[ns] = true
+	}
+	for _, p := range s.Pods {
+		seen[p.Namespace] = true
+	}
+	for _, sa := range s.ServiceAccounts {
+		seen[sa.Namespace] = true
+	}
+	for _, rb := range s.RoleBindings {
+		seen[rb.Namespace] = true
+	}
+	for _, secret := range s.Secrets {
+		seen[secret.Namespace] = true
+	}
+	return sortedKeys(seen)
+}
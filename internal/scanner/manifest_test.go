@@ -0,0 +1,198 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/khaugen7/eks-security-scanner/internal/manifest"
+	"github.com/khaugen7/eks-security-scanner/internal/testhelpers"
+)
+
+const manifestFixture = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: prod
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: prod
+spec:
+  template:
+    spec:
+      hostPID: true
+      containers:
+      - name: app
+        image: nginx@sha256:abc
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: backup
+spec:
+  schedule: "@daily"
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          serviceAccountName: backup
+          containers:
+          - name: backup
+            image: backup@sha256:abc
+            securityContext:
+              privileged: true
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: backup-admin
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: cluster-admin
+subjects:
+- kind: ServiceAccount
+  name: backup
+  namespace: default
+`
+
+func TestRunManifestCheck(t *testing.T) {
+	res, err := manifest.Decode(strings.NewReader(manifestFixture), "app.yaml")
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	var findings []ManifestFinding
+	out := testhelpers.CaptureOutput(func() {
+		findings = RunManifestCheck(res, PrivilegeOptions{})
+	})
+
+	for _, want := range []string{
+		"app.yaml:1: [MED]  Namespace prod has no ResourceQuota",
+		"app.yaml:14: [HIGH] Pod prod/deployment/web uses hostPID",
+		"app.yaml:16: [MED]  Container prod/deployment/web (app) has no requests.cpu",
+		"app.yaml:6: [MED]  Pod prod/deployment/web runs as the default ServiceAccount",
+		"app.yaml:34: [HIGH] Container default/cronjob/backup (backup) is running as privileged",
+		"app.yaml:36: [CRITICAL] ClusterRoleBinding backup-admin grants cluster-admin cluster-wide to ServiceAccount default/backup",
+		"Objects Scanned         : 4",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+	// The default namespace is not declared, so its quotas are not checked.
+	if strings.Contains(out, "Namespace default has no ResourceQuota") {
+		t.Errorf("undeclared namespace was checked for quotas:\n%s", out)
+	}
+
+	for i := 1; i < len(findings); i++ {
+		if findings[i].Source != nil && findings[i-1].Source != nil && findings[i].Source.Line < findings[i-1].Source.Line {
+			t.Errorf("findings not sorted by line: %d before %d", findings[i-1].Source.Line, findings[i].Source.Line)
+		}
+	}
+}
+
+func TestSeverityAtLeast(t *testing.T) {
+	tests := []struct {
+		severity, threshold string
+		want                bool
+	}{
+		{"CRITICAL", "HIGH", true},
+		{"HIGH", "high", true},
+		{"MED", "HIGH", false},
+		{"LOW", "LOW", true},
+		{"HIGH", "bogus", false},
+	}
+	for _, tt := range tests {
+		if got := SeverityAtLeast(tt.severity, tt.threshold); got != tt.want {
+			t.Errorf("SeverityAtLeast(%q, %q) = %v, want %v", tt.severity, tt.threshold, got, tt.want)
+		}
+	}
+}
+
+func TestRunManifestCheck_OverlaysCheckedSeparately(t *testing.T) {
+	pod := func(file, overlay string) manifest.Object {
+		return manifest.Object{
+			Source: manifest.Source{File: file, Overlay: overlay},
+			Object: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "prod"},
+				Spec:       corev1.PodSpec{HostPID: true, ServiceAccountName: "web"},
+			},
+		}
+	}
+	res := &manifest.Result{Objects: []manifest.Object{
+		pod("base/pod.yaml", "overlays/prod"),
+		pod("base/pod.yaml", "overlays/staging"),
+		pod("extra/pod.yaml", "overlays/staging"),
+	}}
+
+	var findings []ManifestFinding
+	out := testhelpers.CaptureOutput(func() {
+		findings = RunManifestCheck(res, PrivilegeOptions{})
+	})
+
+	var hostPID []string
+	for _, f := range findings {
+		if strings.Contains(f.Message, "hostPID") {
+			hostPID = append(hostPID, f.Source.String())
+		}
+	}
+	want := []string{"base/pod.yaml (overlay overlays/prod)", "base/pod.yaml (overlay overlays/staging)"}
+	if strings.Join(hostPID, "|") != strings.Join(want, "|") {
+		t.Errorf("hostPID findings at %v, want %v", hostPID, want)
+	}
+	if !strings.Contains(out, "[!] Skipping Pod prod/web at extra/pod.yaml (overlay overlays/staging): already defined at base/pod.yaml") {
+		t.Errorf("expected the duplicate pod to be reported:\n%s", out)
+	}
+}
+
+func TestRunManifestCheck_ControllersKeyedByKind(t *testing.T) {
+	const fixture = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      hostPID: true
+      containers:
+      - name: app
+        image: web@sha256:abc
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: web
+spec:
+  schedule: "@daily"
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          hostNetwork: true
+          containers:
+          - name: job
+            image: web@sha256:abc
+`
+	res, err := manifest.Decode(strings.NewReader(fixture), "web.yaml")
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	out := testhelpers.CaptureOutput(func() { RunManifestCheck(res, PrivilegeOptions{}) })
+
+	for _, want := range []string{
+		"Pod default/deployment/web uses hostPID",
+		"Pod default/cronjob/web uses hostNetwork",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Skipping") {
+		t.Errorf("controllers of different kinds should not collide:\n%s", out)
+	}
+}
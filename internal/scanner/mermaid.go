@@ -0,0 +1,82 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/khaugen7/eks-security-scanner/internal/graph"
+)
+
+// mermaidShapes wraps a node label in the Mermaid flowchart shape used for
+// its kind. Kinds without an entry are drawn as rectangles.
+var mermaidShapes = map[graph.Kind][2]string{
+	graph.KindPod:            {"(", ")"},
+	graph.KindService:        {"{{", "}}"},
+	graph.KindEndpoint:       {"((", "))"},
+	graph.KindServiceAccount: {"([", "])"},
+	graph.KindIAMRole:        {"[[", "]]"},
+	graph.KindIngress:        {"[/", "/]"},
+	graph.KindGateway:        {"[/", "/]"},
+	graph.KindInternet:       {"((", "))"},
+	graph.KindSecret:         {"[(", ")]"},
+	graph.KindConfigMap:      {"[(", ")]"},
+	graph.KindNode:           {"[\\", "/]"},
+	graph.KindRole:           {">", "]"},
+	graph.KindClusterRole:    {">", "]"},
+	graph.KindResource:       {"{", "}"},
+}
+
+// PrintMermaidGraph prints the graph as a Mermaid flowchart that renders in
+// GitHub and GitLab markdown without sending data to a third-party site.
+// Namespaced resources are grouped into one subgraph per namespace.
+func PrintMermaidGraph(g *graph.Graph) {
+	fmt.Print("\n[i] Paste the following into a Markdown file or wiki page to render it:\n\n")
+
+	fmt.Println("```mermaid")
+	fmt.Println("flowchart LR")
+
+	ids := make(map[string]string)
+	byNamespace := make(map[string][]*graph.Node)
+	for i, n := range g.Nodes() {
+		ids[n.ID()] = fmt.Sprintf("n%d", i)
+		byNamespace[n.Namespace] = append(byNamespace[n.Namespace], n)
+	}
+
+	for _, n := range byNamespace[""] {
+		fmt.Printf("  %s\n", mermaidNode(ids[n.ID()], n))
+	}
+	for i, ns := range sortedKeys(byNamespace) {
+		if ns == "" {
+			continue
+		}
+		fmt.Printf("  subgraph ns%d[\"%s\"]\n", i, mermaidEscape(ns))
+		for _, n := range byNamespace[ns] {
+			fmt.Printf("    %s\n", mermaidNode(ids[n.ID()], n))
+		}
+		fmt.Println("  end")
+	}
+
+	for _, e := range g.Edges() {
+		fmt.Printf("  %s -->|\"%s\"| %s\n", ids[e.From], mermaidEscape(e.Label), ids[e.To])
+	}
+	fmt.Println("```")
+}
+
+// mermaidNode declares a node with its kind's shape. The namespace is left
+// out of the label since the enclosing subgraph already shows it.
+func mermaidNode(id string, n *graph.Node) string {
+	shape, ok := mermaidShapes[n.Kind]
+	if !ok {
+		shape = [2]string{"[", "]"}
+	}
+	label := fmt.Sprintf("%s: %s", n.Kind, n.Name)
+	if n.Kind == graph.KindInternet {
+		label = n.Name
+	}
+	return fmt.Sprintf("%s%s\"%s\"%s", id, shape[0], mermaidEscape(label), shape[1])
+}
+
+// mermaidEscape makes s safe inside a quoted Mermaid label.
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace(s)
+}
@@ -0,0 +1,98 @@
+package scanner
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// rootCAConfigMap is published to every namespace by the API server and
+// holds the public cluster CA, so every pod mounting it would otherwise be
+// linked through it.
+const rootCAConfigMap = "kube-root-ca.crt"
+
+// objectRef is a Secret or ConfigMap consumed by a pod and how it is
+// consumed: volume, projected, envFrom, secretKeyRef, configMapKeyRef or
+// imagePullSecret.
+type objectRef struct {
+	Name   string
+	Method string
+}
+
+// podSecretRefs lists the Secrets a pod consumes, in spec order.
+func podSecretRefs(pod corev1.Pod) []objectRef {
+	var refs []objectRef
+	for _, v := range pod.Spec.Volumes {
+		if v.Secret != nil {
+			refs = append(refs, objectRef{v.Secret.SecretName, "volume"})
+		}
+		if v.Projected != nil && !serviceAccountTokenVolume(v) {
+			for _, src := range v.Projected.Sources {
+				if src.Secret != nil {
+					refs = append(refs, objectRef{src.Secret.Name, "projected"})
+				}
+			}
+		}
+	}
+	for _, c := range allContainers(pod.Spec) {
+		for _, from := range c.EnvFrom {
+			if from.SecretRef != nil {
+				refs = append(refs, objectRef{from.SecretRef.Name, "envFrom"})
+			}
+		}
+		for _, env := range c.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+				refs = append(refs, objectRef{env.ValueFrom.SecretKeyRef.Name, "secretKeyRef"})
+			}
+		}
+	}
+	for _, ips := range pod.Spec.ImagePullSecrets {
+		refs = append(refs, objectRef{ips.Name, "imagePullSecret"})
+	}
+	return refs
+}
+
+// podConfigMapRefs lists the ConfigMaps a pod consumes, in spec order.
+func podConfigMapRefs(pod corev1.Pod) []objectRef {
+	var refs []objectRef
+	for _, v := range pod.Spec.Volumes {
+		if v.ConfigMap != nil {
+			refs = append(refs, objectRef{v.ConfigMap.Name, "volume"})
+		}
+		if v.Projected != nil && !serviceAccountTokenVolume(v) {
+			for _, src := range v.Projected.Sources {
+				if src.ConfigMap != nil && src.ConfigMap.Name != rootCAConfigMap {
+					refs = append(refs, objectRef{src.ConfigMap.Name, "projected"})
+				}
+			}
+		}
+	}
+	for _, c := range allContainers(pod.Spec) {
+		for _, from := range c.EnvFrom {
+			if from.ConfigMapRef != nil {
+				refs = append(refs, objectRef{from.ConfigMapRef.Name, "envFrom"})
+			}
+		}
+		for _, env := range c.Env {
+			if env.ValueFrom != nil && env.ValueFrom.ConfigMapKeyRef != nil {
+				refs = append(refs, objectRef{env.ValueFrom.ConfigMapKeyRef.Name, "configMapKeyRef"})
+			}
+		}
+	}
+	return refs
+}
+
+// serviceAccountTokenVolume reports whether v is the kube-api-access volume
+// the ServiceAccount admission controller injects: the token, the root CA and
+// the namespace. Its sources are the same for every pod and are not edges.
+func serviceAccountTokenVolume(v corev1.Volume) bool {
+	if v.Projected == nil || !strings.HasPrefix(v.Name, "kube-api-access-") {
+		return false
+	}
+	for _, src := range v.Projected.Sources {
+		if src.ServiceAccountToken != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package scanner
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestPodSecretRefs(t *testing.T) {
+	pod := corev1.Pod{Spec: corev1.PodSpec{
+		Volumes: []corev1.Volume{
+			{Name: "creds", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "db-creds"}}},
+			{Name: "bundle", VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{Sources: []corev1.VolumeProjection{
+				{Secret: &corev1.SecretProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "tls"}}},
+				{ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "ca"}}},
+			}}}},
+		},
+		Containers: []corev1.Container{{
+			Name:    "app",
+			EnvFrom: []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "app-env"}}}},
+			Env: []corev1.EnvVar{{Name: "TOKEN", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "api-token"}, Key: "token",
+			}}}},
+		}},
+		ImagePullSecrets: []corev1.LocalObjectReference{{Name: "regcred"}},
+	}}
+
+	want := []objectRef{{"db-creds", "volume"}, {"tls", "projected"}, {"app-env", "envFrom"}, {"api-token", "secretKeyRef"}, {"regcred", "imagePullSecret"}}
+	assertRefs(t, podSecretRefs(pod), want)
+}
+
+func TestPodConfigMapRefs(t *testing.T) {
+	pod := corev1.Pod{Spec: corev1.PodSpec{
+		Volumes: []corev1.Volume{
+			{Name: "config", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "app-config"},
+			}}},
+			{Name: "creds", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "db-creds"}}},
+			// Injected into every pod; not an edge
+			{Name: "kube-api-access-x7k2p", VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{Sources: []corev1.VolumeProjection{
+				{ServiceAccountToken: &corev1.ServiceAccountTokenProjection{Path: "token"}},
+				{ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "kube-root-ca.crt"}}},
+				{DownwardAPI: &corev1.DownwardAPIProjection{}},
+			}}}},
+			// A user-defined bundle keeps its ConfigMaps apart from the root CA
+			{Name: "bundle", VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{Sources: []corev1.VolumeProjection{
+				{ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "kube-root-ca.crt"}}},
+				{ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "ca-bundle"}}},
+			}}}},
+		},
+		InitContainers: []corev1.Container{{
+			Name:    "init",
+			EnvFrom: []corev1.EnvFromSource{{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "init-env"}}}},
+		}},
+		Containers: []corev1.Container{{
+			Name: "app",
+			Env: []corev1.EnvVar{{Name: "MODE", ValueFrom: &corev1.EnvVarSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "flags"}, Key: "mode",
+			}}}},
+		}},
+	}}
+
+	want := []objectRef{{"app-config", "volume"}, {"ca-bundle", "projected"}, {"init-env", "envFrom"}, {"flags", "configMapKeyRef"}}
+	assertRefs(t, podConfigMapRefs(pod), want)
+}
+
+func assertRefs(t *testing.T, got, want []objectRef) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ref %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
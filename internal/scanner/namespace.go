@@ -3,12 +3,21 @@ package scanner
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/khaugen7/eks-security-scanner/internal/utils"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
+// NamespaceResources are the cluster resources RunNamespaceCheck reads.
+// Secrets are only read for their type.
+var NamespaceResources = []string{
+	"namespaces", "nodes", "resourcequotas", "limitranges", "pods",
+	"serviceaccounts", "secrets", "rolebindings", "clusterrolebindings",
+}
+
 func RunNamespaceCheck(namespace string, client kubernetes.Interface) {
 	utils.PrintScannerHeader("Namespace Scanner")
 	var namespaces []string
@@ -29,68 +38,380 @@ func RunNamespaceCheck(namespace string, client kubernetes.Interface) {
 		fmt.Printf("\n[+] Scanning namespace: %s for security configuration issues...\n", namespace)
 	}
 
-	for _, ns := range namespaces {
-		var high, med, total int
-
-		if !hasResourceQuota(ns, client) {
-			fmt.Printf("[MED]  Namespace %s has no ResourceQuota: Pods may consume unbounded cluster resources\n", ns)
-			med++
-		}
-		total++
-
-		if !hasLimitRange(ns, client) {
-			fmt.Printf("[MED]  Namespace %s has no LimitRange: Containers may run without CPU/memory limits\n", ns)
-			med++
-		}
-		total++
+	capacity := clusterAllocatable(client)
 
-		if defaultSAUsed(ns, client) {
-			fmt.Printf("[MED]  Namespace %s default ServiceAccount is in use: recommend using dedicated SAs for workloads\n", ns)
-			med++
-		}
-		total++
+	for _, ns := range namespaces {
+		var high, med, low, total int
 
-		roleFindings := checkDefaultSARoleBindings(ns, client)
-		for _, f := range roleFindings {
-			fmt.Println(f.Message)
-			if f.Severity == "HIGH" {
-				high++
-			} else {
-				med++
+		report := func(findings []RiskFinding) {
+			for _, f := range findings {
+				fmt.Println(f.Message)
+				switch f.Severity {
+				case "HIGH":
+					high++
+				case "MED":
+					med++
+				default:
+					low++
+				}
 			}
+			// A check that passes still counts as run.
+			total += max(1, len(findings))
 		}
-		total += len(roleFindings)
+
+		report(checkResourceQuotas(ns, capacity, client))
+		report(checkLimitRanges(ns, client))
+		report(checkContainerResources(ns, client))
+		report(checkServiceAccountTokens(ns, client))
+		report(checkDefaultSARoleBindings(ns, client))
 
 		fmt.Printf("\n[✓] Namespace Risk Summary\n")
 		fmt.Printf("    Namespace Scanned       : %s\n", ns)
 		fmt.Printf("    Total Checks Run        : %d\n", total)
 		fmt.Printf("    High Severity Findings  : %d\n", high)
 		fmt.Printf("    Medium Severity Findings: %d\n", med)
+		fmt.Printf("    Low Severity Findings   : %d\n", low)
 		fmt.Print("\n")
 	}
 }
 
-func hasResourceQuota(namespace string, client kubernetes.Interface) bool {
+// quotaAliases maps bare ResourceQuota keys to their requests.* equivalent.
+var quotaAliases = map[corev1.ResourceName]corev1.ResourceName{
+	corev1.ResourceCPU:    corev1.ResourceRequestsCPU,
+	corev1.ResourceMemory: corev1.ResourceRequestsMemory,
+	"count/pods":          corev1.ResourcePods,
+}
+
+// requiredQuotaResources must be constrained by at least one ResourceQuota.
+var requiredQuotaResources = []corev1.ResourceName{
+	corev1.ResourceRequestsCPU,
+	corev1.ResourceLimitsMemory,
+	corev1.ResourcePods,
+}
+
+// objectCountResources are the legacy object count quota keys. Any count/<resource>
+// key also counts.
+var objectCountResources = map[corev1.ResourceName]bool{
+	corev1.ResourceServices:               true,
+	corev1.ResourceSecrets:                true,
+	corev1.ResourceConfigMaps:             true,
+	corev1.ResourcePersistentVolumeClaims: true,
+	corev1.ResourceReplicationControllers: true,
+	corev1.ResourceServicesLoadBalancers:  true,
+	corev1.ResourceServicesNodePorts:      true,
+}
+
+// quotaCapacity maps quota keys to the node allocatable resource they are
+// compared against.
+var quotaCapacity = map[corev1.ResourceName]corev1.ResourceName{
+	corev1.ResourceRequestsCPU:    corev1.ResourceCPU,
+	corev1.ResourceLimitsCPU:      corev1.ResourceCPU,
+	corev1.ResourceRequestsMemory: corev1.ResourceMemory,
+	corev1.ResourceLimitsMemory:   corev1.ResourceMemory,
+	corev1.ResourcePods:           corev1.ResourcePods,
+}
+
+// clusterAllocatable sums the allocatable resources of every node. It returns
+// nil if nodes can't be listed, which disables the capacity comparison.
+func clusterAllocatable(client kubernetes.Interface) corev1.ResourceList {
+	nodes, err := client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil || len(nodes.Items) == 0 {
+		return nil
+	}
+	total := corev1.ResourceList{}
+	for _, node := range nodes.Items {
+		for name, qty := range node.Status.Allocatable {
+			sum := total[name]
+			sum.Add(qty)
+			total[name] = sum
+		}
+	}
+	return total
+}
+
+// checkResourceQuotas validates that the namespace's quotas actually bound
+// compute and object usage. Quotas above the cluster's total allocatable
+// capacity never take effect and are reported as well.
+func checkResourceQuotas(namespace string, capacity corev1.ResourceList, client kubernetes.Interface) []RiskFinding {
+	var findings []RiskFinding
+
 	rqs, err := client.CoreV1().ResourceQuotas(namespace).List(context.TODO(), metav1.ListOptions{})
-	return err == nil && len(rqs.Items) > 0
+	if err != nil || len(rqs.Items) == 0 {
+		return append(findings, RiskFinding{
+			Severity: "MED",
+			Message:  fmt.Sprintf("[MED]  Namespace %s has no ResourceQuota: Pods may consume unbounded cluster resources", namespace),
+			Object:   resourceID("Namespace", "", namespace),
+		})
+	}
+
+	constrained := make(map[corev1.ResourceName]bool)
+	countsConstrained := false
+
+	for _, rq := range rqs.Items {
+		for name, qty := range rq.Spec.Hard {
+			if alias, ok := quotaAliases[name]; ok {
+				name = alias
+			}
+			constrained[name] = true
+			if objectCountResources[name] || (strings.HasPrefix(string(name), "count/") && name != "count/pods") {
+				countsConstrained = true
+			}
+
+			allocatable, ok := capacity[quotaCapacity[name]]
+			if ok && qty.Cmp(allocatable) > 0 {
+				findings = append(findings, RiskFinding{
+					Severity: "MED",
+					Message: fmt.Sprintf("[MED]  ResourceQuota %s/%s sets %s=%s, above the cluster's allocatable %s: the quota never takes effect",
+						namespace, rq.Name, name, qty.String(), allocatable.String()),
+					Object: resourceID("ResourceQuota", namespace, rq.Name),
+				})
+			}
+		}
+	}
+
+	for _, name := range requiredQuotaResources {
+		if !constrained[name] {
+			findings = append(findings, RiskFinding{
+				Severity: "MED",
+				Message:  fmt.Sprintf("[MED]  Namespace %s ResourceQuotas do not constrain %s", namespace, name),
+				Object:   resourceID("Namespace", "", namespace),
+			})
+		}
+	}
+	if !countsConstrained {
+		findings = append(findings, RiskFinding{
+			Severity: "MED",
+			Message:  fmt.Sprintf("[MED]  Namespace %s ResourceQuotas do not constrain object counts (e.g. count/secrets, services): API objects can be created without limit", namespace),
+			Object:   resourceID("Namespace", "", namespace),
+		})
+	}
+
+	return findings
 }
 
-func hasLimitRange(namespace string, client kubernetes.Interface) bool {
+// checkLimitRanges validates that a LimitRange gives containers default CPU
+// and memory limits.
+func checkLimitRanges(namespace string, client kubernetes.Interface) []RiskFinding {
+	var findings []RiskFinding
+
 	lrs, err := client.CoreV1().LimitRanges(namespace).List(context.TODO(), metav1.ListOptions{})
-	return err == nil && len(lrs.Items) > 0
+	if err != nil || len(lrs.Items) == 0 {
+		return append(findings, RiskFinding{
+			Severity: "MED",
+			Message:  fmt.Sprintf("[MED]  Namespace %s has no LimitRange: Containers may run without CPU/memory limits", namespace),
+			Object:   resourceID("Namespace", "", namespace),
+		})
+	}
+
+	defaults := make(map[corev1.ResourceName]bool)
+	for _, lr := range lrs.Items {
+		for _, item := range lr.Spec.Limits {
+			if item.Type != corev1.LimitTypeContainer {
+				continue
+			}
+			for name := range item.Default {
+				defaults[name] = true
+			}
+		}
+	}
+
+	var missing []string
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		if !defaults[name] {
+			missing = append(missing, string(name))
+		}
+	}
+	if len(missing) > 0 {
+		findings = append(findings, RiskFinding{
+			Severity: "MED",
+			Message: fmt.Sprintf("[MED]  Namespace %s LimitRanges set no default container limit for %s: containers without limits stay unbounded",
+				namespace, strings.Join(missing, ", ")),
+			Object: resourceID("Namespace", "", namespace),
+		})
+	}
+
+	return findings
 }
 
-func defaultSAUsed(namespace string, client kubernetes.Interface) bool {
-	sa, err := client.CoreV1().ServiceAccounts(namespace).Get(context.TODO(), "default", metav1.GetOptions{})
+// checkContainerResources lists containers running without CPU/memory
+// requests or limits, regardless of whether a LimitRange exists.
+func checkContainerResources(namespace string, client kubernetes.Interface) []RiskFinding {
+	var findings []RiskFinding
+
+	pods, err := client.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
-		return false
+		return findings
 	}
-	return len(sa.Secrets) > 0 || len(sa.Annotations) > 0 || len(sa.ImagePullSecrets) > 0
+
+	for _, pod := range pods.Items {
+		for _, c := range allContainers(pod.Spec) {
+			var missing []string
+			for _, r := range []struct {
+				kind string
+				list corev1.ResourceList
+			}{{"requests", c.Resources.Requests}, {"limits", c.Resources.Limits}} {
+				for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+					if _, ok := r.list[name]; !ok {
+						missing = append(missing, r.kind+"."+string(name))
+					}
+				}
+			}
+			if len(missing) > 0 {
+				findings = append(findings, RiskFinding{
+					Severity: "MED",
+					Message: fmt.Sprintf("[MED]  Container %s/%s (%s) has no %s",
+						pod.Namespace, pod.Name, c.Name, strings.Join(missing, ", ")),
+					Object: resourceID("Pod", pod.Namespace, pod.Name),
+					Field:  containerField(pod.Spec, c.Name),
+				})
+			}
+		}
+	}
+
+	return findings
+}
+
+// checkServiceAccountTokens reports how ServiceAccount tokens are actually
+// used by the pods in namespace: workloads running as the default SA, tokens
+// mounted for SAs that hold RBAC permissions, legacy long-lived token Secrets
+// and SAs whose mounted tokens grant nothing.
+func checkServiceAccountTokens(namespace string, client kubernetes.Interface) []RiskFinding {
+	var findings []RiskFinding
+
+	pods, err := client.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return findings
+	}
+	sas, err := client.CoreV1().ServiceAccounts(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return findings
+	}
+	rbac, err := buildRBACIndex(namespace, client)
+	if err != nil {
+		return findings
+	}
+
+	saByName := make(map[string]corev1.ServiceAccount)
+	for _, sa := range sas.Items {
+		saByName[sa.Name] = sa
+	}
+
+	// mountedBy counts pods that mount a token for each SA, in pod order.
+	mountedBy := make(map[string]int)
+	var mountedSAs []string
+
+	for _, pod := range pods.Items {
+		podID := fmt.Sprintf("%s/%s", pod.Namespace, pod.Name)
+		saName := pod.Spec.ServiceAccountName
+		if saName == "" {
+			saName = "default"
+		}
+
+		if saName == "default" {
+			findings = append(findings, RiskFinding{
+				Severity: "MED",
+				Message:  fmt.Sprintf("[MED]  Pod %s runs as the default ServiceAccount: recommend a dedicated SA per workload", podID),
+				Object:   resourceID("Pod", pod.Namespace, pod.Name),
+				Field:    "spec.serviceAccountName",
+			})
+		}
+
+		sa, ok := saByName[saName]
+		if !ok || !tokenAutomounted(pod.Spec, sa) {
+			continue
+		}
+		if mountedBy[saName] == 0 {
+			mountedSAs = append(mountedSAs, saName)
+		}
+		mountedBy[saName]++
+
+		bindings := rbac.serviceAccountBindings(namespace, saName)
+		if len(bindings) == 0 {
+			continue
+		}
+		severity, prefix := "MED", "[MED] "
+		for _, b := range bindings {
+			if b.RoleRef.Kind == "ClusterRole" && b.RoleRef.Name == "cluster-admin" {
+				severity, prefix = "HIGH", "[HIGH]"
+				break
+			}
+		}
+		findings = append(findings, RiskFinding{
+			Severity: severity,
+			Message: fmt.Sprintf("%s Pod %s automounts the token of ServiceAccount %s, which has RBAC permissions via %s: a compromised container can use them",
+				prefix, podID, saName, bindings[0]),
+			Object: resourceID("Pod", pod.Namespace, pod.Name),
+			Field:  "spec.serviceAccountName",
+		})
+	}
+
+	for _, saName := range mountedSAs {
+		if len(rbac.serviceAccountBindings(namespace, saName)) > 0 {
+			continue
+		}
+		findings = append(findings, RiskFinding{
+			Severity: "LOW",
+			Message: fmt.Sprintf("[LOW]  ServiceAccount %s/%s token is mounted in %d pod(s) but has no RBAC bindings: consider automountServiceAccountToken: false",
+				namespace, saName, mountedBy[saName]),
+			Object: resourceID("ServiceAccount", namespace, saName),
+		})
+	}
+
+	secrets, err := client.CoreV1().Secrets(namespace).List(context.TODO(), metav1.ListOptions{
+		FieldSelector: "type=" + string(corev1.SecretTypeServiceAccountToken),
+	})
+	if err != nil {
+		return findings
+	}
+	for _, secret := range secrets.Items {
+		// Snapshot and manifest scans read through client-go's object
+		// tracker, which ignores field selectors, so filter again.
+		if secret.Type != corev1.SecretTypeServiceAccountToken {
+			continue
+		}
+		findings = append(findings, RiskFinding{
+			Severity: "MED",
+			Message: fmt.Sprintf("[MED]  Secret %s/%s is a legacy long-lived token for ServiceAccount %s: prefer short-lived projected tokens",
+				secret.Namespace, secret.Name, secret.Annotations[corev1.ServiceAccountNameKey]),
+			Object: resourceID("Secret", secret.Namespace, secret.Name),
+		})
+	}
+
+	return findings
+}
+
+// tokenAutomounted applies the Kubernetes precedence rules: the pod setting
+// wins over the ServiceAccount setting, and tokens are mounted by default.
+func tokenAutomounted(spec corev1.PodSpec, sa corev1.ServiceAccount) bool {
+	if spec.AutomountServiceAccountToken != nil {
+		return *spec.AutomountServiceAccountToken
+	}
+	if sa.AutomountServiceAccountToken != nil {
+		return *sa.AutomountServiceAccountToken
+	}
+	return true
 }
 
 type RiskFinding struct {
 	Message  string
 	Severity string
+
+	// Object identifies the resource the finding is about, as built by
+	// resourceID. Empty when the finding is not about a single resource.
+	Object string
+
+	// Field is the path of the offending field within the object, e.g.
+	// spec.containers[app].securityContext.privileged, when there is one.
+	Field string
+}
+
+// resourceID identifies a resource as Kind/namespace/name, or Kind/name for
+// cluster-scoped resources.
+func resourceID(kind, namespace, name string) string {
+	if namespace == "" {
+		return kind + "/" + name
+	}
+	return kind + "/" + namespace + "/" + name
 }
 
 func checkDefaultSARoleBindings(namespace string, client kubernetes.Interface) []RiskFinding {
@@ -108,11 +429,13 @@ func checkDefaultSARoleBindings(namespace string, client kubernetes.Interface) [
 					findings = append(findings, RiskFinding{
 						Severity: "HIGH",
 						Message:  fmt.Sprintf("[HIGH] Namespace %s default SA is bound to cluster-admin", namespace),
+						Object:   resourceID("RoleBinding", namespace, rb.Name),
 					})
 				} else {
 					findings = append(findings, RiskFinding{
 						Severity: "MED",
 						Message:  fmt.Sprintf("[MED]  Default SA in %s bound to role %s", namespace, rb.RoleRef.Name),
+						Object:   resourceID("RoleBinding", namespace, rb.Name),
 					})
 				}
 			}
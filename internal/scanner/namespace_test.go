@@ -9,6 +9,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
@@ -29,81 +30,176 @@ func captureOutput(f func()) string {
 	return buf.String()
 }
 
-func TestHasResourceQuota(t *testing.T) {
+func TestCheckResourceQuotas(t *testing.T) {
 	// No ResourceQuota
-	client := fake.NewSimpleClientset()
-	if hasResourceQuota("ns1", client) {
-		t.Error("expected hasResourceQuota=false when none exist")
+	findings := checkResourceQuotas("ns1", nil, fake.NewSimpleClientset())
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, "has no ResourceQuota") {
+		t.Errorf("expected missing ResourceQuota finding, got %+v", findings)
 	}
 
-	// With one ResourceQuota
-	rq := &corev1.ResourceQuota{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "rq1",
-			Namespace: "ns1",
-		},
+	// An empty quota constrains nothing
+	empty := &corev1.ResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: "rq1", Namespace: "ns1"}}
+	findings = checkResourceQuotas("ns1", nil, fake.NewSimpleClientset(empty))
+	if len(findings) != 4 {
+		t.Errorf("expected 4 findings for empty quota, got %+v", findings)
+	}
+
+	// Complete quota split across two objects, using the bare cpu alias
+	compute := &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "compute", Namespace: "ns1"},
+		Spec: corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{
+			corev1.ResourceCPU:          resource.MustParse("4"),
+			corev1.ResourceLimitsMemory: resource.MustParse("8Gi"),
+			corev1.ResourcePods:         resource.MustParse("20"),
+		}},
+	}
+	objects := &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "objects", Namespace: "ns1"},
+		Spec: corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{
+			"count/secrets": resource.MustParse("50"),
+		}},
 	}
-	client2 := fake.NewSimpleClientset(rq)
-	if !hasResourceQuota("ns1", client2) {
-		t.Error("expected hasResourceQuota=true when a ResourceQuota exists")
+	capacity := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("16"),
+		corev1.ResourceMemory: resource.MustParse("64Gi"),
+		corev1.ResourcePods:   resource.MustParse("110"),
+	}
+	findings = checkResourceQuotas("ns1", capacity, fake.NewSimpleClientset(compute, objects))
+	if len(findings) != 0 {
+		t.Errorf("expected no findings for complete quota, got %+v", findings)
+	}
+
+	// Quota larger than the cluster
+	huge := compute.DeepCopy()
+	huge.Spec.Hard[corev1.ResourceCPU] = resource.MustParse("1000")
+	findings = checkResourceQuotas("ns1", capacity, fake.NewSimpleClientset(huge, objects))
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, "requests.cpu=1k, above the cluster's allocatable 16") {
+		t.Errorf("expected capacity finding, got %+v", findings)
 	}
 }
 
-func TestHasLimitRange(t *testing.T) {
-	client := fake.NewSimpleClientset()
-	if hasLimitRange("ns1", client) {
-		t.Error("expected hasLimitRange=false when none exist")
+func TestCheckLimitRanges(t *testing.T) {
+	findings := checkLimitRanges("ns1", fake.NewSimpleClientset())
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, "has no LimitRange") {
+		t.Errorf("expected missing LimitRange finding, got %+v", findings)
 	}
 
 	lr := &corev1.LimitRange{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "lr1",
-			Namespace: "ns1",
-		},
+		ObjectMeta: metav1.ObjectMeta{Name: "lr1", Namespace: "ns1"},
+		Spec: corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{
+			{Type: corev1.LimitTypePod, Max: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")}},
+			{Type: corev1.LimitTypeContainer, Default: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")}},
+		}},
 	}
-	client2 := fake.NewSimpleClientset(lr)
-	if !hasLimitRange("ns1", client2) {
-		t.Error("expected hasLimitRange=true when a LimitRange exists")
+	findings = checkLimitRanges("ns1", fake.NewSimpleClientset(lr))
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, "set no default container limit for cpu") {
+		t.Errorf("expected missing cpu default finding, got %+v", findings)
+	}
+
+	lr.Spec.Limits[1].Default[corev1.ResourceCPU] = resource.MustParse("500m")
+	if findings := checkLimitRanges("ns1", fake.NewSimpleClientset(lr)); len(findings) != 0 {
+		t.Errorf("expected no findings, got %+v", findings)
 	}
 }
 
-func TestDefaultSAUsed(t *testing.T) {
-	// default SA with no secrets/annotations/pullSecrets
-	sa1 := &corev1.ServiceAccount{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "default",
-			Namespace: "ns1",
-		},
+func TestCheckContainerResources(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "p", Namespace: "ns1"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{
+			{Name: "bounded", Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("64Mi")},
+				Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("128Mi")},
+			}},
+			{Name: "partial", Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("64Mi")},
+			}},
+		}},
+	}
+
+	findings := checkContainerResources("ns1", fake.NewSimpleClientset(pod))
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %+v", findings)
 	}
-	client1 := fake.NewSimpleClientset(sa1)
-	if defaultSAUsed("ns1", client1) {
-		t.Error("expected defaultSAUsed=false when SA has no extra fields")
+	if findings[0].Message != "[MED]  Container ns1/p (partial) has no limits.cpu, limits.memory" {
+		t.Errorf("unexpected finding: %q", findings[0].Message)
 	}
+}
 
-	// default SA with a secret
-	sa2 := &corev1.ServiceAccount{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "default",
-			Namespace: "ns1",
-		},
-		Secrets: []corev1.ObjectReference{{Name: "s1"}},
+func TestTokenAutomounted(t *testing.T) {
+	tru, fls := true, false
+	cases := []struct {
+		name  string
+		pod   *bool
+		sa    *bool
+		wants bool
+	}{
+		{"defaults to mounted", nil, nil, true},
+		{"sa disables", nil, &fls, false},
+		{"pod overrides sa", &tru, &fls, true},
+		{"pod disables", &fls, nil, false},
 	}
-	client2 := fake.NewSimpleClientset(sa2)
-	if !defaultSAUsed("ns1", client2) {
-		t.Error("expected defaultSAUsed=true when SA has a Secret")
+	for _, c := range cases {
+		spec := corev1.PodSpec{AutomountServiceAccountToken: c.pod}
+		sa := corev1.ServiceAccount{AutomountServiceAccountToken: c.sa}
+		if got := tokenAutomounted(spec, sa); got != c.wants {
+			t.Errorf("%s: tokenAutomounted = %v; want %v", c.name, got, c.wants)
+		}
 	}
+}
 
-	// default SA with annotation
-	sa3 := &corev1.ServiceAccount{
+func TestCheckServiceAccountTokens(t *testing.T) {
+	fls := false
+	sa := func(name string, automount *bool) *corev1.ServiceAccount {
+		return &corev1.ServiceAccount{
+			ObjectMeta:                   metav1.ObjectMeta{Name: name, Namespace: "ns1"},
+			AutomountServiceAccountToken: automount,
+		}
+	}
+	pod := func(name, saName string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns1"},
+			Spec:       corev1.PodSpec{ServiceAccountName: saName},
+		}
+	}
+	binding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-reader", Namespace: "ns1"},
+		Subjects:   []rbacv1.Subject{{Kind: "ServiceAccount", Name: "api"}},
+		RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "reader"},
+	}
+	legacy := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:        "default",
+			Name:        "api-token-abcde",
 			Namespace:   "ns1",
-			Annotations: map[string]string{"foo": "bar"},
+			Annotations: map[string]string{corev1.ServiceAccountNameKey: "api"},
 		},
+		Type: corev1.SecretTypeServiceAccountToken,
+	}
+	opaque := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "ns1"},
+		Type:       corev1.SecretTypeOpaque,
+	}
+
+	client := fake.NewSimpleClientset(
+		sa("default", nil), sa("api", nil), sa("worker", nil), sa("quiet", &fls),
+		pod("web", ""), pod("api-1", "api"), pod("worker-1", "worker"), pod("worker-2", "worker"), pod("batch", "quiet"),
+		binding, legacy, opaque,
+	)
+	findings := checkServiceAccountTokens("ns1", client)
+
+	want := []string{
+		"[MED]  Pod ns1/api-1 automounts the token of ServiceAccount api, which has RBAC permissions via RoleBinding ns1/api-reader → Role reader",
+		"[MED]  Pod ns1/web runs as the default ServiceAccount",
+		"[LOW]  ServiceAccount ns1/default token is mounted in 1 pod(s) but has no RBAC bindings",
+		"[LOW]  ServiceAccount ns1/worker token is mounted in 2 pod(s) but has no RBAC bindings",
+		"[MED]  Secret ns1/api-token-abcde is a legacy long-lived token for ServiceAccount api",
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(want), len(findings), findings)
 	}
-	client3 := fake.NewSimpleClientset(sa3)
-	if !defaultSAUsed("ns1", client3) {
-		t.Error("expected defaultSAUsed=true when SA has an Annotation")
+	for i, w := range want {
+		if !strings.HasPrefix(findings[i].Message, w) {
+			t.Errorf("finding %d = %q; want prefix %q", i, findings[i].Message, w)
+		}
 	}
 }
 
@@ -162,13 +258,18 @@ func TestCheckDefaultSARoleBindings(t *testing.T) {
 }
 
 func TestRunNamespaceCheck_AllFindings(t *testing.T) {
-	// Build fake client with no RQ, no LR, default SA used, plus 2 RoleBindings
+	// Build fake client with no RQ, no LR, a pod on the default SA, plus 2 RoleBindings
 	sa := &corev1.ServiceAccount{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "default",
 			Namespace: "ns1",
 		},
-		Secrets: []corev1.ObjectReference{{Name: "s1"}},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web",
+			Namespace: "ns1",
+		},
 	}
 	rbHigh := &rbacv1.RoleBinding{
 		ObjectMeta: metav1.ObjectMeta{
@@ -201,7 +302,7 @@ func TestRunNamespaceCheck_AllFindings(t *testing.T) {
 		},
 	}
 
-	var client kubernetes.Interface = fake.NewSimpleClientset(sa, rbHigh, rbMed)
+	var client kubernetes.Interface = fake.NewSimpleClientset(sa, pod, rbHigh, rbMed)
 
 	out := captureOutput(func() {
 		RunNamespaceCheck("ns1", client)
@@ -214,9 +315,12 @@ func TestRunNamespaceCheck_AllFindings(t *testing.T) {
 	if !strings.Contains(out, "[MED]  Namespace ns1 has no LimitRange") {
 		t.Error("missing LimitRange warning")
 	}
-	if !strings.Contains(out, "[MED]  Namespace ns1 default ServiceAccount is in use") {
+	if !strings.Contains(out, "[MED]  Pod ns1/web runs as the default ServiceAccount") {
 		t.Error("missing default SA usage warning")
 	}
+	if !strings.Contains(out, "[HIGH] Pod ns1/web automounts the token of ServiceAccount default") {
+		t.Error("missing automounted token warning")
+	}
 
 	// RoleBinding messages
 	if !strings.Contains(out, "[HIGH] Namespace ns1 default SA is bound to cluster-admin") {
@@ -227,10 +331,10 @@ func TestRunNamespaceCheck_AllFindings(t *testing.T) {
 	}
 
 	// Summary
-	if !strings.Contains(out, "Total Checks Run        : 5") {
+	if !strings.Contains(out, "Total Checks Run        : 7") {
 		t.Error("wrong total checks in summary")
 	}
-	if !strings.Contains(out, "High Severity Findings  : 1") {
+	if !strings.Contains(out, "High Severity Findings  : 2") {
 		t.Error("wrong high count in summary")
 	}
 	if !strings.Contains(out, "Medium Severity Findings: 4") {
@@ -0,0 +1,414 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/khaugen7/eks-security-scanner/internal/utils"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+// podPort is a port traffic is sent to on a destination pod. Name is set
+// when the port is declared on a container, so named policy ports match.
+type podPort struct {
+	Protocol corev1.Protocol
+	Port     int32
+	Name     string
+}
+
+// policyEvaluator answers whether NetworkPolicies allow traffic between
+// pods. Without any policy selecting a pod, all traffic to and from it is
+// allowed, as in Kubernetes.
+type policyEvaluator struct {
+	policies        []networkingv1.NetworkPolicy
+	namespaceLabels map[string]map[string]string
+}
+
+// newPolicyEvaluator loads every NetworkPolicy and the namespace labels that
+// namespaceSelectors match against. Policies in other namespaces matter even
+// for a single-namespace scan since they control egress from there.
+func newPolicyEvaluator(client kubernetes.Interface) (*policyEvaluator, error) {
+	policies, err := client.NetworkingV1().NetworkPolicies("").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list NetworkPolicies: %w", err)
+	}
+	namespaces, err := client.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+
+	e := &policyEvaluator{policies: policies.Items, namespaceLabels: make(map[string]map[string]string)}
+	for _, ns := range namespaces.Items {
+		e.namespaceLabels[ns.Name] = ns.Labels
+	}
+	return e, nil
+}
+
+// labelsOf returns a namespace's labels, including the name label the API
+// server sets automatically.
+func (e *policyEvaluator) labelsOf(namespace string) labels.Set {
+	set := labels.Set{"kubernetes.io/metadata.name": namespace}
+	for k, v := range e.namespaceLabels[namespace] {
+		set[k] = v
+	}
+	return set
+}
+
+// selecting returns the policies of the given type that select pod.
+func (e *policyEvaluator) selecting(pod corev1.Pod, policyType networkingv1.PolicyType) []networkingv1.NetworkPolicy {
+	var selected []networkingv1.NetworkPolicy
+	for _, p := range e.policies {
+		if p.Namespace != pod.Namespace || !hasPolicyType(p, policyType) {
+			continue
+		}
+		if selectorMatchesLabels(&p.Spec.PodSelector, pod.Labels) {
+			selected = append(selected, p)
+		}
+	}
+	return selected
+}
+
+// hasPolicyType applies the API defaults: Ingress is always implied when
+// policyTypes is empty, Egress only when egress rules are present.
+func hasPolicyType(p networkingv1.NetworkPolicy, t networkingv1.PolicyType) bool {
+	if len(p.Spec.PolicyTypes) == 0 {
+		return t == networkingv1.PolicyTypeIngress || (t == networkingv1.PolicyTypeEgress && len(p.Spec.Egress) > 0)
+	}
+	for _, pt := range p.Spec.PolicyTypes {
+		if pt == t {
+			return true
+		}
+	}
+	return false
+}
+
+// isolated reports whether any policy restricts the given direction of
+// traffic for pod.
+func (e *policyEvaluator) isolated(pod corev1.Pod, policyType networkingv1.PolicyType) bool {
+	return len(e.selecting(pod, policyType)) > 0
+}
+
+// allowed reports whether src may send traffic to port on dst: egress from
+// src and ingress to dst must both be permitted.
+func (e *policyEvaluator) allowed(src, dst corev1.Pod, port podPort) bool {
+	return e.egressAllowed(src, dst, port) && e.ingressAllowed(&src, dst, port)
+}
+
+// ingressAllowed checks dst's ingress policies. A nil src stands for traffic
+// from the internet, which only rules without peers and ipBlock peers
+// covering public addresses admit.
+func (e *policyEvaluator) ingressAllowed(src *corev1.Pod, dst corev1.Pod, port podPort) bool {
+	policies := e.selecting(dst, networkingv1.PolicyTypeIngress)
+	if len(policies) == 0 {
+		return true
+	}
+	for _, p := range policies {
+		for _, rule := range p.Spec.Ingress {
+			if portsMatch(rule.Ports, port) && e.peersMatch(rule.From, p.Namespace, src) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (e *policyEvaluator) egressAllowed(src, dst corev1.Pod, port podPort) bool {
+	policies := e.selecting(src, networkingv1.PolicyTypeEgress)
+	if len(policies) == 0 {
+		return true
+	}
+	for _, p := range policies {
+		for _, rule := range p.Spec.Egress {
+			if portsMatch(rule.Ports, port) && e.peersMatch(rule.To, p.Namespace, &dst) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// peersMatch reports whether pod (nil for an internet address) is one of
+// peers. An empty peer list matches everything.
+func (e *policyEvaluator) peersMatch(peers []networkingv1.NetworkPolicyPeer, policyNamespace string, pod *corev1.Pod) bool {
+	if len(peers) == 0 {
+		return true
+	}
+	for _, peer := range peers {
+		if peer.IPBlock != nil {
+			// Internet addresses are unknown; any ipBlock reaching beyond the
+			// private ranges admits some of them.
+			if pod == nil && ipBlockAdmitsPublic(peer.IPBlock) || pod != nil && ipBlockContains(peer.IPBlock, pod.Status.PodIP) {
+				return true
+			}
+			continue
+		}
+		if pod == nil {
+			continue
+		}
+		if peer.NamespaceSelector != nil {
+			if !selectorMatchesLabels(peer.NamespaceSelector, e.labelsOf(pod.Namespace)) {
+				continue
+			}
+		} else if pod.Namespace != policyNamespace {
+			continue
+		}
+		if peer.PodSelector != nil && !selectorMatchesLabels(peer.PodSelector, pod.Labels) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+func ipBlockContains(block *networkingv1.IPBlock, ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	if _, cidr, err := net.ParseCIDR(block.CIDR); err != nil || !cidr.Contains(addr) {
+		return false
+	}
+	for _, except := range block.Except {
+		if _, cidr, err := net.ParseCIDR(except); err == nil && cidr.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// privateNetworks are the address ranges internet traffic does not come
+// from: RFC 1918, carrier-grade NAT, loopback, link-local and unique local.
+var privateNetworks = parseCIDRs("10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "100.64.0.0/10",
+	"127.0.0.0/8", "169.254.0.0/16", "fc00::/7", "fe80::/10", "::1/128")
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, c := range cidrs {
+		_, nets[i], _ = net.ParseCIDR(c)
+	}
+	return nets
+}
+
+// ipBlockAdmitsPublic reports whether block covers public addresses: its CIDR
+// is not within a private range and not wholly excepted.
+func ipBlockAdmitsPublic(block *networkingv1.IPBlock) bool {
+	_, cidr, err := net.ParseCIDR(block.CIDR)
+	if err != nil {
+		return false
+	}
+	for _, private := range privateNetworks {
+		if cidrCovers(private, cidr) {
+			return false
+		}
+	}
+	for _, except := range block.Except {
+		if _, e, err := net.ParseCIDR(except); err == nil && cidrCovers(e, cidr) {
+			return false
+		}
+	}
+	return true
+}
+
+// cidrCovers reports whether inner lies entirely within outer.
+func cidrCovers(outer, inner *net.IPNet) bool {
+	outerOnes, outerBits := outer.Mask.Size()
+	innerOnes, innerBits := inner.Mask.Size()
+	return outerBits == innerBits && outerOnes <= innerOnes && outer.Contains(inner.IP)
+}
+
+// portsMatch reports whether port is covered by a rule's ports. An empty
+// list matches every port.
+func portsMatch(ports []networkingv1.NetworkPolicyPort, port podPort) bool {
+	if len(ports) == 0 {
+		return true
+	}
+	for _, p := range ports {
+		protocol := corev1.ProtocolTCP
+		if p.Protocol != nil {
+			protocol = *p.Protocol
+		}
+		if protocol != port.Protocol {
+			continue
+		}
+		switch {
+		case p.Port == nil:
+			return true
+		case p.Port.Type == intstr.String:
+			if port.Name != "" && p.Port.StrVal == port.Name {
+				return true
+			}
+		case p.EndPort != nil:
+			if port.Port >= p.Port.IntVal && port.Port <= *p.EndPort {
+				return true
+			}
+		case p.Port.IntVal == port.Port:
+			return true
+		}
+	}
+	return false
+}
+
+func selectorMatchesLabels(selector *metav1.LabelSelector, set map[string]string) bool {
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false
+	}
+	return s.Matches(labels.Set(set))
+}
+
+// servicePorts resolves the target ports of svc on pod. Named target ports
+// the pod doesn't declare are dropped.
+func servicePorts(svc corev1.Service, pod corev1.Pod) []podPort {
+	var ports []podPort
+	for _, sp := range svc.Spec.Ports {
+		protocol := sp.Protocol
+		if protocol == "" {
+			protocol = corev1.ProtocolTCP
+		}
+		switch {
+		case sp.TargetPort.Type == intstr.String:
+			if p, ok := containerPort(pod, func(cp corev1.ContainerPort) bool { return cp.Name == sp.TargetPort.StrVal }); ok {
+				ports = append(ports, p)
+			}
+		default:
+			number := sp.TargetPort.IntVal
+			if number == 0 {
+				number = sp.Port
+			}
+			p, ok := containerPort(pod, func(cp corev1.ContainerPort) bool { return cp.ContainerPort == number })
+			if !ok {
+				p = podPort{Protocol: protocol, Port: number}
+			}
+			ports = append(ports, p)
+		}
+	}
+	return ports
+}
+
+func containerPort(pod corev1.Pod, match func(corev1.ContainerPort) bool) (podPort, bool) {
+	for _, c := range pod.Spec.Containers {
+		for _, cp := range c.Ports {
+			if match(cp) {
+				protocol := cp.Protocol
+				if protocol == "" {
+					protocol = corev1.ProtocolTCP
+				}
+				return podPort{Protocol: protocol, Port: cp.ContainerPort, Name: cp.Name}, true
+			}
+		}
+	}
+	return podPort{}, false
+}
+
+// candidatePorts are the ports to try when asking whether any traffic from
+// src reaches dst: the ports dst declares, numeric ports named in the
+// relevant policies, and an unnamed port that only matches rules without a
+// port restriction.
+func (e *policyEvaluator) candidatePorts(src, dst corev1.Pod) []podPort {
+	ports := []podPort{{Protocol: corev1.ProtocolTCP}}
+	for _, c := range dst.Spec.Containers {
+		for _, cp := range c.Ports {
+			protocol := cp.Protocol
+			if protocol == "" {
+				protocol = corev1.ProtocolTCP
+			}
+			ports = append(ports, podPort{Protocol: protocol, Port: cp.ContainerPort, Name: cp.Name})
+		}
+	}
+
+	addRulePorts := func(rulePorts []networkingv1.NetworkPolicyPort) {
+		for _, p := range rulePorts {
+			if p.Port == nil || p.Port.Type != intstr.Int {
+				continue
+			}
+			protocol := corev1.ProtocolTCP
+			if p.Protocol != nil {
+				protocol = *p.Protocol
+			}
+			ports = append(ports, podPort{Protocol: protocol, Port: p.Port.IntVal})
+		}
+	}
+	for _, p := range e.selecting(dst, networkingv1.PolicyTypeIngress) {
+		for _, rule := range p.Spec.Ingress {
+			addRulePorts(rule.Ports)
+		}
+	}
+	for _, p := range e.selecting(src, networkingv1.PolicyTypeEgress) {
+		for _, rule := range p.Spec.Egress {
+			addRulePorts(rule.Ports)
+		}
+	}
+	return ports
+}
+
+// reachable reports whether src can send any traffic to dst.
+func (e *policyEvaluator) reachable(src, dst corev1.Pod) bool {
+	for _, port := range e.candidatePorts(src, dst) {
+		if e.allowed(src, dst, port) {
+			return true
+		}
+	}
+	return false
+}
+
+// RunReachabilityCheck prints the pod-to-pod allow matrix computed from
+// NetworkPolicies for the pods in namespace (all namespaces if empty).
+func RunReachabilityCheck(namespace string, client kubernetes.Interface) {
+	utils.PrintScannerHeader("Network Reachability")
+
+	pods, err := client.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		fmt.Printf("Failed to list pods: %v\n", err)
+		return
+	}
+	eval, err := newPolicyEvaluator(client)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		return
+	}
+
+	fmt.Printf("\n[+] Evaluating %d NetworkPolicies across %d pods...\n", len(eval.policies), len(pods.Items))
+	fmt.Printf("\nPod-to-pod reachability (row can reach column):\n\n")
+
+	n := len(pods.Items)
+	width := len(fmt.Sprint(n))
+	fmt.Printf("  %*s", width, "")
+	for j := range pods.Items {
+		fmt.Printf(" %*d", width, j+1)
+	}
+	fmt.Println()
+
+	allowed, isolatedPods := 0, 0
+	for i, src := range pods.Items {
+		var row strings.Builder
+		for j, dst := range pods.Items {
+			mark := "✓"
+			if i == j {
+				mark = "-"
+			} else if eval.reachable(src, dst) {
+				allowed++
+			} else {
+				mark = "✗"
+			}
+			fmt.Fprintf(&row, " %*s", width, mark)
+		}
+		fmt.Printf("  %*d%s   %s/%s\n", width, i+1, row.String(), src.Namespace, src.Name)
+
+		if eval.isolated(src, networkingv1.PolicyTypeIngress) || eval.isolated(src, networkingv1.PolicyTypeEgress) {
+			isolatedPods++
+		}
+	}
+
+	pairs := n * (n - 1)
+	fmt.Printf("\n[✓] Reachability Summary\n")
+	fmt.Printf("    Pods Evaluated          : %d\n", n)
+	fmt.Printf("    Pods Under a Policy     : %d\n", isolatedPods)
+	fmt.Printf("    Allowed Pod Pairs       : %d of %d\n", allowed, max(pairs, 0))
+}
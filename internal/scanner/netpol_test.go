@@ -0,0 +1,247 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/khaugen7/eks-security-scanner/internal/graph"
+	"github.com/khaugen7/eks-security-scanner/internal/testhelpers"
+)
+
+func netpolPod(ns, name, app, ip string, ports ...corev1.ContainerPort) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns, Labels: map[string]string{"app": app}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "c", Ports: ports}}},
+		Status:     corev1.PodStatus{PodIP: ip},
+	}
+}
+
+func TestPolicyEvaluator(t *testing.T) {
+	web := netpolPod("shop", "web", "web", "10.0.0.1")
+	db := netpolPod("shop", "db", "db", "10.0.0.2", corev1.ContainerPort{Name: "pg", ContainerPort: 5432})
+	batch := netpolPod("jobs", "batch", "batch", "10.0.1.1")
+	monitor := netpolPod("monitoring", "prom", "prom", "10.0.2.1")
+
+	dbIngress := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-ingress", Namespace: "shop"},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{
+					From:  []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}}},
+					Ports: []networkingv1.NetworkPolicyPort{{Port: &intstr.IntOrString{Type: intstr.String, StrVal: "pg"}}},
+				},
+				{
+					From: []networkingv1.NetworkPolicyPeer{{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "sre"}}}},
+				},
+			},
+		},
+	}
+	batchEgress := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "deny-egress", Namespace: "jobs"},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+			Egress: []networkingv1.NetworkPolicyEgressRule{{
+				To: []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.0/16", Except: []string{"10.0.0.2/32"}}}},
+			}},
+		},
+	}
+	monitoringNS := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "monitoring", Labels: map[string]string{"team": "sre"}}}
+
+	var client kubernetes.Interface = fake.NewSimpleClientset(dbIngress, batchEgress, monitoringNS)
+	eval, err := newPolicyEvaluator(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pg := podPort{Protocol: corev1.ProtocolTCP, Port: 5432, Name: "pg"}
+	ssh := podPort{Protocol: corev1.ProtocolTCP, Port: 22}
+	tests := []struct {
+		name     string
+		src, dst *corev1.Pod
+		port     podPort
+		want     bool
+	}{
+		{"selected pod on named port", web, db, pg, true},
+		{"selected pod on other port", web, db, ssh, false},
+		{"namespace selector on any port", monitor, db, ssh, true},
+		{"unselected source", batch, db, pg, false},
+		{"egress ipBlock allows", batch, web, ssh, true},
+		{"egress ipBlock except", monitor, batch, ssh, true},
+		{"unisolated destination", db, web, ssh, true},
+	}
+	for _, tt := range tests {
+		if got := eval.allowed(*tt.src, *tt.dst, tt.port); got != tt.want {
+			t.Errorf("%s: allowed(%s → %s) = %v, want %v", tt.name, tt.src.Name, tt.dst.Name, got, tt.want)
+		}
+	}
+
+	if !eval.isolated(*db, networkingv1.PolicyTypeIngress) || eval.isolated(*db, networkingv1.PolicyTypeEgress) {
+		t.Error("db should be isolated for ingress only")
+	}
+	if eval.ingressAllowed(nil, *db, pg) {
+		t.Error("external traffic should not reach db without an ipBlock rule")
+	}
+	if !eval.reachable(*web, *db) || eval.reachable(*batch, *db) {
+		t.Error("unexpected pod-to-pod reachability for db")
+	}
+}
+
+func TestIPBlockAdmitsPublic(t *testing.T) {
+	tests := []struct {
+		block networkingv1.IPBlock
+		want  bool
+	}{
+		{networkingv1.IPBlock{CIDR: "0.0.0.0/0"}, true},
+		{networkingv1.IPBlock{CIDR: "0.0.0.0/0", Except: []string{"10.0.0.0/8"}}, true},
+		{networkingv1.IPBlock{CIDR: "203.0.113.0/24"}, true},
+		{networkingv1.IPBlock{CIDR: "10.0.0.0/8"}, false},
+		{networkingv1.IPBlock{CIDR: "172.20.0.0/16"}, false},
+		{networkingv1.IPBlock{CIDR: "192.168.1.0/24"}, false},
+		{networkingv1.IPBlock{CIDR: "203.0.113.0/24", Except: []string{"203.0.0.0/16"}}, false},
+		{networkingv1.IPBlock{CIDR: "fd00::/64"}, false},
+		{networkingv1.IPBlock{CIDR: "::/0"}, true},
+		{networkingv1.IPBlock{CIDR: "not-a-cidr"}, false},
+	}
+	for _, tt := range tests {
+		if got := ipBlockAdmitsPublic(&tt.block); got != tt.want {
+			t.Errorf("ipBlockAdmitsPublic(%s except %v) = %v, want %v", tt.block.CIDR, tt.block.Except, got, tt.want)
+		}
+	}
+}
+
+func TestPortsMatch(t *testing.T) {
+	udp := corev1.ProtocolUDP
+	end := int32(8090)
+	ports := []networkingv1.NetworkPolicyPort{
+		{Port: &intstr.IntOrString{IntVal: 8080}, EndPort: &end},
+		{Protocol: &udp, Port: &intstr.IntOrString{IntVal: 53}},
+	}
+	tests := []struct {
+		port podPort
+		want bool
+	}{
+		{podPort{Protocol: corev1.ProtocolTCP, Port: 8085}, true},
+		{podPort{Protocol: corev1.ProtocolTCP, Port: 8091}, false},
+		{podPort{Protocol: corev1.ProtocolUDP, Port: 53}, true},
+		{podPort{Protocol: corev1.ProtocolTCP, Port: 53}, false},
+	}
+	for _, tt := range tests {
+		if got := portsMatch(ports, tt.port); got != tt.want {
+			t.Errorf("portsMatch(%+v) = %v, want %v", tt.port, got, tt.want)
+		}
+	}
+	if !portsMatch(nil, podPort{Protocol: corev1.ProtocolTCP, Port: 1}) {
+		t.Error("empty port list should match everything")
+	}
+}
+
+func TestServicePorts(t *testing.T) {
+	pod := netpolPod("ns", "api", "api", "", corev1.ContainerPort{Name: "http", ContainerPort: 8080})
+	svc := corev1.Service{Spec: corev1.ServiceSpec{Ports: []corev1.ServicePort{
+		{Port: 80, TargetPort: intstr.FromString("http")},
+		{Port: 9090},
+		{Port: 443, TargetPort: intstr.FromString("https")},
+	}}}
+
+	got := servicePorts(svc, *pod)
+	want := []podPort{
+		{Protocol: corev1.ProtocolTCP, Port: 8080, Name: "http"},
+		{Protocol: corev1.ProtocolTCP, Port: 9090},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("servicePorts = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("servicePorts[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestBuildThreatGraph_NetworkPolicy(t *testing.T) {
+	lb := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "ns1"},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeLoadBalancer,
+			Selector: map[string]string{"app": "api"},
+			Ports:    []corev1.ServicePort{{Port: 443, TargetPort: intstr.FromInt32(8443)}},
+		},
+	}
+	pod := netpolPod("ns1", "api-1", "api", "10.0.0.5", corev1.ContainerPort{ContainerPort: 8443})
+	pod.Spec.ServiceAccountName = "api"
+	denyAll := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "internal-only", Namespace: "ns1"},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{{
+				From: []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}},
+			}},
+		},
+	}
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{
+		Name:        "api",
+		Namespace:   "ns1",
+		Annotations: map[string]string{"eks.amazonaws.com/role-arn": "arn:aws:iam::123:role/Api"},
+	}}
+
+	var client kubernetes.Interface = fake.NewSimpleClientset(lb, pod, denyAll, sa)
+	g := BuildThreatGraph("ns1", client)
+
+	n, _ := g.Node("pod/ns1/api-1")
+	if n.Attr("ingress-isolated") != "true" || n.Attr("external-ingress") != "denied" {
+		t.Errorf("unexpected pod attrs %v", n.Attrs)
+	}
+	if paths := FindAttackPaths(g, 0); len(paths) != 0 {
+		t.Errorf("expected NetworkPolicy to block the path from the LoadBalancer, got %d paths", len(paths))
+	}
+
+	// Opening the port to ipBlocks lets load balancer traffic in
+	denyAll.Spec.Ingress = append(denyAll.Spec.Ingress, networkingv1.NetworkPolicyIngressRule{
+		From:  []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "0.0.0.0/0"}}},
+		Ports: []networkingv1.NetworkPolicyPort{{Port: &intstr.IntOrString{IntVal: 8443}}},
+	})
+	client = fake.NewSimpleClientset(lb, pod, denyAll, sa)
+	g = BuildThreatGraph("ns1", client)
+	if n, _ := g.Node("pod/ns1/api-1"); n.Attr("external-ingress") != "allowed" {
+		t.Errorf("expected external ingress to be allowed, got %v", n.Attrs)
+	}
+	paths := FindAttackPaths(g, 0)
+	if len(paths) == 0 || paths[0].Target.Kind != graph.KindIAMRole {
+		t.Errorf("expected a path to the IAM role, got %+v", paths)
+	}
+}
+
+func TestRunReachabilityCheck(t *testing.T) {
+	web := netpolPod("ns1", "a-web", "web", "")
+	db := netpolPod("ns1", "b-db", "db", "")
+	policy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "ns1"},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		},
+	}
+
+	var client kubernetes.Interface = fake.NewSimpleClientset(web, db, policy)
+	out := testhelpers.CaptureOutput(func() { RunReachabilityCheck("ns1", client) })
+
+	for _, want := range []string{
+		"1 - ✗   ns1/a-web",
+		"2 ✓ -   ns1/b-db",
+		"Pods Under a Policy     : 1",
+		"Allowed Pod Pairs       : 1 of 2",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in output:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/smithy-go"
+	"github.com/khaugen7/eks-security-scanner/internal/graph"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// dangerousCapabilities let a container break out of its namespaces or
+// tamper with the host kernel.
+var dangerousCapabilities = map[corev1.Capability]bool{
+	"SYS_ADMIN":       true,
+	"SYS_PTRACE":      true,
+	"SYS_MODULE":      true,
+	"DAC_READ_SEARCH": true,
+	"NET_ADMIN":       true,
+	"ALL":             true,
+}
+
+// escapeVector is a pod setting that lets a compromised container reach the
+// node it runs on. Label names the vector on pod → node graph edges.
+type escapeVector struct {
+	Label    string
+	Severity string
+	Reason   string
+}
+
+// podEscapeVectors lists the ways out of a pod onto its node, most specific
+// first: per-container settings, host namespaces, then hostPath mounts.
+// Read-only mounts of harmless paths are not escape vectors.
+func podEscapeVectors(pod corev1.Pod) []escapeVector {
+	var vectors []escapeVector
+	for _, c := range allContainers(pod.Spec) {
+		sc := c.SecurityContext
+		if sc == nil {
+			continue
+		}
+		if sc.Privileged != nil && *sc.Privileged {
+			vectors = append(vectors, escapeVector{"privileged", "CRITICAL",
+				fmt.Sprintf("container %s is privileged: full access to host devices and kernel", c.Name)})
+		}
+		if sc.Capabilities != nil {
+			for _, capability := range sc.Capabilities.Add {
+				if dangerousCapabilities[capability] {
+					vectors = append(vectors, escapeVector{"cap:" + string(capability), "HIGH",
+						fmt.Sprintf("container %s adds capability %s", c.Name, capability)})
+				}
+			}
+		}
+	}
+	if pod.Spec.HostPID {
+		vectors = append(vectors, escapeVector{"hostPID", "HIGH", "hostPID: can see and signal host processes"})
+	}
+	if pod.Spec.HostNetwork {
+		vectors = append(vectors, escapeVector{"hostNetwork", "HIGH",
+			"hostNetwork: can reach node-local services such as the kubelet and instance metadata"})
+	}
+	if pod.Spec.HostIPC {
+		vectors = append(vectors, escapeVector{"hostIPC", "MED", "hostIPC: shares IPC namespace with the host"})
+	}
+	for _, v := range pod.Spec.Volumes {
+		if v.HostPath == nil {
+			continue
+		}
+		severity, reason := classifyHostPath(v.HostPath.Path, hostPathReadOnly(pod.Spec, v.Name), nil)
+		if severity == "LOW" {
+			continue
+		}
+		vectors = append(vectors, escapeVector{"hostPath:" + v.HostPath.Path, severity,
+			fmt.Sprintf("hostPath %s: %s", v.HostPath.Path, reason)})
+	}
+	return vectors
+}
+
+// isEscapeLabel reports whether a graph edge label is a pod → node escape
+// vector.
+func isEscapeLabel(label string) bool {
+	switch label {
+	case "privileged", "hostPID", "hostNetwork", "hostIPC":
+		return true
+	}
+	return strings.HasPrefix(label, "hostPath:") || strings.HasPrefix(label, "cap:")
+}
+
+// instanceIDFromProviderID extracts the EC2 instance ID from a node's
+// providerID, e.g. aws:///us-east-1a/i-0123456789abcdef0.
+func instanceIDFromProviderID(providerID string) string {
+	if !strings.HasPrefix(providerID, "aws://") {
+		return ""
+	}
+	id := providerID[strings.LastIndex(providerID, "/")+1:]
+	if !strings.HasPrefix(id, "i-") {
+		return ""
+	}
+	return id
+}
+
+// newNodeNode records the node's EC2 instance so its IAM role can be looked
+// up later.
+func newNodeNode(node corev1.Node) graph.Node {
+	n := graph.Node{Kind: graph.KindNode, Name: node.Name, Attrs: map[string]string{}}
+	if id := instanceIDFromProviderID(node.Spec.ProviderID); id != "" {
+		n.Attrs["instance-id"] = id
+	}
+	if ng := node.Labels["eks.amazonaws.com/nodegroup"]; ng != "" {
+		n.Attrs["nodegroup"] = ng
+	}
+	return n
+}
+
+// addNodeIAMRoles links node graph nodes to the IAM role of their EC2
+// instance profile. Every pod that reaches the node can use these
+// credentials through the instance metadata service. Lookups stop at the
+// first error since it is usually missing credentials; instances that no
+// longer exist are skipped.
+func addNodeIAMRoles(g *graph.Graph) {
+	byInstance := make(map[string]*graph.Node)
+	var ids []string
+	for _, n := range g.NodesOfKind(graph.KindNode) {
+		if id := n.Attr("instance-id"); id != "" {
+			byInstance[id] = n
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	cfg, err := LoadAWSConfig(context.TODO())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[!] Skipping node IAM roles: unable to load AWS config: %v\n", err)
+		return
+	}
+	ec2Client := ec2.NewFromConfig(cfg)
+	iamClient := iam.NewFromConfig(cfg)
+
+	profiles, err := describeInstanceProfiles(ec2Client, ids)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[!] Skipping node IAM roles: unable to describe instances: %v\n", err)
+		return
+	}
+
+	roles := make(map[string][]string)
+	for _, id := range ids {
+		profileArn, ok := profiles[id]
+		if !ok {
+			continue
+		}
+		profileName := extractRoleName(profileArn)
+		if _, done := roles[profileName]; !done {
+			out, err := iamClient.GetInstanceProfile(context.TODO(), &iam.GetInstanceProfileInput{InstanceProfileName: &profileName})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "[!] Skipping node IAM roles: instance profile %s: %v\n", profileName, err)
+				return
+			}
+			roles[profileName] = nil
+			for _, r := range out.InstanceProfile.Roles {
+				if r.RoleName != nil && r.Arn != nil {
+					roles[profileName] = append(roles[profileName], *r.Arn)
+				}
+			}
+		}
+
+		node := byInstance[id]
+		node.Attrs["instance-profile"] = profileArn
+		for _, roleArn := range roles[profileName] {
+			roleNode := graph.Node{
+				Kind:  graph.KindIAMRole,
+				Name:  extractRoleName(roleArn),
+				Attrs: map[string]string{"arn": roleArn},
+			}
+			g.AddEdge(*node, roleNode, "instance-profile")
+		}
+	}
+}
+
+// describeInstancesBatch is how many instance IDs are described per request.
+const describeInstancesBatch = 100
+
+// describeInstanceProfiles returns the instance profile ARN of each instance
+// by ID. EC2 fails a whole request if one instance no longer exists, which is
+// routine while nodes scale down, so such batches are retried one instance at
+// a time and the missing instances skipped.
+func describeInstanceProfiles(client *ec2.Client, ids []string) (map[string]string, error) {
+	profiles := make(map[string]string)
+	describe := func(batch []string) error {
+		paginator := ec2.NewDescribeInstancesPaginator(client, &ec2.DescribeInstancesInput{InstanceIds: batch})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(context.TODO())
+			if err != nil {
+				return err
+			}
+			for _, res := range page.Reservations {
+				for _, inst := range res.Instances {
+					if inst.InstanceId != nil && inst.IamInstanceProfile != nil && inst.IamInstanceProfile.Arn != nil {
+						profiles[*inst.InstanceId] = *inst.IamInstanceProfile.Arn
+					}
+				}
+			}
+		}
+		return nil
+	}
+
+	for start := 0; start < len(ids); start += describeInstancesBatch {
+		batch := ids[start:min(start+describeInstancesBatch, len(ids))]
+		err := describe(batch)
+		if !instanceNotFound(err) {
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		for _, id := range batch {
+			if err := describe([]string{id}); err != nil && !instanceNotFound(err) {
+				return nil, err
+			}
+		}
+	}
+	return profiles, nil
+}
+
+// instanceNotFound reports whether err is EC2 rejecting an instance ID that
+// does not exist.
+func instanceNotFound(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "InvalidInstanceID.NotFound"
+}
@@ -0,0 +1,192 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/khaugen7/eks-security-scanner/internal/graph"
+)
+
+func TestInstanceIDFromProviderID(t *testing.T) {
+	tests := map[string]string{
+		"aws:///us-east-1a/i-0123456789abcdef0": "i-0123456789abcdef0",
+		"aws:///us-east-1a/fargate-ip-10-0-1-5": "",
+		"gce://project/zone/node-1":             "",
+		"":                                      "",
+	}
+	for in, want := range tests {
+		if got := instanceIDFromProviderID(in); got != want {
+			t.Errorf("instanceIDFromProviderID(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPodEscapeVectors(t *testing.T) {
+	privileged := true
+	pod := corev1.Pod{Spec: corev1.PodSpec{
+		HostNetwork: true,
+		Containers: []corev1.Container{{
+			Name:            "c",
+			SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+			VolumeMounts:    []corev1.VolumeMount{{Name: "logs", MountPath: "/logs", ReadOnly: true}},
+		}},
+		Volumes: []corev1.Volume{
+			{Name: "docker", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/run/docker.sock"}}},
+			{Name: "logs", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/log"}}},
+		},
+	}}
+
+	var labels []string
+	for _, v := range podEscapeVectors(pod) {
+		labels = append(labels, v.Label)
+		if !isEscapeLabel(v.Label) {
+			t.Errorf("isEscapeLabel(%q) = false", v.Label)
+		}
+	}
+	want := []string{"privileged", "hostNetwork", "hostPath:/var/run/docker.sock"}
+	if len(labels) != len(want) {
+		t.Fatalf("labels = %v, want %v", labels, want)
+	}
+	for i := range want {
+		if labels[i] != want[i] {
+			t.Errorf("labels = %v, want %v", labels, want)
+			break
+		}
+	}
+	if isEscapeLabel("uses") {
+		t.Error("isEscapeLabel(uses) = true")
+	}
+}
+
+func TestBuildThreatGraph_NodeEscape(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "ip-10-0-1-5", Labels: map[string]string{"eks.amazonaws.com/nodegroup": "general"}},
+		Spec:       corev1.NodeSpec{ProviderID: "aws:///us-east-1a/i-0123456789abcdef0"},
+	}
+	agent := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "ns1"},
+		Spec: corev1.PodSpec{
+			NodeName: "ip-10-0-1-5",
+			HostPID:  true,
+			Volumes: []corev1.Volume{{Name: "sock", VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{Path: "/var/run/docker.sock"},
+			}}},
+		},
+	}
+	web := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "ns1"},
+		Spec:       corev1.PodSpec{NodeName: "ip-10-0-1-5"},
+	}
+
+	var client kubernetes.Interface = fake.NewSimpleClientset(node, agent, web)
+	g := BuildThreatGraph("ns1", client)
+
+	var labels []string
+	for _, e := range g.In("node/ip-10-0-1-5") {
+		if e.From != "pod/ns1/agent" {
+			t.Errorf("unexpected escape edge from %s", e.From)
+		}
+		labels = append(labels, e.Label)
+	}
+	if len(labels) != 2 || labels[0] != "hostPID" || labels[1] != "hostPath:/var/run/docker.sock" {
+		t.Errorf("escape edges = %v", labels)
+	}
+	n, ok := g.Node("node/ip-10-0-1-5")
+	if !ok {
+		t.Fatal("node missing from graph")
+	}
+	if n.Attr("instance-id") != "i-0123456789abcdef0" || n.Attr("nodegroup") != "general" {
+		t.Errorf("unexpected node attrs %v", n.Attrs)
+	}
+}
+
+func TestFindAttackPaths_NodeEscape(t *testing.T) {
+	pod := graph.Node{Kind: graph.KindPod, Namespace: "ns", Name: "agent", Attrs: map[string]string{"privileged": "true"}}
+	node := graph.Node{Kind: graph.KindNode, Name: "ip-10-0-1-5"}
+	role := graph.Node{Kind: graph.KindIAMRole, Name: "NodeRole", Attrs: map[string]string{"admin": "true"}}
+
+	g := graph.New()
+	g.AddEdge(pod, node, "privileged")
+	g.AddEdge(node, role, "instance-profile")
+
+	paths := FindAttackPaths(g, 0)
+	if len(paths) != 2 {
+		t.Fatalf("expected paths to the node and its role, got %d", len(paths))
+	}
+	p := paths[0]
+	if p.Target.ID() != "iam-role/NodeRole" {
+		t.Fatalf("expected node role to rank first, got %s", p.Target.ID())
+	}
+	if got := hopReason(g, p.Path[0]); got != "Pod ns/agent can escape to Node ip-10-0-1-5 through privileged" {
+		t.Errorf("unexpected hop reason %q", got)
+	}
+	if got := hopReason(g, p.Path[1]); got != "Node ip-10-0-1-5 exposes IAM role NodeRole to its pods through instance metadata" {
+		t.Errorf("unexpected hop reason %q", got)
+	}
+}
+
+func TestAddNodeIAMRoles_MissingInstance(t *testing.T) {
+	var describeCalls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		w.Header().Set("Content-Type", "text/xml")
+		switch r.Form.Get("Action") {
+		case "DescribeInstances":
+			describeCalls++
+			var ids []string
+			for i := 1; r.Form.Get(fmt.Sprintf("InstanceId.%d", i)) != ""; i++ {
+				ids = append(ids, r.Form.Get(fmt.Sprintf("InstanceId.%d", i)))
+			}
+			var items strings.Builder
+			for _, id := range ids {
+				if id == "i-gone" {
+					w.WriteHeader(http.StatusBadRequest)
+					fmt.Fprint(w, "<Response><Errors><Error><Code>InvalidInstanceID.NotFound</Code><Message>The instance ID 'i-gone' does not exist</Message></Error></Errors><RequestID>1</RequestID></Response>")
+					return
+				}
+				fmt.Fprintf(&items, "<item><instanceId>%s</instanceId><iamInstanceProfile><arn>arn:aws:iam::123:instance-profile/nodes</arn></iamInstanceProfile></item>", id)
+			}
+			fmt.Fprintf(w, "<DescribeInstancesResponse><reservationSet><item><instancesSet>%s</instancesSet></item></reservationSet></DescribeInstancesResponse>", items.String())
+		case "GetInstanceProfile":
+			fmt.Fprint(w, "<GetInstanceProfileResponse><GetInstanceProfileResult><InstanceProfile><Roles><member><RoleName>NodeRole</RoleName><Arn>arn:aws:iam::123:role/NodeRole</Arn></member></Roles></InstanceProfile></GetInstanceProfileResult></GetInstanceProfileResponse>")
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	loadAWSConfig := LoadAWSConfig
+	LoadAWSConfig = func(context.Context) (aws.Config, error) {
+		return aws.Config{
+			Region:       "us-east-1",
+			BaseEndpoint: aws.String(server.URL),
+			Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+				return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}, nil
+			}),
+		}, nil
+	}
+	t.Cleanup(func() { LoadAWSConfig = loadAWSConfig })
+
+	g := graph.New()
+	live := g.AddNode(graph.Node{Kind: graph.KindNode, Name: "live", Attrs: map[string]string{"instance-id": "i-live"}})
+	g.AddNode(graph.Node{Kind: graph.KindNode, Name: "gone", Attrs: map[string]string{"instance-id": "i-gone"}})
+	addNodeIAMRoles(g)
+
+	out := g.Out(live.ID())
+	if len(out) != 1 || out[0].To != "iam-role/NodeRole" || out[0].Label != "instance-profile" {
+		t.Errorf("live node edges = %v; want instance-profile to iam-role/NodeRole", out)
+	}
+	if describeCalls != 3 {
+		t.Errorf("DescribeInstances called %d times; want the batch and one retry per instance", describeCalls)
+	}
+}
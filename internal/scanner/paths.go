@@ -0,0 +1,324 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/khaugen7/eks-security-scanner/internal/graph"
+	"github.com/khaugen7/eks-security-scanner/internal/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// PathOptions controls the attack path search.
+type PathOptions struct {
+	// MaxDepth limits the number of hops in a path. Zero means no limit.
+	MaxDepth int
+
+	// Limit caps the number of paths printed. Zero prints all of them.
+	Limit int
+
+	// SkipIAM disables the AWS lookups that identify admin-equivalent IAM
+	// roles. Every IAM role is then treated as a lower-value target.
+	SkipIAM bool
+}
+
+// attackHop describes how an attacker moves along edges with a given label.
+// Reverse hops are followed against the edge direction. Reason is a format
+// string taking the display names of the hop's start and end nodes.
+type attackHop struct {
+	Reverse bool
+	Reason  string
+}
+
+var attackHops = map[string]attackHop{
+	"backend":          {Reason: "Ingress %s routes external traffic to Service %s"},
+	"attaches":         {Reason: "Gateway %s accepts traffic for Route %s"},
+	"backend-ref":      {Reason: "Route %s forwards traffic to Service %s"},
+	"matches":          {Reverse: true, Reason: "Service %s forwards traffic to its selected Pod %s"},
+	"routes-to":        {Reason: "Service %s routes traffic to its endpoint %s"},
+	"uses":             {Reason: "Pod %s runs as ServiceAccount %s and can read its token"},
+	"assumes":          {Reason: "ServiceAccount %s can assume IAM role %s through IRSA"},
+	"web-identity":     {Reason: "Pod %s can assume IAM role %s with its injected web identity token"},
+	"bound-to":         {Reason: "%s is bound to role %s"},
+	"instance-profile": {Reason: "Node %s exposes IAM role %s to its pods through instance metadata"},
+	"volume":           {Reason: "Pod %s mounts %s as a volume"},
+	"projected":        {Reason: "Pod %s mounts %s in a projected volume"},
+	"envFrom":          {Reason: "Pod %s loads %s into its environment"},
+	"secretKeyRef":     {Reason: "Pod %s reads a key of Secret %s into its environment"},
+}
+
+// RBAC capability edges are named after the capability they grant.
+func init() {
+	for _, c := range rbacCapabilities {
+		attackHops[c.Name] = attackHop{Reason: "Role %s grants " + c.Name + " on %s"}
+	}
+}
+
+// lookupHop returns how an attacker uses edges with label. Pod → node escape
+// edges are labelled with the vector, e.g. hostPath:/var/run/docker.sock.
+func lookupHop(label string) (attackHop, bool) {
+	if isEscapeLabel(label) {
+		return attackHop{Reason: "Pod %s can escape to Node %s through " + label}, true
+	}
+	hop, ok := attackHops[label]
+	return hop, ok
+}
+
+// attackFollow only lets the search take edges an attacker can move along.
+func attackFollow(e graph.Edge, reverse bool) bool {
+	hop, ok := lookupHop(e.Label)
+	return ok && hop.Reverse == reverse
+}
+
+// attackFollower extends attackFollow with what the graph knows about
+// NetworkPolicies: traffic from entry points that forward it from outside the
+// pod network does not enter pods whose policies block internet sources.
+// Other entry points proxy through in-cluster pods, which the policies are
+// not evaluated for, so their hops are kept.
+func attackFollower(g *graph.Graph, entry *graph.Node) graph.Follow {
+	external := forwardsExternalTraffic(entry)
+	return func(e graph.Edge, reverse bool) bool {
+		if !attackFollow(e, reverse) {
+			return false
+		}
+		if !external {
+			return true
+		}
+		switch e.Label {
+		case "matches":
+			pod, _ := g.Node(e.From)
+			return pod.Attr("external-ingress") != "denied"
+		case "routes-to":
+			pod, _ := g.Node(e.To)
+			return pod.Attr("external-ingress") != "denied"
+		}
+		return true
+	}
+}
+
+// forwardsExternalTraffic reports whether an entry point delivers traffic to
+// pods from outside the pod network: load balancers and node ports, and ALB
+// Ingresses. Other Ingress controllers and Gateways forward it from their own
+// pods, e.g. an ingress-nginx controller that policies commonly admit.
+func forwardsExternalTraffic(entry *graph.Node) bool {
+	switch entry.Kind {
+	case graph.KindService:
+		t := entry.Attr("type")
+		return t == string(corev1.ServiceTypeLoadBalancer) || t == string(corev1.ServiceTypeNodePort)
+	case graph.KindIngress:
+		return entry.Attr("class") == "alb"
+	}
+	return false
+}
+
+// AttackPath is a ranked route from an exposed entry point to a target.
+type AttackPath struct {
+	Entry       *graph.Node
+	EntryReason string
+	Target      *graph.Node
+	TargetValue string
+	Path        graph.Path
+	Score       int
+	Severity    string
+}
+
+// classifyEntryPoint reports whether n is somewhere an attacker can start:
+// workloads reachable from outside the cluster or already holding host
+// privileges. The weight ranks how exposed it is; internal load balancers
+// only need a foothold in the VPC.
+func classifyEntryPoint(n *graph.Node) (string, int, bool) {
+	switch n.Kind {
+	case graph.KindService:
+		switch n.Attr("type") {
+		case "LoadBalancer":
+			if n.Attr("scheme") == schemeInternal {
+				return "internal LoadBalancer Service", 1, true
+			}
+			return "LoadBalancer Service", 3, true
+		case "NodePort":
+			return "NodePort Service", 2, true
+		}
+	case graph.KindIngress:
+		if n.Attr("scheme") == schemeInternal {
+			return "internal Ingress", 1, true
+		}
+		return "Ingress", 3, true
+	case graph.KindGateway:
+		return "Gateway", 3, true
+	case graph.KindPod:
+		if n.Attr("privileged") == "true" {
+			return "privileged Pod", 1, true
+		}
+	}
+	return "", 0, false
+}
+
+// classifyTarget reports whether n is a crown jewel and how valuable it is.
+func classifyTarget(n *graph.Node) (string, int, bool) {
+	switch n.Kind {
+	case graph.KindServiceAccount:
+		if n.Attr("cluster-admin") != "" {
+			return "ServiceAccount bound to cluster-admin", 10, true
+		}
+		if n.Attr("namespace-admin") != "" {
+			return "ServiceAccount with namespace admin", 6, true
+		}
+	case graph.KindIAMRole:
+		if n.Attr("admin") == "true" {
+			return "admin-equivalent IAM role", 10, true
+		}
+		return "IAM role", 5, true
+	case graph.KindNode:
+		return "Node", 8, true
+	case graph.KindSecret:
+		return "Secret", 5, true
+	case graph.KindResource:
+		return n.Attr("capability"), n.Risk, true
+	}
+	return "", 0, false
+}
+
+// FindAttackPaths returns the shortest path from every entry point to every
+// reachable target, highest score first. Scores favour valuable targets,
+// exposed entry points and short paths.
+func FindAttackPaths(g *graph.Graph, maxDepth int) []AttackPath {
+	var paths []AttackPath
+	for _, entry := range g.Nodes() {
+		entryReason, entryWeight, ok := classifyEntryPoint(entry)
+		if !ok {
+			continue
+		}
+
+		shortest := g.ShortestPaths(entry.ID(), maxDepth, attackFollower(g, entry))
+		for _, id := range sortedKeys(shortest) {
+			target, _ := g.Node(id)
+			value, weight, ok := classifyTarget(target)
+			if !ok || id == entry.ID() {
+				continue
+			}
+			p := shortest[id]
+			paths = append(paths, AttackPath{
+				Entry:       entry,
+				EntryReason: entryReason,
+				Target:      target,
+				TargetValue: value,
+				Path:        p,
+				Score:       entryWeight + weight - len(p),
+				Severity:    targetSeverity(weight),
+			})
+		}
+	}
+
+	sort.SliceStable(paths, func(i, j int) bool {
+		if paths[i].Score != paths[j].Score {
+			return paths[i].Score > paths[j].Score
+		}
+		return len(paths[i].Path) < len(paths[j].Path)
+	})
+	return paths
+}
+
+func targetSeverity(weight int) string {
+	switch {
+	case weight >= 10:
+		return "CRITICAL"
+	case weight >= 8:
+		return "HIGH"
+	default:
+		return "MED"
+	}
+}
+
+// hopReason explains why the attacker can take a hop.
+func hopReason(g *graph.Graph, h graph.Hop) string {
+	from, _ := g.Node(h.From())
+	to, _ := g.Node(h.To())
+	hop, ok := lookupHop(h.Edge.Label)
+	if !ok {
+		return h.Edge.Label
+	}
+	return fmt.Sprintf(hop.Reason, from.DisplayName(), to.DisplayName())
+}
+
+// PathsResources are the cluster resources RunPathsCheck reads.
+var PathsResources = GraphResources
+
+func RunPathsCheck(namespace string, opts PathOptions, client kubernetes.Interface) {
+	utils.PrintScannerHeader("Attack Paths")
+
+	g := BuildThreatGraph(namespace, client)
+	if !opts.SkipIAM {
+		addNodeIAMRoles(g)
+		annotateAdminRoles(g)
+	}
+
+	var entries, targets int
+	for _, n := range g.Nodes() {
+		if _, _, ok := classifyEntryPoint(n); ok {
+			entries++
+		}
+		if _, _, ok := classifyTarget(n); ok {
+			targets++
+		}
+	}
+
+	fmt.Printf("\n[+] Searching for attack paths from %d entry points to %d targets...\n", entries, targets)
+
+	paths := FindAttackPaths(g, opts.MaxDepth)
+	shown := paths
+	if opts.Limit > 0 && len(shown) > opts.Limit {
+		shown = shown[:opts.Limit]
+	}
+
+	for i, p := range shown {
+		fmt.Printf("\n[%s] Path %d (score %d): %s %s → %s %s\n",
+			p.Severity, i+1, p.Score, p.EntryReason, p.Entry.DisplayName(), p.TargetValue, p.Target.DisplayName())
+		fmt.Printf("    %s\n", formatNode(p.Entry))
+		for _, h := range p.Path {
+			to, _ := g.Node(h.To())
+			fmt.Printf("      └─ %s\n", hopReason(g, h))
+			fmt.Printf("    %s\n", formatNode(to))
+		}
+	}
+	if len(shown) < len(paths) {
+		fmt.Printf("\n[i] %d more paths not shown, raise --limit to see them\n", len(paths)-len(shown))
+	}
+
+	fmt.Printf("\n[✓] Attack Path Summary\n")
+	fmt.Printf("    Namespace Scanned       : %s\n", namespace)
+	fmt.Printf("    Entry Points            : %d\n", entries)
+	fmt.Printf("    Targets                 : %d\n", targets)
+	fmt.Printf("    Attack Paths Found      : %d\n", len(paths))
+}
+
+// annotateAdminRoles marks IAM role nodes whose policies are admin-equivalent.
+// Lookups stop at the first error since it is usually missing credentials.
+func annotateAdminRoles(g *graph.Graph) {
+	roles := g.NodesOfKind(graph.KindIAMRole)
+	if len(roles) == 0 {
+		return
+	}
+
+	cfg, err := LoadAWSConfig(context.TODO())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[!] Skipping IAM policy analysis: unable to load AWS config: %v\n", err)
+		return
+	}
+	client := iam.NewFromConfig(cfg)
+
+	for _, role := range roles {
+		admin, err := roleIsAdminEquivalent(client, role.Name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[!] Skipping IAM policy analysis: role %s: %v\n", role.Name, err)
+			return
+		}
+		if admin {
+			role.Attrs["admin"] = "true"
+			role.Risk = 10
+		}
+	}
+}
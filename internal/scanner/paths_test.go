@@ -0,0 +1,319 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/khaugen7/eks-security-scanner/internal/graph"
+	"github.com/khaugen7/eks-security-scanner/internal/testhelpers"
+)
+
+func TestFindAttackPaths(t *testing.T) {
+	lb := graph.Node{Kind: graph.KindService, Namespace: "ns", Name: "web", Attrs: map[string]string{"type": "LoadBalancer"}}
+	internal := graph.Node{Kind: graph.KindService, Namespace: "ns", Name: "db", Attrs: map[string]string{"type": "ClusterIP"}}
+	web := graph.Node{Kind: graph.KindPod, Namespace: "ns", Name: "web-1"}
+	db := graph.Node{Kind: graph.KindPod, Namespace: "ns", Name: "db-1"}
+	webSA := graph.Node{Kind: graph.KindServiceAccount, Namespace: "ns", Name: "web"}
+	dbSA := graph.Node{Kind: graph.KindServiceAccount, Namespace: "ns", Name: "db", Attrs: map[string]string{"cluster-admin": "crb"}}
+	admin := graph.Node{Kind: graph.KindIAMRole, Name: "Admin", Attrs: map[string]string{"admin": "true"}}
+	reader := graph.Node{Kind: graph.KindIAMRole, Name: "Reader"}
+
+	g := graph.New()
+	g.AddEdge(web, lb, "matches")
+	g.AddEdge(db, internal, "matches")
+	g.AddEdge(web, webSA, "uses")
+	g.AddEdge(db, dbSA, "uses")
+	g.AddEdge(webSA, admin, "assumes")
+	g.AddEdge(dbSA, reader, "assumes")
+
+	paths := FindAttackPaths(g, 0)
+	if len(paths) != 1 {
+		t.Fatalf("expected 1 path from the LoadBalancer, got %d: %+v", len(paths), paths)
+	}
+	p := paths[0]
+	if p.Entry.ID() != "svc/ns/web" || p.Target.ID() != "iam-role/Admin" {
+		t.Errorf("unexpected path %s → %s", p.Entry.ID(), p.Target.ID())
+	}
+	if p.Severity != "CRITICAL" || p.Score != 3+10-3 {
+		t.Errorf("unexpected severity/score: %s %d", p.Severity, p.Score)
+	}
+	if !p.Path[0].Reverse {
+		t.Error("expected first hop to follow the matches edge backwards")
+	}
+	if got := hopReason(g, p.Path[0]); got != "Service ns/web forwards traffic to its selected Pod ns/web-1" {
+		t.Errorf("unexpected hop reason %q", got)
+	}
+
+	// Limiting depth drops the path
+	if paths := FindAttackPaths(g, 2); len(paths) != 0 {
+		t.Errorf("expected no paths within 2 hops, got %d", len(paths))
+	}
+}
+
+func TestFindAttackPaths_Ranking(t *testing.T) {
+	privileged := graph.Node{Kind: graph.KindPod, Namespace: "ns", Name: "agent", Attrs: map[string]string{"privileged": "true"}}
+	sa := graph.Node{Kind: graph.KindServiceAccount, Namespace: "ns", Name: "agent", Attrs: map[string]string{"cluster-admin": "crb"}}
+	role := graph.Node{Kind: graph.KindIAMRole, Name: "Reader"}
+
+	g := graph.New()
+	g.AddEdge(privileged, sa, "uses")
+	g.AddEdge(sa, role, "assumes")
+
+	paths := FindAttackPaths(g, 0)
+	if len(paths) != 2 {
+		t.Fatalf("expected 2 paths, got %d", len(paths))
+	}
+	if paths[0].Target.ID() != "sa/ns/agent" || paths[1].Target.ID() != "iam-role/Reader" {
+		t.Errorf("paths not ranked by score: %s, %s", paths[0].Target.ID(), paths[1].Target.ID())
+	}
+	if paths[1].Severity != "MED" {
+		t.Errorf("expected unverified IAM role to be MED, got %s", paths[1].Severity)
+	}
+}
+
+func TestRunPathsCheck(t *testing.T) {
+	pathType := networkingv1.PathTypePrefix
+	ing := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "public", Namespace: "ns1"},
+		Spec: networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{{
+			IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+				Paths: []networkingv1.HTTPIngressPath{{
+					Path:     "/",
+					PathType: &pathType,
+					Backend:  networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{Name: "api"}},
+				}},
+			}},
+		}}},
+	}
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "ns1"},
+		Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "api"}},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-1", Namespace: "ns1", Labels: map[string]string{"app": "api"}},
+		Spec:       corev1.PodSpec{ServiceAccountName: "api"},
+	}
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "ns1"}}
+	crb := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-admin"},
+		Subjects:   []rbacv1.Subject{{Kind: "ServiceAccount", Name: "api", Namespace: "ns1"}},
+		RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+	}
+
+	var client kubernetes.Interface = fake.NewSimpleClientset(ing, svc, pod, sa, crb)
+	out := testhelpers.CaptureOutput(func() {
+		RunPathsCheck("ns1", PathOptions{SkipIAM: true}, client)
+	})
+
+	if !strings.Contains(out, "[CRITICAL] Path 1 (score 10): Ingress ns1/public → ServiceAccount bound to cluster-admin ns1/api") {
+		t.Errorf("missing ranked path header, got:\n%s", out)
+	}
+	for _, hop := range []string{
+		"└─ Ingress ns1/public routes external traffic to Service ns1/api",
+		"└─ Service ns1/api forwards traffic to its selected Pod ns1/api-1",
+		"└─ Pod ns1/api-1 runs as ServiceAccount ns1/api and can read its token",
+	} {
+		if !strings.Contains(out, hop) {
+			t.Errorf("missing hop %q", hop)
+		}
+	}
+	if !strings.Contains(out, "Attack Paths Found      : 1") {
+		t.Error("wrong path count in summary")
+	}
+}
+
+func TestFindAttackPaths_RBAC(t *testing.T) {
+	lb := graph.Node{Kind: graph.KindService, Namespace: "ns", Name: "web", Attrs: map[string]string{"type": "LoadBalancer"}}
+	pod := graph.Node{Kind: graph.KindPod, Namespace: "ns", Name: "web-1"}
+	sa := graph.Node{Kind: graph.KindServiceAccount, Namespace: "ns", Name: "web"}
+	role := graph.Node{Kind: graph.KindRole, Namespace: "ns", Name: "reader"}
+	secrets := newResourceNode("ns", grantedCapability{rbacCapability: rbacCapability{Name: "can-read-secrets", Severity: "HIGH", Resource: "secrets"}})
+
+	g := graph.New()
+	g.AddEdge(pod, lb, "matches")
+	g.AddEdge(pod, sa, "uses")
+	g.AddEdge(sa, role, "bound-to")
+	g.AddEdge(role, secrets, "can-read-secrets")
+
+	paths := FindAttackPaths(g, 0)
+	if len(paths) != 1 {
+		t.Fatalf("expected 1 path, got %d", len(paths))
+	}
+	p := paths[0]
+	if p.Target.ID() != "resource/ns/secrets" || p.TargetValue != "can-read-secrets" || p.Severity != "HIGH" {
+		t.Errorf("unexpected path to %s (%s, %s)", p.Target.ID(), p.TargetValue, p.Severity)
+	}
+	if got := hopReason(g, p.Path[3]); got != "Role ns/reader grants can-read-secrets on ns/secrets" {
+		t.Errorf("unexpected hop reason %q", got)
+	}
+}
+
+func TestClassifyEntryPoint_Scheme(t *testing.T) {
+	tests := []struct {
+		node   graph.Node
+		reason string
+		weight int
+	}{
+		{graph.Node{Kind: graph.KindService, Attrs: map[string]string{"type": "LoadBalancer", "scheme": "internet-facing"}}, "LoadBalancer Service", 3},
+		{graph.Node{Kind: graph.KindService, Attrs: map[string]string{"type": "LoadBalancer", "scheme": "internal"}}, "internal LoadBalancer Service", 1},
+		{graph.Node{Kind: graph.KindIngress, Attrs: map[string]string{"scheme": "internal"}}, "internal Ingress", 1},
+		{graph.Node{Kind: graph.KindGateway}, "Gateway", 3},
+	}
+	for _, tt := range tests {
+		reason, weight, ok := classifyEntryPoint(&tt.node)
+		if !ok || reason != tt.reason || weight != tt.weight {
+			t.Errorf("classifyEntryPoint(%v) = %q, %d, %v; want %q, %d", tt.node.Attrs, reason, weight, ok, tt.reason, tt.weight)
+		}
+	}
+}
+
+func TestFindAttackPaths_MountedSecret(t *testing.T) {
+	lb := graph.Node{Kind: graph.KindService, Namespace: "ns", Name: "web", Attrs: map[string]string{"type": "LoadBalancer"}}
+	pod := graph.Node{Kind: graph.KindPod, Namespace: "ns", Name: "web-1"}
+	secret := graph.Node{Kind: graph.KindSecret, Namespace: "ns", Name: "db-creds"}
+	regcred := graph.Node{Kind: graph.KindSecret, Namespace: "ns", Name: "regcred"}
+
+	g := graph.New()
+	g.AddEdge(pod, lb, "matches")
+	g.AddEdge(pod, secret, "envFrom")
+	g.AddEdge(pod, regcred, "imagePullSecret")
+
+	paths := FindAttackPaths(g, 0)
+	if len(paths) != 1 {
+		t.Fatalf("expected only the envFrom secret to be reachable, got %d paths", len(paths))
+	}
+	if got := hopReason(g, paths[0].Path[1]); got != "Pod ns/web-1 loads ns/db-creds into its environment" {
+		t.Errorf("unexpected hop reason %q", got)
+	}
+}
+
+func TestFindAttackPaths_EndpointSlice(t *testing.T) {
+	lb := graph.Node{Kind: graph.KindService, Namespace: "ns", Name: "web", Attrs: map[string]string{"type": "LoadBalancer"}}
+	pod := graph.Node{Kind: graph.KindPod, Namespace: "ns", Name: "web-1"}
+	sa := graph.Node{Kind: graph.KindServiceAccount, Namespace: "ns", Name: "web", Attrs: map[string]string{"cluster-admin": "crb"}}
+
+	g := graph.New()
+	g.AddEdge(lb, pod, "routes-to")
+	g.AddEdge(pod, sa, "uses")
+
+	paths := FindAttackPaths(g, 0)
+	if len(paths) != 1 {
+		t.Fatalf("expected 1 path, got %d", len(paths))
+	}
+	if got := hopReason(g, paths[0].Path[0]); got != "Service ns/web routes traffic to its endpoint ns/web-1" {
+		t.Errorf("unexpected hop reason %q", got)
+	}
+
+	// Pods that deny traffic from outside the pod network are not entered
+	g.AddNode(graph.Node{Kind: graph.KindPod, Namespace: "ns", Name: "web-1", Attrs: map[string]string{"external-ingress": "denied"}})
+	if paths := FindAttackPaths(g, 0); len(paths) != 0 {
+		t.Errorf("expected no paths, got %d", len(paths))
+	}
+}
+
+func TestFindAttackPaths_IngressPruning(t *testing.T) {
+	pod := graph.Node{Kind: graph.KindPod, Namespace: "ns", Name: "web-1", Attrs: map[string]string{"external-ingress": "denied"}}
+	svc := graph.Node{Kind: graph.KindService, Namespace: "ns", Name: "web", Attrs: map[string]string{"type": "ClusterIP"}}
+	sa := graph.Node{Kind: graph.KindServiceAccount, Namespace: "ns", Name: "web", Attrs: map[string]string{"cluster-admin": "crb"}}
+
+	tests := []struct {
+		class string
+		want  int
+	}{
+		// ingress-nginx proxies from its own pod, which the policy may admit
+		{"nginx", 1},
+		// an ALB sends traffic from outside the pod network
+		{"alb", 0},
+	}
+	for _, tt := range tests {
+		ing := graph.Node{Kind: graph.KindIngress, Namespace: "ns", Name: "web", Attrs: map[string]string{"class": tt.class}}
+		g := graph.New()
+		g.AddEdge(graph.Internet, ing, "exposes")
+		g.AddEdge(ing, svc, "backend")
+		g.AddEdge(svc, pod, "routes-to")
+		g.AddEdge(pod, sa, "uses")
+
+		if paths := FindAttackPaths(g, 0); len(paths) != tt.want {
+			t.Errorf("%s Ingress: got %d paths, want %d", tt.class, len(paths), tt.want)
+		}
+	}
+}
+
+func TestFindAttackPaths_TokenNotAutomounted(t *testing.T) {
+	automount := false
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "ns1"},
+		Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeNodePort, Selector: map[string]string{"app": "web"}},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "ns1", Labels: map[string]string{"app": "web"}},
+		Spec:       corev1.PodSpec{ServiceAccountName: "web", AutomountServiceAccountToken: &automount},
+	}
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{
+		Name:        "web",
+		Namespace:   "ns1",
+		Annotations: map[string]string{"eks.amazonaws.com/role-arn": "arn:aws:iam::123:role/Web"},
+	}}
+	crb := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-admin"},
+		RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+		Subjects:   []rbacv1.Subject{{Kind: "ServiceAccount", Name: "web", Namespace: "ns1"}},
+	}
+
+	targets := func(g *graph.Graph) map[string]AttackPath {
+		found := make(map[string]AttackPath)
+		for _, p := range FindAttackPaths(g, 0) {
+			found[p.Target.ID()] = p
+		}
+		return found
+	}
+
+	// The web identity token is injected whatever the automount setting, so
+	// the IAM role stays reachable but the ServiceAccount's RBAC does not.
+	g := BuildThreatGraph("ns1", fake.NewSimpleClientset(svc, pod, sa, crb))
+	found := targets(g)
+	role, ok := found["iam-role/Web"]
+	if !ok {
+		t.Fatalf("expected a path to the IAM role without the token, got %v", sortedKeys(found))
+	}
+	if last := role.Path[len(role.Path)-1]; last.Edge.Label != "web-identity" {
+		t.Errorf("last hop = %s; want web-identity", last.Edge.Label)
+	}
+	if _, ok := found["sa/ns1/web"]; ok {
+		t.Error("unexpected path to the ServiceAccount through a pod without the token")
+	}
+
+	// Tokens are mounted by default
+	pod.Spec.AutomountServiceAccountToken = nil
+	g = BuildThreatGraph("ns1", fake.NewSimpleClientset(svc, pod, sa, crb))
+	if _, ok := targets(g)["sa/ns1/web"]; !ok {
+		t.Error("expected a path to the ServiceAccount once the token is mounted")
+	}
+}
+
+func TestWebIdentityRoleARN(t *testing.T) {
+	projected := corev1.PodSpec{
+		Volumes: []corev1.Volume{{Name: "aws-iam-token", VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{
+			Sources: []corev1.VolumeProjection{{ServiceAccountToken: &corev1.ServiceAccountTokenProjection{Audience: "sts.amazonaws.com", Path: "token"}}},
+		}}}},
+		Containers: []corev1.Container{{Name: "app", Env: []corev1.EnvVar{{Name: "AWS_ROLE_ARN", Value: "arn:aws:iam::123:role/App"}}}},
+	}
+	irsa := corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"eks.amazonaws.com/role-arn": "arn:aws:iam::123:role/Web"}}}
+
+	if got := webIdentityRoleARN(corev1.PodSpec{}, irsa); got != "arn:aws:iam::123:role/Web" {
+		t.Errorf("IRSA ServiceAccount: got %q", got)
+	}
+	if got := webIdentityRoleARN(projected, corev1.ServiceAccount{}); got != "arn:aws:iam::123:role/App" {
+		t.Errorf("projected token with AWS_ROLE_ARN: got %q", got)
+	}
+	if got := webIdentityRoleARN(corev1.PodSpec{Containers: projected.Containers}, corev1.ServiceAccount{}); got != "" {
+		t.Errorf("AWS_ROLE_ARN without a token: got %q", got)
+	}
+}
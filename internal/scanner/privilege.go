@@ -3,13 +3,55 @@ package scanner
 import (
 	"context"
 	"fmt"
+	"path"
+	"strings"
 
 	"github.com/khaugen7/eks-security-scanner/internal/utils"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func RunPrivilegeCheck(namespace string, client kubernetes.Interface) {
+// PrivilegeOptions tunes the privilege scanner for workloads that are expected
+// to need elevated access.
+type PrivilegeOptions struct {
+	// AllowedHostPaths lists hostPath volumes (including their subdirectories)
+	// that are expected in the cluster and should not be reported, e.g. /proc
+	// and /sys for node-exporter.
+	AllowedHostPaths []string
+
+	// AllowedRegistries restricts where container images may be pulled
+	// from. Entries are registry host globs or registry/repository prefixes.
+	// When empty, the registry check is skipped.
+	AllowedRegistries []string
+}
+
+// sensitiveHostPaths are host locations that hand a container the node's
+// credentials, its container runtime or its root filesystem. /var/run is a
+// symlink to /run on most distributions, so both spellings are listed.
+var sensitiveHostPaths = []struct {
+	Path   string
+	Reason string
+}{
+	{"/", "exposes the entire host filesystem"},
+	{"/etc", "exposes host configuration and credentials"},
+	{"/var/run/docker.sock", "grants full control of the container runtime"},
+	{"/run/docker.sock", "grants full control of the container runtime"},
+	{"/run/containerd/containerd.sock", "grants full control of the container runtime"},
+	{"/var/run/containerd/containerd.sock", "grants full control of the container runtime"},
+	{"/var/lib/kubelet", "exposes kubelet credentials and the volumes of every pod on the node"},
+	{"/proc", "exposes host processes and kernel interfaces"},
+	{"/root", "exposes the host root user's home directory and credentials"},
+}
+
+// logHostPaths are commonly mounted by log shippers. Read-only access to them
+// is reported at a lower severity.
+var logHostPaths = []string{"/var/log", "/var/lib/docker/containers"}
+
+// PrivilegeResources are the cluster resources RunPrivilegeCheck reads.
+var PrivilegeResources = []string{"pods"}
+
+func RunPrivilegeCheck(namespace string, opts PrivilegeOptions, client kubernetes.Interface) {
 	utils.PrintScannerHeader("Privileges Scanner")
 
 	pods, err := client.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{})
@@ -18,74 +60,202 @@ func RunPrivilegeCheck(namespace string, client kubernetes.Interface) {
 		return
 	}
 
-	var totalPods, highFindings, medFindings int
+	var totalPods, criticalFindings, highFindings, medFindings, lowFindings int
 
 	fmt.Printf("\n[+] Scanning Pods in namespace: %s for privilege issues...\n", namespace)
 
+	report := func(findings []RiskFinding) {
+		for _, f := range findings {
+			fmt.Println(f.Message)
+			switch f.Severity {
+			case "CRITICAL":
+				criticalFindings++
+			case "HIGH":
+				highFindings++
+			case "MED":
+				medFindings++
+			case "LOW":
+				lowFindings++
+			}
+		}
+	}
+	drift := newImageDriftTracker()
+
 	for _, pod := range pods.Items {
 		totalPods++
 		podID := fmt.Sprintf("%s/%s", pod.Namespace, pod.Name)
+		report(checkPodPrivileges(podID, pod, opts))
+		report(drift.checkPod(podID, pod))
+	}
+	report(drift.findings())
 
-		if pod.Spec.SecurityContext != nil {
-			if pod.Spec.SecurityContext.RunAsUser != nil && *pod.Spec.SecurityContext.RunAsUser == 0 {
-				fmt.Printf("[HIGH] Pod %s is running as root user\n", podID)
-				highFindings++
+	fmt.Printf("\n[✓] Privilege Check Summary\n")
+	fmt.Printf("    Namespace Scanned       : %s\n", namespace)
+	fmt.Printf("    Total Pods Scanned      : %d\n", totalPods)
+	fmt.Printf("    Critical Findings       : %d\n", criticalFindings)
+	fmt.Printf("    High Severity Findings  : %d\n", highFindings)
+	fmt.Printf("    Medium Severity Findings: %d\n", medFindings)
+	fmt.Printf("    Low Severity Findings   : %d\n", lowFindings)
+
+}
+
+// checkPodPrivileges reports the pod's security context, host namespaces,
+// hostPath volumes and container images.
+func checkPodPrivileges(podID string, pod corev1.Pod, opts PrivilegeOptions) []RiskFinding {
+	var findings []RiskFinding
+	object := resourceID("Pod", pod.Namespace, pod.Name)
+	add := func(field, severity, format string, args ...any) {
+		findings = append(findings, RiskFinding{Message: fmt.Sprintf(format, args...), Severity: severity, Object: object, Field: field})
+	}
+
+	if pod.Spec.SecurityContext != nil {
+		if pod.Spec.SecurityContext.RunAsUser != nil && *pod.Spec.SecurityContext.RunAsUser == 0 {
+			add("spec.securityContext.runAsUser", "HIGH", "[HIGH] Pod %s is running as root user", podID)
+		}
+		if pod.Spec.SecurityContext.RunAsNonRoot != nil && !*pod.Spec.SecurityContext.RunAsNonRoot {
+			add("spec.securityContext.runAsNonRoot", "MED", "[MED] Pod %s is not enforcing non-root execution", podID)
+		}
+	}
+
+	for _, c := range pod.Spec.Containers {
+		containerID := fmt.Sprintf("%s (%s)", podID, c.Name)
+		field := containerField(pod.Spec, c.Name) + ".securityContext"
+		sc := c.SecurityContext
+
+		if sc != nil {
+			if sc.Privileged != nil && *sc.Privileged {
+				add(field+".privileged", "HIGH", "[HIGH] Container %s is running as privileged: grants full access to host devices and kernel", containerID)
 			}
-			if pod.Spec.SecurityContext.RunAsNonRoot != nil && !*pod.Spec.SecurityContext.RunAsNonRoot {
-				fmt.Printf("[MED] Pod %s is not enforcing non-root execution\n", podID)
-				medFindings++
+			if sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+				add(field+".runAsUser", "HIGH", "[HIGH] Container %s is running as root", containerID)
+			}
+			if sc.AllowPrivilegeEscalation != nil && *sc.AllowPrivilegeEscalation {
+				add(field+".allowPrivilegeEscalation", "MED", "[MED] Container %s allows privilege escalation: users inside container can gain more privileges (e.g. sudo)", containerID)
+			}
+			if sc.Capabilities != nil && len(sc.Capabilities.Add) > 0 {
+				add(field+".capabilities.add", "MED", "[MED] Container %s adds Linux capabilities %v: expands kernel-level access beyond defaults", containerID, sc.Capabilities.Add)
 			}
 		}
+	}
 
-		for _, c := range pod.Spec.Containers {
-			containerID := fmt.Sprintf("%s (%s)", podID, c.Name)
-			sc := c.SecurityContext
-
-			if sc != nil {
-				if sc.Privileged != nil && *sc.Privileged {
-					fmt.Printf("[HIGH] Container %s is running as privileged: grants full access to host devices and kernel\n", containerID)
-					highFindings++
-				}
-				if sc.RunAsUser != nil && *sc.RunAsUser == 0 {
-					fmt.Printf("[HIGH] Container %s is running as root\n", containerID)
-					highFindings++
-				}
-				if sc.AllowPrivilegeEscalation != nil && *sc.AllowPrivilegeEscalation {
-					fmt.Printf("[MED] Container %s allows privilege escalation: users inside container can gain more privileges (e.g. sudo)\n", containerID)
-					medFindings++
-				}
-				if sc.Capabilities != nil && len(sc.Capabilities.Add) > 0 {
-					fmt.Printf("[MED] Container %s adds Linux capabilities %v: expands kernel-level access beyond defaults\n", containerID, sc.Capabilities.Add)
-					medFindings++
-				}
-			}
+	for _, c := range allContainers(pod.Spec) {
+		containerID := fmt.Sprintf("%s (%s)", podID, c.Name)
+		for _, f := range checkContainerImage(containerID, c, opts.AllowedRegistries) {
+			f.Object = object
+			f.Field = containerField(pod.Spec, c.Name) + "." + f.Field
+			findings = append(findings, f)
 		}
+	}
 
-		if pod.Spec.HostNetwork {
-			fmt.Printf("[HIGH] Pod %s uses hostNetwork: shares network stack with host, bypasses network isolation\n", podID)
-			highFindings++
+	if pod.Spec.HostNetwork {
+		add("spec.hostNetwork", "HIGH", "[HIGH] Pod %s uses hostNetwork: shares network stack with host, bypasses network isolation", podID)
+	}
+	if pod.Spec.HostPID {
+		add("spec.hostPID", "HIGH", "[HIGH] Pod %s uses hostPID: shares process space with host, can view/kill host processes", podID)
+	}
+	if pod.Spec.HostIPC {
+		add("spec.hostIPC", "MED", "[MED] Pod %s uses hostIPC: shares inter-process comm layer with host, can interfere with other pods", podID)
+	}
+
+	for _, v := range pod.Spec.Volumes {
+		if v.HostPath == nil {
+			continue
 		}
-		if pod.Spec.HostPID {
-			fmt.Printf("[HIGH] Pod %s uses hostPID: shares process space with host, can view/kill host processes\n", podID)
-			highFindings++
+		field := "spec.volumes[" + v.Name + "].hostPath.path"
+		readOnly := hostPathReadOnly(pod.Spec, v.Name)
+		severity, reason := classifyHostPath(v.HostPath.Path, readOnly, opts.AllowedHostPaths)
+		switch severity {
+		case "CRITICAL":
+			add(field, severity, "[CRITICAL] Pod %s mounts sensitive hostPath %s: %s", podID, v.HostPath.Path, reason)
+		case "HIGH":
+			add(field, severity, "[HIGH] Pod %s mounts hostPath %s: %s", podID, v.HostPath.Path, reason)
+		case "LOW":
+			add(field, severity, "[LOW] Pod %s mounts hostPath %s: %s", podID, v.HostPath.Path, reason)
 		}
-		if pod.Spec.HostIPC {
-			fmt.Printf("[MED] Pod %s uses hostIPC: shares inter-process comm layer with host, can interfere with other pods\n", podID)
-			medFindings++
+	}
+	return findings
+}
+
+// classifyHostPath returns the severity of mounting hostPath into a pod along
+// with the reason it is risky. Paths at, beneath or above a sensitive path are
+// CRITICAL. An empty severity means the path is allowlisted.
+func classifyHostPath(hostPath string, readOnly bool, allowed []string) (string, string) {
+	p := path.Clean(hostPath)
+
+	for _, a := range allowed {
+		if hostPathWithin(p, path.Clean(a)) {
+			return "", ""
 		}
+	}
 
-		for _, v := range pod.Spec.Volumes {
-			if v.HostPath != nil {
-				fmt.Printf("[HIGH] Pod %s mounts hostPath %s: exposes host filesystem to container\n", podID, v.HostPath.Path)
-				highFindings++
+	for _, s := range sensitiveHostPaths {
+		if s.Path == "/" {
+			if p == "/" {
+				return "CRITICAL", s.Reason
 			}
+			continue
+		}
+		if hostPathWithin(p, s.Path) {
+			return "CRITICAL", s.Reason
+		}
+		// Mounting a parent such as /var/run exposes the sensitive path too
+		if hostPathWithin(s.Path, p) {
+			return "CRITICAL", fmt.Sprintf("contains %s, which %s", s.Path, s.Reason)
 		}
 	}
 
-	fmt.Printf("\n[✓] Privilege Check Summary\n")
-	fmt.Printf("    Namespace Scanned       : %s\n", namespace)
-	fmt.Printf("    Total Pods Scanned      : %d\n", totalPods)
-	fmt.Printf("    High Severity Findings  : %d\n", highFindings)
-	fmt.Printf("    Medium Severity Findings: %d\n", medFindings)
+	if readOnly {
+		for _, l := range logHostPaths {
+			if hostPathWithin(p, l) {
+				return "LOW", "read-only access to host log files"
+			}
+		}
+	}
 
+	return "HIGH", "exposes host filesystem to container"
+}
+
+// hostPathWithin reports whether p is dir or a path beneath it.
+func hostPathWithin(p, dir string) bool {
+	if dir == "/" {
+		return true
+	}
+	return p == dir || strings.HasPrefix(p, dir+"/")
+}
+
+// hostPathReadOnly reports whether every container that mounts the named
+// volume does so read-only. Unmounted volumes are treated as writable.
+func hostPathReadOnly(spec corev1.PodSpec, volume string) bool {
+	mounted := false
+	for _, c := range allContainers(spec) {
+		for _, m := range c.VolumeMounts {
+			if m.Name != volume {
+				continue
+			}
+			if !m.ReadOnly {
+				return false
+			}
+			mounted = true
+		}
+	}
+	return mounted
+}
+
+// allContainers returns the pod's init containers followed by its regular
+// containers.
+func allContainers(spec corev1.PodSpec) []corev1.Container {
+	containers := make([]corev1.Container, 0, len(spec.InitContainers)+len(spec.Containers))
+	containers = append(containers, spec.InitContainers...)
+	return append(containers, spec.Containers...)
+}
+
+// containerField returns the path of the named container within a pod, as
+// used by RiskFinding.Field.
+func containerField(spec corev1.PodSpec, name string) string {
+	for _, c := range spec.InitContainers {
+		if c.Name == name {
+			return "spec.initContainers[" + name + "]"
+		}
+	}
+	return "spec.containers[" + name + "]"
 }
@@ -36,7 +36,7 @@ func TestRunPrivilegeCheck_AllFindings(t *testing.T) {
 			},
 			Containers: []corev1.Container{{
 				Name:  "ctr1",
-				Image: "img",
+				Image: "registry.example.com/img:1.0@sha256:abc",
 				SecurityContext: &corev1.SecurityContext{
 					Privileged:               &tru,
 					RunAsUser:                &zero,
@@ -50,7 +50,7 @@ func TestRunPrivilegeCheck_AllFindings(t *testing.T) {
 	var client kubernetes.Interface = fake.NewSimpleClientset(pod)
 
 	out := testhelpers.CaptureOutput(func() {
-		RunPrivilegeCheck("ns1", client)
+		RunPrivilegeCheck("ns1", PrivilegeOptions{}, client)
 	})
 
 	if !strings.Contains(out, "[HIGH] Pod ns1/pod1 is running as root user") {
@@ -95,3 +95,143 @@ func TestRunPrivilegeCheck_AllFindings(t *testing.T) {
 		t.Errorf("summary: expected 4 meds, got:\n%s", out)
 	}
 }
+
+func TestClassifyHostPath(t *testing.T) {
+	cases := []struct {
+		name     string
+		path     string
+		readOnly bool
+		allowed  []string
+		want     string
+	}{
+		{"host root", "/", false, nil, "CRITICAL"},
+		{"etc subdir", "/etc/kubernetes", true, nil, "CRITICAL"},
+		{"docker socket", "/var/run/docker.sock", false, nil, "CRITICAL"},
+		{"containerd socket", "/run/containerd/containerd.sock", false, nil, "CRITICAL"},
+		{"kubelet", "/var/lib/kubelet/pods", false, nil, "CRITICAL"},
+		{"proc trailing slash", "/proc/", true, nil, "CRITICAL"},
+		{"root home", "/root", false, nil, "CRITICAL"},
+		{"parent of docker socket", "/var/run", true, nil, "CRITICAL"},
+		{"parent of containerd socket", "/run", false, nil, "CRITICAL"},
+		{"parent of kubelet", "/var/lib", false, nil, "CRITICAL"},
+		{"var", "/var/", true, nil, "CRITICAL"},
+		{"similar prefix", "/etcd-data", false, nil, "HIGH"},
+		{"sibling of kubelet", "/var/lib/containerd", false, nil, "HIGH"},
+		{"log read-only", "/var/log/pods", true, nil, "LOW"},
+		{"log writable", "/var/log", false, nil, "HIGH"},
+		{"other", "/data", false, nil, "HIGH"},
+		{"allowlisted", "/proc", true, []string{"/proc", "/sys"}, ""},
+		{"allowlisted subdir", "/sys/fs/cgroup", false, []string{"/sys/"}, ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got, _ := classifyHostPath(c.path, c.readOnly, c.allowed); got != c.want {
+				t.Errorf("classifyHostPath(%q, %v, %v) = %q; want %q", c.path, c.readOnly, c.allowed, got, c.want)
+			}
+		})
+	}
+}
+
+func TestHostPathReadOnly(t *testing.T) {
+	spec := corev1.PodSpec{
+		InitContainers: []corev1.Container{{
+			Name:         "init",
+			VolumeMounts: []corev1.VolumeMount{{Name: "logs", MountPath: "/logs", ReadOnly: true}},
+		}},
+		Containers: []corev1.Container{
+			{Name: "a", VolumeMounts: []corev1.VolumeMount{{Name: "logs", MountPath: "/logs", ReadOnly: true}}},
+			{Name: "b", VolumeMounts: []corev1.VolumeMount{{Name: "data", MountPath: "/data", ReadOnly: true}, {Name: "data", MountPath: "/rw"}}},
+		},
+	}
+
+	if !hostPathReadOnly(spec, "logs") {
+		t.Error("expected logs volume to be read-only")
+	}
+	if hostPathReadOnly(spec, "data") {
+		t.Error("expected data volume to be writable when any mount is writable")
+	}
+	if hostPathReadOnly(spec, "unused") {
+		t.Error("expected unmounted volume to be treated as writable")
+	}
+}
+
+func TestRunPrivilegeCheck_HostPathSeverity(t *testing.T) {
+	hostPath := func(name, p string) corev1.Volume {
+		return corev1.Volume{Name: name, VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: p}}}
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "ns1"},
+		Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{
+				hostPath("sock", "/var/run/docker.sock"),
+				hostPath("logs", "/var/log"),
+				hostPath("sys", "/sys"),
+			},
+			Containers: []corev1.Container{{
+				Name:  "ctr",
+				Image: "registry.example.com/img:1.0@sha256:abc",
+				VolumeMounts: []corev1.VolumeMount{
+					{Name: "sock", MountPath: "/var/run/docker.sock"},
+					{Name: "logs", MountPath: "/host/logs", ReadOnly: true},
+					{Name: "sys", MountPath: "/host/sys", ReadOnly: true},
+				},
+			}},
+		},
+	}
+
+	var client kubernetes.Interface = fake.NewSimpleClientset(pod)
+	out := testhelpers.CaptureOutput(func() {
+		RunPrivilegeCheck("ns1", PrivilegeOptions{AllowedHostPaths: []string{"/sys"}}, client)
+	})
+
+	if !strings.Contains(out, "[CRITICAL] Pod ns1/agent mounts sensitive hostPath /var/run/docker.sock") {
+		t.Error("missing CRITICAL docker socket finding")
+	}
+	if !strings.Contains(out, "[LOW] Pod ns1/agent mounts hostPath /var/log") {
+		t.Error("missing LOW read-only log finding")
+	}
+	if strings.Contains(out, "/sys") {
+		t.Error("allowlisted hostPath /sys should not be reported")
+	}
+	if !strings.Contains(out, "Critical Findings       : 1") {
+		t.Errorf("summary: expected 1 critical, got:\n%s", out)
+	}
+	if !strings.Contains(out, "Low Severity Findings   : 1") {
+		t.Errorf("summary: expected 1 low, got:\n%s", out)
+	}
+}
+
+func TestCheckPodPrivileges_Fields(t *testing.T) {
+	tru := true
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "p", Namespace: "ns1"},
+		Spec: corev1.PodSpec{
+			HostPID: true,
+			Volumes: []corev1.Volume{
+				{Name: "sock", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/run/docker.sock"}}},
+			},
+			InitContainers: []corev1.Container{{Name: "setup", Image: "busybox"}},
+			Containers: []corev1.Container{{
+				Name:            "app",
+				Image:           "registry.example.com/img:1.0@sha256:abc",
+				SecurityContext: &corev1.SecurityContext{Privileged: &tru},
+			}},
+		},
+	}
+
+	var fields []string
+	for _, f := range checkPodPrivileges("ns1/p", pod, PrivilegeOptions{}) {
+		fields = append(fields, f.Field)
+	}
+	for _, want := range []string{
+		"spec.hostPID",
+		"spec.volumes[sock].hostPath.path",
+		"spec.containers[app].securityContext.privileged",
+		"spec.initContainers[setup].image",
+	} {
+		if !strings.Contains(strings.Join(fields, "\n"), want) {
+			t.Errorf("expected a finding at %s, got %v", want, fields)
+		}
+	}
+}
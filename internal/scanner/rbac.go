@@ -0,0 +1,298 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// rbacBinding is a RoleBinding or ClusterRoleBinding reduced to what the
+// scanners need to attribute permissions to a subject.
+type rbacBinding struct {
+	Kind      string
+	Namespace string
+	Name      string
+	RoleRef   rbacv1.RoleRef
+}
+
+func (b rbacBinding) String() string {
+	if b.Namespace == "" {
+		return fmt.Sprintf("%s %s → %s %s", b.Kind, b.Name, b.RoleRef.Kind, b.RoleRef.Name)
+	}
+	return fmt.Sprintf("%s %s/%s → %s %s", b.Kind, b.Namespace, b.Name, b.RoleRef.Kind, b.RoleRef.Name)
+}
+
+// rbacIndex maps subjects to the bindings that grant them permissions.
+type rbacIndex struct {
+	bySubject map[string][]rbacBinding
+
+	// subjects lists every bound subject once, in binding order, with
+	// ServiceAccount namespaces filled in.
+	subjects []rbacv1.Subject
+}
+
+// buildRBACIndex indexes the RoleBindings in namespace (all namespaces if
+// empty) and every ClusterRoleBinding.
+func buildRBACIndex(namespace string, client kubernetes.Interface) (*rbacIndex, error) {
+	idx := &rbacIndex{bySubject: make(map[string][]rbacBinding)}
+
+	rbs, err := client.RbacV1().RoleBindings(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list RoleBindings: %w", err)
+	}
+	for _, rb := range rbs.Items {
+		b := rbacBinding{Kind: "RoleBinding", Namespace: rb.Namespace, Name: rb.Name, RoleRef: rb.RoleRef}
+		for _, s := range rb.Subjects {
+			idx.add(s, rb.Namespace, b)
+		}
+	}
+
+	crbs, err := client.RbacV1().ClusterRoleBindings().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ClusterRoleBindings: %w", err)
+	}
+	for _, crb := range crbs.Items {
+		b := rbacBinding{Kind: "ClusterRoleBinding", Name: crb.Name, RoleRef: crb.RoleRef}
+		for _, s := range crb.Subjects {
+			idx.add(s, "", b)
+		}
+	}
+
+	return idx, nil
+}
+
+func (idx *rbacIndex) add(s rbacv1.Subject, bindingNamespace string, b rbacBinding) {
+	ns := s.Namespace
+	if s.Kind == rbacv1.ServiceAccountKind && ns == "" {
+		// A RoleBinding subject without a namespace refers to the binding's own.
+		ns = bindingNamespace
+	}
+	key := subjectKey(s.Kind, ns, s.Name)
+	if _, seen := idx.bySubject[key]; !seen {
+		s.Namespace = ns
+		idx.subjects = append(idx.subjects, s)
+	}
+	idx.bySubject[key] = append(idx.bySubject[key], b)
+}
+
+// subjectBindings returns the bindings that name s directly.
+func (idx *rbacIndex) subjectBindings(s rbacv1.Subject) []rbacBinding {
+	return idx.bySubject[subjectKey(s.Kind, s.Namespace, s.Name)]
+}
+
+func subjectKey(kind, namespace, name string) string {
+	if kind != rbacv1.ServiceAccountKind {
+		namespace = ""
+	}
+	return fmt.Sprintf("%s/%s/%s", kind, namespace, name)
+}
+
+// serviceAccountBindings returns the bindings that apply to a ServiceAccount,
+// either directly or through the system:serviceaccounts groups.
+func (idx *rbacIndex) serviceAccountBindings(namespace, name string) []rbacBinding {
+	var bindings []rbacBinding
+	bindings = append(bindings, idx.bySubject[subjectKey(rbacv1.ServiceAccountKind, namespace, name)]...)
+	bindings = append(bindings, idx.bySubject[subjectKey(rbacv1.GroupKind, "", "system:serviceaccounts")]...)
+	bindings = append(bindings, idx.bySubject[subjectKey(rbacv1.GroupKind, "", "system:serviceaccounts:"+namespace)]...)
+	return bindings
+}
+
+// grantedRule is a PolicyRule a subject holds through a binding. Namespace is
+// where the rule applies, or empty for cluster-wide grants.
+type grantedRule struct {
+	Rule      rbacv1.PolicyRule
+	Binding   rbacBinding
+	Namespace string
+}
+
+// Scope describes where the rule applies.
+func (r grantedRule) Scope() string {
+	if r.Namespace == "" {
+		return "cluster-wide"
+	}
+	return "in namespace " + r.Namespace
+}
+
+// resolveRules fetches the Role or ClusterRole behind each binding. Roles that
+// no longer exist are skipped, as the API server does.
+func resolveRules(bindings []rbacBinding, client kubernetes.Interface) []grantedRule {
+	var rules []grantedRule
+	for _, b := range bindings {
+		var policy []rbacv1.PolicyRule
+		switch b.RoleRef.Kind {
+		case "Role":
+			role, err := client.RbacV1().Roles(b.Namespace).Get(context.TODO(), b.RoleRef.Name, metav1.GetOptions{})
+			if err != nil {
+				continue
+			}
+			policy = role.Rules
+		case "ClusterRole":
+			role, err := client.RbacV1().ClusterRoles().Get(context.TODO(), b.RoleRef.Name, metav1.GetOptions{})
+			if err != nil {
+				continue
+			}
+			policy = role.Rules
+		}
+		for _, rule := range policy {
+			rules = append(rules, grantedRule{Rule: rule, Binding: b, Namespace: b.Namespace})
+		}
+	}
+	return rules
+}
+
+// ruleAllows reports whether rule grants verb on resource in the given API
+// group. resource may include a subresource, e.g. pods/exec, or end in /* to
+// match any subresource, e.g. userextras/*. ResourceNames are not checked.
+func ruleAllows(rule rbacv1.PolicyRule, verb, group, resource string) bool {
+	if !matchesAny(rule.Verbs, verb) || !matchesAny(rule.APIGroups, group) {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(resource, "/*"); ok {
+		for _, r := range rule.Resources {
+			if r == rbacv1.ResourceAll || strings.HasPrefix(r, prefix+"/") {
+				return true
+			}
+		}
+		return false
+	}
+	return matchesAny(rule.Resources, resource)
+}
+
+func matchesAny(values []string, want string) bool {
+	for _, v := range values {
+		if v == rbacv1.VerbAll || v == want {
+			return true
+		}
+	}
+	return false
+}
+
+// rbacCapability is a permission that lets a subject escalate or reach
+// sensitive data.
+type rbacCapability struct {
+	Name     string
+	Severity string
+	Group    string
+	Resource string
+	Verbs    []string
+}
+
+// grantedCapability is a capability a rule grants. ResourceNames is set when
+// the rule is limited to named objects.
+type grantedCapability struct {
+	rbacCapability
+	ResourceNames []string
+}
+
+// Target describes what the capability applies to when it is limited to
+// named objects, e.g. " on secrets app-config", and is empty otherwise.
+func (c grantedCapability) Target() string {
+	if len(c.ResourceNames) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" on %s %s", c.Resource, strings.Join(c.ResourceNames, ", "))
+}
+
+var rbacCapabilities = []rbacCapability{
+	{"can-do-anything", "CRITICAL", "*", "*", []string{"*"}},
+	{"can-read-secrets", "HIGH", "", "secrets", []string{"get", "list", "watch"}},
+	{"can-exec-pods", "HIGH", "", "pods/exec", []string{"create", "get"}},
+	{"can-create-pods", "HIGH", "", "pods", []string{"create"}},
+	{"can-create-workloads", "HIGH", "apps", "deployments", []string{"create", "update", "patch"}},
+	{"can-create-workloads", "HIGH", "apps", "daemonsets", []string{"create", "update", "patch"}},
+	{"can-create-workloads", "HIGH", "apps", "statefulsets", []string{"create", "update", "patch"}},
+	{"can-create-workloads", "HIGH", "apps", "replicasets", []string{"create", "update", "patch"}},
+	{"can-create-workloads", "HIGH", "", "replicationcontrollers", []string{"create", "update", "patch"}},
+	{"can-create-workloads", "HIGH", "batch", "jobs", []string{"create", "update", "patch"}},
+	{"can-create-workloads", "HIGH", "batch", "cronjobs", []string{"create", "update", "patch"}},
+	{"can-create-tokens", "HIGH", "", "serviceaccounts/token", []string{"create"}},
+	{"can-impersonate", "CRITICAL", "", "users", []string{"impersonate"}},
+	{"can-impersonate", "CRITICAL", "", "groups", []string{"impersonate"}},
+	{"can-impersonate", "CRITICAL", "", "serviceaccounts", []string{"impersonate"}},
+	{"can-impersonate", "CRITICAL", "authentication.k8s.io", "uids", []string{"impersonate"}},
+	{"can-impersonate", "CRITICAL", "authentication.k8s.io", "userextras/*", []string{"impersonate"}},
+	{"can-escalate-roles", "CRITICAL", "rbac.authorization.k8s.io", "clusterroles", []string{"escalate", "bind"}},
+	{"can-escalate-roles", "CRITICAL", "rbac.authorization.k8s.io", "roles", []string{"escalate", "bind"}},
+	{"can-modify-bindings", "HIGH", "rbac.authorization.k8s.io", "rolebindings", []string{"create", "update", "patch"}},
+	{"can-modify-bindings", "CRITICAL", "rbac.authorization.k8s.io", "clusterrolebindings", []string{"create", "update", "patch"}},
+	{"can-modify-nodes", "HIGH", "", "nodes", []string{"update", "patch", "delete"}},
+}
+
+// ruleCapabilities returns the distinct capabilities a rule grants, in table
+// order. A rule granting everything only reports can-do-anything. Rules
+// limited to ResourceNames only grant the capability on those objects, and
+// never grant create on a resource, since create requests carry no name to
+// match.
+func ruleCapabilities(rule rbacv1.PolicyRule) []grantedCapability {
+	var caps []grantedCapability
+	seen := make(map[string]bool)
+	scoped := len(rule.ResourceNames) > 0
+	for _, c := range rbacCapabilities {
+		if seen[c.Name] {
+			continue
+		}
+		for _, verb := range c.Verbs {
+			if scoped && verb == "create" && !strings.Contains(c.Resource, "/") {
+				continue
+			}
+			if ruleAllows(rule, verb, c.Group, c.Resource) {
+				granted := grantedCapability{rbacCapability: c}
+				if scoped {
+					granted.ResourceNames = rule.ResourceNames
+				} else if c.Name == "can-do-anything" {
+					return []grantedCapability{granted}
+				}
+				seen[c.Name] = true
+				caps = append(caps, granted)
+				break
+			}
+		}
+	}
+	return caps
+}
+
+// checkRoleCapabilities reports the capabilities each Role and ClusterRole
+// grants, whether or not anything is bound to it yet.
+func checkRoleCapabilities(client kubernetes.Interface) []RiskFinding {
+	var findings []RiskFinding
+	report := func(kind, namespace, name string, rules []rbacv1.PolicyRule) {
+		seen := make(map[string]bool)
+		for _, rule := range rules {
+			for _, c := range ruleCapabilities(rule) {
+				if seen[c.Name+c.Target()] {
+					continue
+				}
+				seen[c.Name+c.Target()] = true
+				findings = append(findings, RiskFinding{
+					Severity: c.Severity,
+					Message:  fmt.Sprintf("[%s] %s %s grants %s%s", c.Severity, kind, displayName(namespace, name), c.Name, c.Target()),
+					Object:   resourceID(kind, namespace, name),
+				})
+			}
+		}
+	}
+
+	if roles, err := client.RbacV1().Roles("").List(context.TODO(), metav1.ListOptions{}); err == nil {
+		for _, r := range roles.Items {
+			report("Role", r.Namespace, r.Name, r.Rules)
+		}
+	}
+	if roles, err := client.RbacV1().ClusterRoles().List(context.TODO(), metav1.ListOptions{}); err == nil {
+		for _, r := range roles.Items {
+			report("ClusterRole", "", r.Name, r.Rules)
+		}
+	}
+	return findings
+}
+
+// displayName returns namespace/name, or name for cluster-scoped objects.
+func displayName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
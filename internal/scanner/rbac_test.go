@@ -0,0 +1,161 @@
+package scanner
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestServiceAccountBindings(t *testing.T) {
+	rb := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "rb", Namespace: "ns1"},
+		Subjects:   []rbacv1.Subject{{Kind: "ServiceAccount", Name: "app"}},
+		RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "reader"},
+	}
+	nsGroup := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "ns1-sas"},
+		Subjects:   []rbacv1.Subject{{Kind: "Group", Name: "system:serviceaccounts:ns1"}},
+		RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "view"},
+	}
+	otherNS := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "ns2-app"},
+		Subjects:   []rbacv1.Subject{{Kind: "ServiceAccount", Name: "app", Namespace: "ns2"}},
+		RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+	}
+
+	idx, err := buildRBACIndex("", fake.NewSimpleClientset(rb, nsGroup, otherNS))
+	if err != nil {
+		t.Fatalf("buildRBACIndex: %v", err)
+	}
+
+	got := idx.serviceAccountBindings("ns1", "app")
+	if len(got) != 2 {
+		t.Fatalf("expected 2 bindings for ns1/app, got %+v", got)
+	}
+	if got[0].Name != "rb" || got[1].Name != "ns1-sas" {
+		t.Errorf("unexpected bindings: %+v", got)
+	}
+	if len(idx.serviceAccountBindings("ns1", "other")) != 1 {
+		t.Error("expected group binding to apply to every SA in ns1")
+	}
+	if len(idx.serviceAccountBindings("ns3", "app")) != 0 {
+		t.Error("expected no bindings for ns3/app")
+	}
+}
+
+func TestRuleCapabilities(t *testing.T) {
+	tests := []struct {
+		rule rbacv1.PolicyRule
+		want []string
+	}{
+		{rbacv1.PolicyRule{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}, []string{"can-do-anything"}},
+		{rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"secrets", "pods"}, Verbs: []string{"get", "create"}}, []string{"can-read-secrets", "can-create-pods"}},
+		{rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods/exec"}, Verbs: []string{"create"}}, []string{"can-exec-pods"}},
+		{rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get"}}, nil},
+		{rbacv1.PolicyRule{APIGroups: []string{"batch"}, Resources: []string{"cronjobs"}, Verbs: []string{"create"}}, []string{"can-create-workloads"}},
+		{rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"groups"}, Verbs: []string{"impersonate"}}, []string{"can-impersonate"}},
+		{rbacv1.PolicyRule{APIGroups: []string{"authentication.k8s.io"}, Resources: []string{"userextras/scopes"}, Verbs: []string{"impersonate"}}, []string{"can-impersonate"}},
+		{rbacv1.PolicyRule{APIGroups: []string{"authentication.k8s.io"}, Resources: []string{"userextras"}, Verbs: []string{"impersonate"}}, nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, c := range ruleCapabilities(tt.rule) {
+			got = append(got, c.Name)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("ruleCapabilities(%+v) = %v, want %v", tt.rule, got, tt.want)
+		}
+	}
+}
+
+func TestRuleCapabilities_EveryEntry(t *testing.T) {
+	for _, c := range rbacCapabilities {
+		for _, verb := range c.Verbs {
+			rule := rbacv1.PolicyRule{
+				APIGroups: []string{c.Group},
+				Resources: []string{strings.Replace(c.Resource, "/*", "/scopes", 1)},
+				Verbs:     []string{verb},
+			}
+			var got []string
+			for _, granted := range ruleCapabilities(rule) {
+				got = append(got, granted.Name)
+			}
+			if !slices.Contains(got, c.Name) {
+				t.Errorf("%s %s.%s: got %v, want %s", verb, c.Resource, c.Group, got, c.Name)
+			}
+		}
+	}
+}
+
+func TestRuleCapabilities_ResourceNames(t *testing.T) {
+	tests := []struct {
+		rule rbacv1.PolicyRule
+		want []string
+	}{
+		{
+			rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}, ResourceNames: []string{"app-config"}},
+			[]string{"can-read-secrets on secrets app-config"},
+		},
+		{
+			rbacv1.PolicyRule{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"patch"}, ResourceNames: []string{"web", "api"}},
+			[]string{"can-create-workloads on deployments web, api"},
+		},
+		// Create requests cannot be limited by name
+		{
+			rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"create"}, ResourceNames: []string{"web"}},
+			nil,
+		},
+		{
+			rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods/exec"}, Verbs: []string{"create"}, ResourceNames: []string{"web"}},
+			[]string{"can-exec-pods on pods/exec web"},
+		},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, c := range ruleCapabilities(tt.rule) {
+			got = append(got, c.Name+c.Target())
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("ruleCapabilities(%+v) = %v, want %v", tt.rule, got, tt.want)
+		}
+	}
+
+	role := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{Name: "config-reader", Namespace: "ns1"},
+		Rules:      []rbacv1.PolicyRule{tests[0].rule},
+	}
+	findings := checkRoleCapabilities(fake.NewSimpleClientset(role))
+	if len(findings) != 1 || findings[0].Message != "[HIGH] Role ns1/config-reader grants can-read-secrets on secrets app-config" {
+		t.Errorf("unexpected findings: %+v", findings)
+	}
+}
+
+func TestCheckRoleCapabilities(t *testing.T) {
+	role := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{Name: "reader", Namespace: "ns1"},
+		Rules: []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}},
+			{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"list"}},
+			{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get"}},
+		},
+	}
+	admin := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{Name: "everything"},
+		Rules:      []rbacv1.PolicyRule{{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}},
+	}
+
+	findings := checkRoleCapabilities(fake.NewSimpleClientset(role, admin))
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %+v", findings)
+	}
+	if findings[0].Message != "[HIGH] Role ns1/reader grants can-read-secrets" || findings[0].Object != "Role/ns1/reader" {
+		t.Errorf("unexpected Role finding: %+v", findings[0])
+	}
+	if findings[1].Severity != "CRITICAL" || findings[1].Object != "ClusterRole/everything" {
+		t.Errorf("unexpected ClusterRole finding: %+v", findings[1])
+	}
+}
@@ -0,0 +1,220 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/khaugen7/eks-security-scanner/internal/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// credentialPatterns match well-known credential formats. They are checked
+// before the entropy heuristic and reported at a higher severity.
+var credentialPatterns = []struct {
+	Name    string
+	Pattern *regexp.Regexp
+}{
+	{"an AWS access key ID", regexp.MustCompile(`\b(AKIA|ASIA|AGPA|AIDA|AROA|AIPA|ANPA|ANVA)[A-Z0-9]{16}\b`)},
+	{"a private key", regexp.MustCompile(`-----BEGIN ([A-Z]+ )*PRIVATE KEY( BLOCK)?-----`)},
+	{"a JSON Web Token", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{10,}`)},
+	{"a database URL with a password", regexp.MustCompile(`(?i)\b(postgres|postgresql|mysql|mariadb|mongodb|mongodb\+srv|redis|rediss|amqp|amqps|mssql|sqlserver)://[^:/\s@]+:[^@\s]+@`)},
+}
+
+// pemCertificate matches public certificates, which are high-entropy by
+// nature but not secret.
+var pemCertificate = regexp.MustCompile(`(?s)-----BEGIN CERTIFICATE-----.*?-----END CERTIFICATE-----`)
+
+// ignoredAnnotations duplicate data the scanner already inspects elsewhere,
+// or hold content hashes such as the one the kubelet records for static pods.
+var ignoredAnnotations = map[string]bool{
+	"kubectl.kubernetes.io/last-applied-configuration": true,
+	"kubernetes.io/config.hash":                        true,
+}
+
+// ignoredAnnotationPrefixes mark annotations holding content hashes, like
+// the checksum/config annotation Helm charts use to roll pods.
+var ignoredAnnotationPrefixes = []string{"checksum/"}
+
+// digestLengths are the lengths of hex-encoded SHA-1 and SHA-256 digests.
+// Annotations holding only a digest are hashes of content, not secrets.
+var digestLengths = map[int]bool{40: true, 64: true}
+
+const (
+	minSecretLength   = 20
+	base64EntropyBits = 4.5
+	hexEntropyBits    = 3.0
+)
+
+// SecretsResources are the cluster resources RunSecretsCheck reads.
+var SecretsResources = []string{"pods", "configmaps"}
+
+func RunSecretsCheck(namespace string, client kubernetes.Interface) {
+	utils.PrintScannerHeader("Secrets Hygiene Scanner")
+
+	pods, err := client.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		fmt.Printf("Failed to list pods: %v\n", err)
+		return
+	}
+	configMaps, err := client.CoreV1().ConfigMaps(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		fmt.Printf("Failed to list configmaps: %v\n", err)
+		return
+	}
+
+	fmt.Printf("\n[+] Scanning env vars, ConfigMaps and annotations in namespace: %s for exposed secrets...\n", namespace)
+
+	var high, med int
+	report := func(severity, location, kind string) {
+		// Only the location is printed: the value itself must never reach
+		// the terminal or CI logs.
+		fmt.Printf("[%s] %s contains %s\n", severity, location, kind)
+		if severity == "HIGH" {
+			high++
+		} else {
+			med++
+		}
+	}
+
+	for _, pod := range pods.Items {
+		podID := fmt.Sprintf("%s/%s", pod.Namespace, pod.Name)
+
+		for _, c := range allContainers(pod.Spec) {
+			for _, env := range c.Env {
+				if severity, kind := detectSecret(env.Value); severity != "" {
+					report(severity, fmt.Sprintf("Pod %s container %s env %s", podID, c.Name, env.Name), kind)
+				}
+			}
+		}
+
+		for _, key := range sortedKeys(pod.Annotations) {
+			if ignoredAnnotation(key, pod.Annotations[key]) {
+				continue
+			}
+			if severity, kind := detectSecret(pod.Annotations[key]); severity != "" {
+				report(severity, fmt.Sprintf("Pod %s annotation %s", podID, key), kind)
+			}
+		}
+	}
+
+	for _, cm := range configMaps.Items {
+		cmID := fmt.Sprintf("%s/%s", cm.Namespace, cm.Name)
+		for _, key := range sortedKeys(cm.Data) {
+			if severity, kind := detectSecret(cm.Data[key]); severity != "" {
+				report(severity, fmt.Sprintf("ConfigMap %s key %s", cmID, key), kind)
+			}
+		}
+		// binaryData arrives base64-decoded, so kubeconfigs and PEM bundles
+		// stored there are checked as text. Binary keystores are not parsed.
+		for _, key := range sortedKeys(cm.BinaryData) {
+			if severity, kind := detectSecret(string(cm.BinaryData[key])); severity != "" {
+				report(severity, fmt.Sprintf("ConfigMap %s binaryData key %s", cmID, key), kind)
+			}
+		}
+	}
+
+	fmt.Printf("\n[✓] Secrets Hygiene Summary\n")
+	fmt.Printf("    Namespace Scanned       : %s\n", namespace)
+	fmt.Printf("    Total Pods Scanned      : %d\n", len(pods.Items))
+	fmt.Printf("    ConfigMaps Scanned      : %d\n", len(configMaps.Items))
+	fmt.Printf("    High Severity Findings  : %d\n", high)
+	fmt.Printf("    Medium Severity Findings: %d\n", med)
+}
+
+// ignoredAnnotation reports whether an annotation is known not to hold a
+// secret: duplicated data, a hash annotation or a bare hex digest.
+func ignoredAnnotation(key, value string) bool {
+	if ignoredAnnotations[key] {
+		return true
+	}
+	for _, prefix := range ignoredAnnotationPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	value = strings.TrimSpace(value)
+	return digestLengths[len(value)] && isCharset(value, "0123456789abcdefABCDEF")
+}
+
+// detectSecret returns the severity and a description of the credential found
+// in value, or an empty severity if it looks clean. Known credential formats
+// are HIGH; otherwise unexplained high-entropy strings are MED.
+func detectSecret(value string) (string, string) {
+	if value == "" {
+		return "", ""
+	}
+
+	for _, p := range credentialPatterns {
+		if p.Pattern.MatchString(value) {
+			return "HIGH", p.Name
+		}
+	}
+
+	value = pemCertificate.ReplaceAllString(value, "")
+	for _, token := range strings.FieldsFunc(value, isTokenSeparator) {
+		if highEntropy(token) {
+			return "MED", "a high-entropy string that may be a secret"
+		}
+	}
+	return "", ""
+}
+
+func isTokenSeparator(r rune) bool {
+	switch r {
+	case ' ', '\t', '\n', '\r', '"', '\'', '`', '=', ':', ',', ';', '(', ')', '[', ']', '{', '}', '<', '>':
+		return true
+	}
+	return false
+}
+
+// highEntropy applies the usual secret-scanner heuristic: long base64 or hex
+// tokens whose Shannon entropy is close to random.
+func highEntropy(token string) bool {
+	if len(token) < minSecretLength {
+		return false
+	}
+	switch {
+	case isCharset(token, "0123456789abcdefABCDEF"):
+		return shannonEntropy(token) >= hexEntropyBits
+	case isCharset(token, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/=_-"):
+		return shannonEntropy(token) >= base64EntropyBits
+	}
+	return false
+}
+
+func isCharset(s, charset string) bool {
+	for _, r := range s {
+		if !strings.ContainsRune(charset, r) {
+			return false
+		}
+	}
+	return true
+}
+
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	var entropy float64
+	n := float64(len(s))
+	for _, c := range counts {
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,132 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// AWSExchange is one recorded AWS API request and its response. Requests are
+// matched on method, URL and body; signatures and other headers are ignored.
+type AWSExchange struct {
+	Method      string `json:"method"`
+	URL         string `json:"url"`
+	Body        string `json:"body,omitempty"`
+	Status      int    `json:"status"`
+	ContentType string `json:"contentType,omitempty"`
+	Response    string `json:"response"`
+}
+
+func (e AWSExchange) key() string {
+	return e.Method + " " + e.URL + "\n" + e.Body
+}
+
+// RecordAWS returns a copy of cfg whose HTTP client stores every exchange in
+// the snapshot, so the same calls can be answered offline later.
+func (s *Snapshot) RecordAWS(cfg aws.Config) aws.Config {
+	s.AWSRegion = cfg.Region
+	next := cfg.HTTPClient
+	if next == nil {
+		next = http.DefaultClient
+	}
+	cfg.HTTPClient = &awsRecorder{next: next, snapshot: s}
+	return cfg
+}
+
+// ReplayAWS returns an AWS config that answers requests from the recorded
+// exchanges. Requests that were not recorded fail without retrying, so the
+// scanners report them the way they report missing credentials.
+func (s *Snapshot) ReplayAWS(ctx context.Context) (aws.Config, error) {
+	responses := make(map[string]AWSExchange, len(s.AWS))
+	for _, e := range s.AWS {
+		// Retried requests are recorded more than once; the last answer wins.
+		responses[e.key()] = e
+	}
+	return aws.Config{
+		Region: s.AWSRegion,
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "snapshot", SecretAccessKey: "snapshot", Source: "snapshot"}, nil
+		}),
+		HTTPClient: awsReplayer(responses),
+		Retryer:    func() aws.Retryer { return aws.NopRetryer{} },
+	}, nil
+}
+
+type awsRecorder struct {
+	next     aws.HTTPClient
+	snapshot *Snapshot
+	mu       sync.Mutex
+}
+
+func (r *awsRecorder) Do(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.next.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	r.mu.Lock()
+	r.snapshot.AWS = append(r.snapshot.AWS, AWSExchange{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		Body:        string(body),
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Response:    string(data),
+	})
+	r.mu.Unlock()
+	return resp, nil
+}
+
+type awsReplayer map[string]AWSExchange
+
+func (r awsReplayer) Do(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	e, ok := r[AWSExchange{Method: req.Method, URL: req.URL.String(), Body: string(body)}.key()]
+	if !ok {
+		return nil, fmt.Errorf("%s %s was not recorded in the snapshot", req.Method, req.URL.Host+req.URL.Path)
+	}
+	header := http.Header{}
+	if e.ContentType != "" {
+		header.Set("Content-Type", e.ContentType)
+	}
+	return &http.Response{
+		Status:        http.StatusText(e.Status),
+		StatusCode:    e.Status,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(e.Response))),
+		ContentLength: int64(len(e.Response)),
+		Request:       req,
+	}, nil
+}
+
+// readRequestBody reads the request body and puts it back for the transport.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
@@ -0,0 +1,60 @@
+package snapshot
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestRecordReplayAWS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/xml")
+		io.WriteString(w, "<answer>"+string(body)+"</answer>")
+	}))
+	defer server.Close()
+
+	s := &Snapshot{}
+	cfg := s.RecordAWS(aws.Config{Region: "eu-west-1", HTTPClient: server.Client()})
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/?Action=GetRole", strings.NewReader("RoleName=admin"))
+	resp, err := cfg.HTTPClient.Do(req)
+	if err != nil {
+		t.Fatalf("recording request: %v", err)
+	}
+	live, _ := io.ReadAll(resp.Body)
+	if string(live) != "<answer>RoleName=admin</answer>" {
+		t.Fatalf("recorder changed the response: %q", live)
+	}
+	if s.AWSRegion != "eu-west-1" || len(s.AWS) != 1 {
+		t.Fatalf("recorded region %q and %d exchanges", s.AWSRegion, len(s.AWS))
+	}
+
+	replay, err := s.ReplayAWS(context.TODO())
+	if err != nil {
+		t.Fatalf("ReplayAWS: %v", err)
+	}
+	if replay.Region != "eu-west-1" {
+		t.Errorf("replay region = %q", replay.Region)
+	}
+
+	req, _ = http.NewRequest(http.MethodPost, server.URL+"/?Action=GetRole", strings.NewReader("RoleName=admin"))
+	resp, err = replay.HTTPClient.Do(req)
+	if err != nil {
+		t.Fatalf("replaying recorded request: %v", err)
+	}
+	replayed, _ := io.ReadAll(resp.Body)
+	if string(replayed) != string(live) || resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/xml" {
+		t.Errorf("replayed %d %q %q", resp.StatusCode, resp.Header.Get("Content-Type"), replayed)
+	}
+
+	req, _ = http.NewRequest(http.MethodPost, server.URL+"/?Action=GetRole", strings.NewReader("RoleName=other"))
+	if _, err := replay.HTTPClient.Do(req); err == nil || !strings.Contains(err.Error(), "not recorded") {
+		t.Errorf("unrecorded request error = %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// Clientset serves a snapshot through the regular client-go interface. It is
+// backed by the client-go fake object tracker, which filters by namespace
+// and label selector like the API server but ignores field selectors.
+type Clientset struct {
+	*fake.Clientset
+	gateway map[string]json.RawMessage
+}
+
+// Client returns a kubernetes.Interface reading from the snapshot. Resources
+// listed in Errors fail with the recorded error on every request, and
+// resources outside the collection scope fail as not collected rather than
+// appearing empty.
+func (s *Snapshot) Client() *Clientset {
+	var objects []runtime.Object
+	add := func(obj runtime.Object) { objects = append(objects, obj) }
+
+	for i := range s.Namespaces {
+		add(&s.Namespaces[i])
+	}
+	for i := range s.Nodes {
+		add(&s.Nodes[i])
+	}
+	for i := range s.Pods {
+		add(&s.Pods[i])
+	}
+	for i := range s.ServiceAccounts {
+		add(&s.ServiceAccounts[i])
+	}
+	for i := range s.Services {
+		add(&s.Services[i])
+	}
+	for i := range s.ConfigMaps {
+		add(&s.ConfigMaps[i])
+	}
+	for i := range s.Secrets {
+		add(&s.Secrets[i])
+	}
+	for i := range s.ResourceQuotas {
+		add(&s.ResourceQuotas[i])
+	}
+	for i := range s.LimitRanges {
+		add(&s.LimitRanges[i])
+	}
+	for i := range s.EndpointSlices {
+		add(&s.EndpointSlices[i])
+	}
+	for i := range s.Ingresses {
+		add(&s.Ingresses[i])
+	}
+	for i := range s.NetworkPolicies {
+		add(&s.NetworkPolicies[i])
+	}
+	for i := range s.Roles {
+		add(&s.Roles[i])
+	}
+	for i := range s.ClusterRoles {
+		add(&s.ClusterRoles[i])
+	}
+	for i := range s.RoleBindings {
+		add(&s.RoleBindings[i])
+	}
+	for i := range s.ClusterRoleBindings {
+		add(&s.ClusterRoleBindings[i])
+	}
+
+	cs := fake.NewSimpleClientset(objects...)
+	for _, resource := range AllResources {
+		if s.Collected(resource) {
+			continue
+		}
+		err := fmt.Errorf("%s were not collected for this scan", resource)
+		cs.PrependReactor("*", resource, func(k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, err
+		})
+	}
+	for resource, msg := range s.Errors {
+		err := errors.New(msg)
+		cs.PrependReactor("*", resource, func(k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, err
+		})
+	}
+	return &Clientset{Clientset: cs, gateway: s.Gateway}
+}
+
+// GatewayList returns the collected list response for a Gateway API
+// resource such as httproutes, and whether the resource was collected.
+func (c *Clientset) GatewayList(resource string) ([]byte, bool) {
+	data, ok := c.gateway[resource]
+	return data, ok
+}
@@ -0,0 +1,75 @@
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// FormatVersion is the snapshot file format written by this version.
+const FormatVersion = 1
+
+// Save writes the snapshot as JSON, gzip-compressed if path ends in .gz.
+// Secrets are redacted again first, so a snapshot not built by Collect
+// cannot write their values either.
+func (s *Snapshot) Save(path string) error {
+	redactSecrets(s.Secrets)
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = f
+	var gz *gzip.Writer
+	if strings.HasSuffix(path, ".gz") {
+		gz = gzip.NewWriter(f)
+		w = gz
+	}
+	if err := json.NewEncoder(w).Encode(s); err != nil {
+		f.Close()
+		return err
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// Load reads a snapshot written by Save. Compression is detected from the
+// file contents rather than its name.
+func Load(path string) (*Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var src io.Reader = r
+	if magic, _ := r.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		src = gz
+	}
+
+	var s Snapshot
+	if err := json.NewDecoder(src).Decode(&s); err != nil {
+		return nil, fmt.Errorf("decoding snapshot %s: %w", path, err)
+	}
+	if s.Version != FormatVersion {
+		return nil, fmt.Errorf("snapshot %s has format version %d, this build reads version %d", path, s.Version, FormatVersion)
+	}
+	return &s, nil
+}
@@ -0,0 +1,99 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSaveLoad_RoundTrip(t *testing.T) {
+	for _, name := range []string{"snap.json", "snap.json.gz"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			s := &Snapshot{
+				Version:     FormatVersion,
+				Cluster:     "prod",
+				CollectedAt: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+				Pods:        []corev1.Pod{{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"}}},
+				AWSRegion:   "us-east-1",
+				AWS:         []AWSExchange{{Method: "GET", URL: "https://iam.amazonaws.com/", Status: 200, Response: "<ok/>"}},
+			}
+			if err := s.Save(path); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+
+			data, _ := os.ReadFile(path)
+			gzipped := len(data) > 1 && data[0] == 0x1f && data[1] == 0x8b
+			if gzipped != strings.HasSuffix(name, ".gz") {
+				t.Errorf("gzipped = %v for %s", gzipped, name)
+			}
+
+			got, err := Load(path)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if got.Cluster != "prod" || !got.CollectedAt.Equal(s.CollectedAt) || got.AWSRegion != "us-east-1" {
+				t.Errorf("metadata not round-tripped: %+v", got)
+			}
+			if len(got.Pods) != 1 || got.Pods[0].Name != "web" {
+				t.Errorf("pods not round-tripped: %+v", got.Pods)
+			}
+			if len(got.AWS) != 1 || got.AWS[0].Response != "<ok/>" {
+				t.Errorf("AWS exchanges not round-tripped: %+v", got.AWS)
+			}
+		})
+	}
+}
+
+func TestLoad_RejectsOtherVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snap.json")
+	if err := os.WriteFile(path, []byte(`{"version": 99}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "version 99") {
+		t.Errorf("Load error = %v, want version mismatch", err)
+	}
+}
+
+func TestSave_RedactsAppliedSecret(t *testing.T) {
+	applied := `{"apiVersion":"v1","data":{"password":"aHVudGVyMg=="},"kind":"Secret","metadata":{"name":"db","namespace":"app"}}`
+	s := &Snapshot{
+		Version: FormatVersion,
+		Secrets: []corev1.Secret{{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "app",
+				Name:      "db",
+				Annotations: map[string]string{
+					"kubectl.kubernetes.io/last-applied-configuration": applied,
+					"team": "payments",
+				},
+				ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl", FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:data":{"f:password":{}}}`)}}},
+			},
+			Data: map[string][]byte{"password": []byte("hunter2")},
+		}},
+	}
+	path := filepath.Join(t.TempDir(), "snap.json")
+	if err := s.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	for _, leaked := range []string{"aHVudGVyMg==", "hunter2", "last-applied-configuration", "f:password"} {
+		if strings.Contains(string(data), leaked) {
+			t.Errorf("snapshot file contains %q", leaked)
+		}
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(got.Secrets) != 1 || got.Secrets[0].Name != "db" || got.Secrets[0].Annotations["team"] != "payments" {
+		t.Errorf("secret metadata not kept: %+v", got.Secrets)
+	}
+}
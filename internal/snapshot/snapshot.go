@@ -0,0 +1,367 @@
+// Package snapshot collects every cluster resource the scanners read in one
+// paginated pass and serves it back through a kubernetes.Interface, so all
+// scanners in a run see the same state without listing it again.
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+// PageSize is the number of objects requested per List call.
+const PageSize = 500
+
+// GatewayAPIPath is where the Gateway API resources are served, for both
+// live scans and snapshots. They are not part of client-go, so the snapshot
+// keeps their raw list responses.
+const GatewayAPIPath = "/apis/gateway.networking.k8s.io/v1"
+
+// GatewayResources are the Gateway API resources collected.
+var GatewayResources = []string{"gateways", "httproutes", "grpcroutes"}
+
+// AllResources names every resource Collect can list, as used in Scope and
+// Errors.
+var AllResources = append([]string{
+	"namespaces", "nodes", "pods", "serviceaccounts", "services", "configmaps",
+	"secrets", "resourcequotas", "limitranges", "endpointslices", "ingresses",
+	"networkpolicies", "roles", "clusterroles", "rolebindings", "clusterrolebindings",
+}, GatewayResources...)
+
+// Scope limits what Collect lists, so a scan only needs RBAC for what its
+// scanners read.
+type Scope struct {
+	// Namespace restricts namespaced resources to one namespace. Empty
+	// collects every namespace.
+	Namespace string
+
+	// Resources names the resources to list, from AllResources. Nil lists
+	// all of them.
+	Resources []string
+}
+
+// secretMetadataAccept asks the API server for Secret metadata only, so
+// Secret values are never sent to the scanner.
+const secretMetadataAccept = "application/json;as=PartialObjectMetadataList;g=meta.k8s.io;v=v1"
+
+// Snapshot is the cluster state a scan runs against. Secrets are stored
+// without their data: the scanners only need names, token types and
+// annotations.
+type Snapshot struct {
+	// Version is the file format version written by Save.
+	Version     int       `json:"version"`
+	Cluster     string    `json:"cluster,omitempty"`
+	CollectedAt time.Time `json:"collectedAt"`
+
+	// Namespace and Resources record the Scope the snapshot was collected
+	// with. Resources outside it are reported as not collected.
+	Namespace string   `json:"namespace,omitempty"`
+	Resources []string `json:"resources,omitempty"`
+
+	Namespaces          []corev1.Namespace           `json:"namespaces"`
+	Nodes               []corev1.Node                `json:"nodes"`
+	Pods                []corev1.Pod                 `json:"pods"`
+	ServiceAccounts     []corev1.ServiceAccount      `json:"serviceAccounts"`
+	Services            []corev1.Service             `json:"services"`
+	ConfigMaps          []corev1.ConfigMap           `json:"configMaps"`
+	Secrets             []corev1.Secret              `json:"secrets"`
+	ResourceQuotas      []corev1.ResourceQuota       `json:"resourceQuotas"`
+	LimitRanges         []corev1.LimitRange          `json:"limitRanges"`
+	EndpointSlices      []discoveryv1.EndpointSlice  `json:"endpointSlices"`
+	Ingresses           []networkingv1.Ingress       `json:"ingresses"`
+	NetworkPolicies     []networkingv1.NetworkPolicy `json:"networkPolicies"`
+	Roles               []rbacv1.Role                `json:"roles"`
+	ClusterRoles        []rbacv1.ClusterRole         `json:"clusterRoles"`
+	RoleBindings        []rbacv1.RoleBinding         `json:"roleBindings"`
+	ClusterRoleBindings []rbacv1.ClusterRoleBinding  `json:"clusterRoleBindings"`
+
+	// Gateway holds the raw list response for each Gateway API resource,
+	// keyed by resource name. Missing when the CRDs are not installed.
+	Gateway map[string]json.RawMessage `json:"gateway,omitempty"`
+
+	// Errors records resources that could not be listed, keyed by resource
+	// name. The snapshot client returns the same error for them, so scanners
+	// report it as they would against the live cluster.
+	Errors map[string]string `json:"errors,omitempty"`
+
+	// AWS holds the AWS API responses recorded while saving the snapshot,
+	// and the region they were made in.
+	AWSRegion string        `json:"awsRegion,omitempty"`
+	AWS       []AWSExchange `json:"aws,omitempty"`
+}
+
+// Collect lists the resources in scope. Resources that fail to list, e.g.
+// because RBAC forbids it, are recorded in Errors rather than failing the
+// whole collection.
+func Collect(ctx context.Context, client kubernetes.Interface, scope Scope) *Snapshot {
+	s := &Snapshot{
+		Version:     FormatVersion,
+		CollectedAt: time.Now().UTC(),
+		Namespace:   scope.Namespace,
+		Resources:   scope.Resources,
+		Errors:      map[string]string{},
+	}
+	collect := func(resource string, list func() error) {
+		if !s.Collected(resource) {
+			return
+		}
+		if err := list(); err != nil {
+			s.Errors[resource] = err.Error()
+		}
+	}
+
+	ns := scope.Namespace
+	core, disc, netv1, rbac := client.CoreV1(), client.DiscoveryV1(), client.NetworkingV1(), client.RbacV1()
+	var err error
+
+	collect("namespaces", func() error {
+		s.Namespaces, err = listAll[corev1.Namespace](ctx, func(o metav1.ListOptions) (runtime.Object, error) { return core.Namespaces().List(ctx, o) })
+		return err
+	})
+	collect("nodes", func() error {
+		s.Nodes, err = listAll[corev1.Node](ctx, func(o metav1.ListOptions) (runtime.Object, error) { return core.Nodes().List(ctx, o) })
+		return err
+	})
+	collect("pods", func() error {
+		s.Pods, err = listAll[corev1.Pod](ctx, func(o metav1.ListOptions) (runtime.Object, error) { return core.Pods(ns).List(ctx, o) })
+		return err
+	})
+	collect("serviceaccounts", func() error {
+		s.ServiceAccounts, err = listAll[corev1.ServiceAccount](ctx, func(o metav1.ListOptions) (runtime.Object, error) { return core.ServiceAccounts(ns).List(ctx, o) })
+		return err
+	})
+	collect("services", func() error {
+		s.Services, err = listAll[corev1.Service](ctx, func(o metav1.ListOptions) (runtime.Object, error) { return core.Services(ns).List(ctx, o) })
+		return err
+	})
+	collect("configmaps", func() error {
+		s.ConfigMaps, err = listAll[corev1.ConfigMap](ctx, func(o metav1.ListOptions) (runtime.Object, error) { return core.ConfigMaps(ns).List(ctx, o) })
+		return err
+	})
+	collect("secrets", func() error {
+		s.Secrets, err = listSecretMetadata(ctx, client, ns)
+		return err
+	})
+	collect("resourcequotas", func() error {
+		s.ResourceQuotas, err = listAll[corev1.ResourceQuota](ctx, func(o metav1.ListOptions) (runtime.Object, error) { return core.ResourceQuotas(ns).List(ctx, o) })
+		return err
+	})
+	collect("limitranges", func() error {
+		s.LimitRanges, err = listAll[corev1.LimitRange](ctx, func(o metav1.ListOptions) (runtime.Object, error) { return core.LimitRanges(ns).List(ctx, o) })
+		return err
+	})
+	collect("endpointslices", func() error {
+		s.EndpointSlices, err = listAll[discoveryv1.EndpointSlice](ctx, func(o metav1.ListOptions) (runtime.Object, error) { return disc.EndpointSlices(ns).List(ctx, o) })
+		return err
+	})
+	collect("ingresses", func() error {
+		s.Ingresses, err = listAll[networkingv1.Ingress](ctx, func(o metav1.ListOptions) (runtime.Object, error) { return netv1.Ingresses(ns).List(ctx, o) })
+		return err
+	})
+	collect("networkpolicies", func() error {
+		s.NetworkPolicies, err = listAll[networkingv1.NetworkPolicy](ctx, func(o metav1.ListOptions) (runtime.Object, error) { return netv1.NetworkPolicies(ns).List(ctx, o) })
+		return err
+	})
+	collect("roles", func() error {
+		s.Roles, err = listAll[rbacv1.Role](ctx, func(o metav1.ListOptions) (runtime.Object, error) { return rbac.Roles(ns).List(ctx, o) })
+		return err
+	})
+	collect("clusterroles", func() error {
+		s.ClusterRoles, err = listAll[rbacv1.ClusterRole](ctx, func(o metav1.ListOptions) (runtime.Object, error) { return rbac.ClusterRoles().List(ctx, o) })
+		return err
+	})
+	collect("rolebindings", func() error {
+		s.RoleBindings, err = listAll[rbacv1.RoleBinding](ctx, func(o metav1.ListOptions) (runtime.Object, error) { return rbac.RoleBindings(ns).List(ctx, o) })
+		return err
+	})
+	collect("clusterrolebindings", func() error {
+		s.ClusterRoleBindings, err = listAll[rbacv1.ClusterRoleBinding](ctx, func(o metav1.ListOptions) (runtime.Object, error) { return rbac.ClusterRoleBindings().List(ctx, o) })
+		return err
+	})
+
+	s.Gateway = collectGatewayResources(ctx, client, s)
+	return s
+}
+
+// Collected reports whether resource is in the snapshot's scope.
+func (s *Snapshot) Collected(resource string) bool {
+	return s.Resources == nil || slices.Contains(s.Resources, resource)
+}
+
+// listSecretMetadata lists Secrets as PartialObjectMetadata, so their values
+// never leave the API server. Metadata carries no type, so ServiceAccount
+// token Secrets are found with a second, field-selected list. Clients that
+// cannot make raw requests fall back to a typed list with the data dropped.
+func listSecretMetadata(ctx context.Context, client kubernetes.Interface, namespace string) ([]corev1.Secret, error) {
+	rc, ok := client.CoreV1().RESTClient().(*rest.RESTClient)
+	if !ok || rc == nil {
+		secrets, err := listAll[corev1.Secret](ctx, func(o metav1.ListOptions) (runtime.Object, error) {
+			return client.CoreV1().Secrets(namespace).List(ctx, o)
+		})
+		redactSecrets(secrets)
+		return secrets, err
+	}
+
+	list := func(fieldSelector string) ([]metav1.PartialObjectMetadata, error) {
+		return listAll[metav1.PartialObjectMetadata](ctx, func(o metav1.ListOptions) (runtime.Object, error) {
+			o.FieldSelector = fieldSelector
+			page := &metav1.PartialObjectMetadataList{}
+			err := rc.Get().
+				Namespace(namespace).
+				Resource("secrets").
+				VersionedParams(&o, scheme.ParameterCodec).
+				SetHeader("Accept", secretMetadataAccept).
+				Do(ctx).
+				Into(page)
+			return page, err
+		})
+	}
+
+	all, err := list("")
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := list("type=" + string(corev1.SecretTypeServiceAccountToken))
+	if err != nil {
+		return nil, err
+	}
+	isToken := make(map[string]bool, len(tokens))
+	for _, t := range tokens {
+		isToken[t.Namespace+"/"+t.Name] = true
+	}
+
+	secrets := make([]corev1.Secret, len(all))
+	for i, m := range all {
+		secrets[i] = corev1.Secret{ObjectMeta: m.ObjectMeta}
+		if isToken[m.Namespace+"/"+m.Name] {
+			secrets[i].Type = corev1.SecretTypeServiceAccountToken
+		}
+	}
+	redactSecrets(secrets)
+	return secrets, nil
+}
+
+// lastAppliedAnnotation holds the object as last applied by kubectl, which
+// for a Secret includes its data.
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// redactSecrets drops everything that can carry Secret values: the data
+// itself, the last-applied annotation and managedFields.
+func redactSecrets(secrets []corev1.Secret) {
+	for i := range secrets {
+		secret := &secrets[i]
+		secret.Data, secret.StringData = nil, nil
+		secret.ManagedFields = nil
+		if _, ok := secret.Annotations[lastAppliedAnnotation]; ok {
+			annotations := maps.Clone(secret.Annotations)
+			delete(annotations, lastAppliedAnnotation)
+			secret.Annotations = annotations
+		}
+	}
+}
+
+// listAll pages through a List call with Limit/Continue and returns the
+// items of every page.
+func listAll[T any](ctx context.Context, list func(metav1.ListOptions) (runtime.Object, error)) ([]T, error) {
+	var items []T
+	opts := metav1.ListOptions{Limit: PageSize}
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		page, err := list(opts)
+		if err != nil {
+			return nil, err
+		}
+		objects, err := meta.ExtractList(page)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objects {
+			item, ok := any(obj).(*T)
+			if !ok {
+				return nil, fmt.Errorf("unexpected list item %T", obj)
+			}
+			items = append(items, *item)
+		}
+
+		listMeta, err := meta.ListAccessor(page)
+		if err != nil {
+			return nil, err
+		}
+		if listMeta.GetContinue() == "" {
+			return items, nil
+		}
+		opts.Continue = listMeta.GetContinue()
+	}
+}
+
+// collectGatewayResources fetches the Gateway API lists through the raw REST
+// client, following continue tokens and merging the pages into one list.
+// Clusters without the CRDs, and clients that cannot make raw requests,
+// return nil.
+func collectGatewayResources(ctx context.Context, client kubernetes.Interface, s *Snapshot) map[string]json.RawMessage {
+	rc := client.Discovery().RESTClient()
+	if rc == nil {
+		return nil
+	}
+	path := []string{GatewayAPIPath}
+	if s.Namespace != "" {
+		path = append(path, "namespaces", s.Namespace)
+	}
+
+	lists := make(map[string]json.RawMessage)
+	for _, resource := range GatewayResources {
+		if !s.Collected(resource) {
+			continue
+		}
+		var items []json.RawMessage
+		token := ""
+		for {
+			req := rc.Get().AbsPath(append(path, resource)...).Param("limit", fmt.Sprint(PageSize))
+			if token != "" {
+				req = req.Param("continue", token)
+			}
+			data, err := req.DoRaw(ctx)
+			if err != nil {
+				items = nil
+				break
+			}
+			var page struct {
+				Metadata struct {
+					Continue string `json:"continue"`
+				} `json:"metadata"`
+				Items []json.RawMessage `json:"items"`
+			}
+			if err := json.Unmarshal(data, &page); err != nil {
+				items = nil
+				break
+			}
+			items = append(items, page.Items...)
+			if page.Metadata.Continue == "" {
+				merged, _ := json.Marshal(map[string]any{"items": items})
+				lists[resource] = merged
+				break
+			}
+			token = page.Metadata.Continue
+		}
+	}
+	if len(lists) == 0 {
+		return nil
+	}
+	return lists
+}
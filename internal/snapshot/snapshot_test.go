@@ -0,0 +1,188 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestCollect_Paginates(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	var requests []metav1.ListOptions
+	client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		opts := action.(k8stesting.ListActionImpl).GetListOptions()
+		requests = append(requests, opts)
+
+		page := 0
+		if opts.Continue != "" {
+			fmt.Sscanf(opts.Continue, "page-%d", &page)
+		}
+		list := &corev1.PodList{}
+		for i := 0; i < 2; i++ {
+			list.Items = append(list.Items, corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: fmt.Sprintf("p%d-%d", page, i)}})
+		}
+		if page < 2 {
+			list.Continue = fmt.Sprintf("page-%d", page+1)
+		}
+		return true, list, nil
+	})
+
+	s := Collect(context.TODO(), client, Scope{})
+
+	if len(s.Pods) != 6 {
+		t.Fatalf("expected 6 pods across 3 pages, got %d", len(s.Pods))
+	}
+	if s.Pods[5].Name != "p2-1" {
+		t.Errorf("last pod = %s; want p2-1", s.Pods[5].Name)
+	}
+	if len(requests) != 3 {
+		t.Fatalf("expected 3 list requests, got %d", len(requests))
+	}
+	for i, opts := range requests {
+		if opts.Limit != PageSize {
+			t.Errorf("request %d: Limit = %d; want %d", i, opts.Limit, PageSize)
+		}
+	}
+	if requests[2].Continue != "page-2" {
+		t.Errorf("third request Continue = %q; want page-2", requests[2].Continue)
+	}
+}
+
+func TestCollect_StripsSecretData(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "token", Annotations: map[string]string{"kubernetes.io/service-account.name": "app"}},
+		Type:       corev1.SecretTypeServiceAccountToken,
+		Data:       map[string][]byte{"token": []byte("secret")},
+	})
+
+	s := Collect(context.TODO(), client, Scope{})
+	if len(s.Secrets) != 1 {
+		t.Fatalf("expected 1 secret, got %d", len(s.Secrets))
+	}
+	if s.Secrets[0].Data != nil {
+		t.Error("secret data must not be kept in the snapshot")
+	}
+	if s.Secrets[0].Type != corev1.SecretTypeServiceAccountToken || s.Secrets[0].Annotations["kubernetes.io/service-account.name"] != "app" {
+		t.Error("secret metadata should be kept")
+	}
+}
+
+func TestCollect_SecretMetadataOnly(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if !strings.Contains(r.Header.Get("Accept"), "as=PartialObjectMetadataList") {
+			t.Errorf("secrets requested without the metadata Accept header: %q", r.Header.Get("Accept"))
+		}
+		items := []string{
+			`{"metadata":{"namespace":"ns","name":"app-token","annotations":{"kubernetes.io/service-account.name":"app"}}}`,
+		}
+		if r.URL.Query().Get("fieldSelector") == "" {
+			items = append(items, `{"metadata":{"namespace":"ns","name":"db"}}`)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"kind":"PartialObjectMetadataList","apiVersion":"meta.k8s.io/v1","metadata":{},"items":[%s]}`, strings.Join(items, ","))
+	}))
+	defer server.Close()
+
+	client, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := Collect(context.TODO(), client, Scope{Namespace: "ns", Resources: []string{"secrets"}})
+
+	if len(s.Errors) != 0 {
+		t.Fatalf("unexpected errors: %v", s.Errors)
+	}
+	if len(s.Secrets) != 2 {
+		t.Fatalf("expected 2 secrets, got %d", len(s.Secrets))
+	}
+	if s.Secrets[0].Type != corev1.SecretTypeServiceAccountToken || s.Secrets[0].Annotations["kubernetes.io/service-account.name"] != "app" {
+		t.Errorf("token secret = %+v; want its type and annotations", s.Secrets[0])
+	}
+	if s.Secrets[1].Type != "" {
+		t.Errorf("db secret type = %q; want empty", s.Secrets[1].Type)
+	}
+	for _, p := range paths {
+		if p != "/api/v1/namespaces/ns/secrets" {
+			t.Errorf("unexpected request to %s", p)
+		}
+	}
+}
+
+func TestCollect_Scope(t *testing.T) {
+	live := fake.NewSimpleClientset(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "a", Name: "p1"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "b", Name: "p2"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "a", Name: "cm"}},
+	)
+
+	s := Collect(context.TODO(), live, Scope{Namespace: "a", Resources: []string{"pods"}})
+
+	if len(s.Pods) != 1 || s.Pods[0].Name != "p1" {
+		t.Errorf("pods = %v; want only p1 from namespace a", s.Pods)
+	}
+	for _, action := range live.Actions() {
+		if action.GetResource().Resource != "pods" {
+			t.Errorf("listed %s outside the scope", action.GetResource().Resource)
+		}
+	}
+	if _, err := s.Client().CoreV1().ConfigMaps("a").List(context.TODO(), metav1.ListOptions{}); err == nil {
+		t.Error("expected configmaps to fail as not collected")
+	}
+}
+
+func TestClient_ServesSnapshot(t *testing.T) {
+	live := fake.NewSimpleClientset(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "a", Name: "p1", Labels: map[string]string{"app": "x"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "b", Name: "p2"}},
+		&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "view"}},
+	)
+	live.PrependReactor("list", "secrets", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New(`secrets is forbidden: User "scanner" cannot list resource "secrets"`)
+	})
+
+	s := Collect(context.TODO(), live, Scope{})
+	before := len(live.Actions())
+	client := s.Client()
+
+	pods, err := client.CoreV1().Pods("a").List(context.TODO(), metav1.ListOptions{LabelSelector: "app=x"})
+	if err != nil || len(pods.Items) != 1 || pods.Items[0].Name != "p1" {
+		t.Errorf("namespaced list = %v, %v; want p1", pods, err)
+	}
+	if _, err := client.RbacV1().ClusterRoles().Get(context.TODO(), "view", metav1.GetOptions{}); err != nil {
+		t.Errorf("get from snapshot: %v", err)
+	}
+	if _, err := client.CoreV1().Secrets("").List(context.TODO(), metav1.ListOptions{}); err == nil || err.Error() != s.Errors["secrets"] {
+		t.Errorf("expected the recorded secrets error, got %v", err)
+	}
+	if after := len(live.Actions()); after != before {
+		t.Errorf("snapshot client made %d calls to the live cluster", after-before)
+	}
+}
+
+func TestClient_GatewayList(t *testing.T) {
+	s := &Snapshot{Gateway: map[string]json.RawMessage{"gateways": json.RawMessage(`{"items":[]}`)}}
+	client := s.Client()
+	if data, ok := client.GatewayList("gateways"); !ok || string(data) != `{"items":[]}` {
+		t.Errorf("GatewayList(gateways) = %s, %v", data, ok)
+	}
+	if _, ok := client.GatewayList("httproutes"); ok {
+		t.Error("expected httproutes to be missing")
+	}
+}
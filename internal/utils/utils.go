@@ -2,10 +2,18 @@ package utils
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strings"
 )
 
 func PrintScannerHeader(name string) {
+	FprintScannerHeader(os.Stdout, name)
+}
+
+// FprintScannerHeader writes the scanner banner to w, so commands whose
+// output is piped elsewhere can keep it off stdout.
+func FprintScannerHeader(w io.Writer, name string) {
 	border := strings.Repeat("=", len(name)+8)
-	fmt.Printf("\n%s\n>>> %s <<<\n%s\n\n", border, name, border)
+	fmt.Fprintf(w, "\n%s\n>>> %s <<<\n%s\n\n", border, name, border)
 }